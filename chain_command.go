@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// runChainCommand runs the "chain" subcommands.
+func runChainCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("expected a chain subcommand: export or import")
+	}
+
+	switch args[0] {
+	case "export":
+		return runChainExport(args[1:])
+	case "import":
+		return runChainImport(args[1:])
+	default:
+		return fmt.Errorf("unknown chain subcommand %q", args[0])
+	}
+}
+
+// runChainExport writes a height range of a chain file to a new export file.
+func runChainExport(args []string) error {
+	flags := flag.NewFlagSet("chain export", flag.ContinueOnError)
+	chainPath := flags.String("chain", "", "chain file of the node")
+	outPath := flags.String("out", "", "file to write the exported blocks to")
+	from := flags.Uint64("from", 0, "first height to export")
+	to := flags.Uint64("to", math.MaxUint64, "last height to export")
+	compress := flags.Bool("gzip", false, "gzip-compress the export")
+	configPath := flags.String("config", "", "YAML configuration file of the chain's network")
+	overrides := src.BindConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *chainPath == "" || *outPath == "" {
+		return errors.New("both -chain and -out are required")
+	}
+
+	opts, err := chainOptions(*configPath, overrides)
+	if err != nil {
+		return err
+	}
+	blockchain, err := loadChainFile(*chainPath, opts)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	exported, err := src.ExportChain(blockchain, out, *from, *to, *compress)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d blocks to %s\n", exported, *outPath)
+	return out.Close()
+}
+
+// runChainImport validates the blocks of an export file and adds them to a chain file.
+// If the chain file does not exist yet, it is created from the export's genesis block.
+func runChainImport(args []string) error {
+	flags := flag.NewFlagSet("chain import", flag.ContinueOnError)
+	chainPath := flags.String("chain", "", "chain file of the node")
+	inPath := flags.String("in", "", "file to import the blocks from")
+	configPath := flags.String("config", "", "YAML configuration file of the chain's network")
+	overrides := src.BindConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *chainPath == "" || *inPath == "" {
+		return errors.New("both -chain and -in are required")
+	}
+
+	opts, err := chainOptions(*configPath, overrides)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(*inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	progress := func(p src.ImportProgress) {
+		fmt.Printf("\rHeight %d: %d read, %d imported, %d skipped", p.Height, p.Read, p.Imported, p.Skipped)
+	}
+
+	var blockchain *src.Blockchain
+	var state src.ImportProgress
+	if _, statErr := os.Stat(*chainPath); errors.Is(statErr, os.ErrNotExist) {
+		blockchain, state, err = src.LoadChain(in, progress, opts...)
+	} else {
+		blockchain, err = loadChainFile(*chainPath, opts)
+		if err != nil {
+			return err
+		}
+		state, err = src.ImportChain(blockchain, in, progress)
+	}
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d blocks, skipped %d known blocks\n", state.Imported, state.Skipped)
+
+	return saveChainFile(blockchain, *chainPath)
+}
+
+// chainOptions returns the options of the blockchain of the network selected by
+// a configuration file and flags, so that chains of any network, consensus
+// engine and ledger model can be loaded. The blockchain keeps the transactions
+// of every block, since saveChainFile writes it out from the genesis block.
+func chainOptions(configPath string, overrides *src.ConfigFlags) ([]src.BlockchainOption, error) {
+	config, err := src.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(&config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		return nil, err
+	}
+	logger, err := config.Logger(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(profile.BlockchainOptions(), config.BlockchainOptions(logger)...)
+	return append(opts, src.WithArchive()), nil
+}
+
+// loadChainFile reads a whole chain from a chain file into a blockchain with opts.
+func loadChainFile(path string, opts []src.BlockchainOption) (*src.Blockchain, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	blockchain, _, err := src.LoadChain(file, nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chain file %s: %w", path, err)
+	}
+	return blockchain, nil
+}
+
+// saveChainFile writes the whole canonical chain to a chain file, replacing it atomically.
+func saveChainFile(blockchain *src.Blockchain, path string) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := src.ExportChain(blockchain, file, 0, math.MaxUint64, false); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -12,4 +12,6 @@ type BlockchainInterface interface {
 	BlockExists(hash []byte) bool
 	GenerateNewBlock(transaction []types.Transaction) *types.Block
 	GetRoot() *types.BlockNode
+	CheckCheckpoint(block *types.Block) error
+	GetConsensusEngine() ConsensusEngine
 }
@@ -2,9 +2,12 @@ package interfaces
 
 import (
 	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
 )
 
 type BlockMessageHandlerInterface interface {
 	HandleBlockMessage(msg *block_chain.BlockMessage)
+	HandleBlockMessageFrom(msg *block_chain.BlockMessage, reply MessageSender)
+	AnnounceBlock(block *types.Block)
 	BroadcastLatestBlock(nodes [][]byte)
 }
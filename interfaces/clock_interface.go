@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"time"
+)
+
+// Clock tells the time used to produce and validate blocks.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
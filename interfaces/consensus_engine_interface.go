@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"math/big"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// ConsensusEngine decides how blocks are produced and which blocks are valid.
+// The parent of a block is passed as its node in the block tree, so that an
+// engine can walk the ancestors of the block when its rules depend on them.
+type ConsensusEngine interface {
+	// VerifyHeader checks the consensus fields of block, a child of parent.
+	VerifyHeader(parent *types.BlockNode, block *types.Block) error
+	// Prepare sets the consensus fields of a new block before its transactions are chosen.
+	Prepare(parent *types.BlockNode, block *types.Block) error
+	// Finalize completes a new block once its transactions are chosen, before it is sealed.
+	Finalize(parent *types.BlockNode, block *types.Block) error
+	// Seal returns a copy of block that passes VerifyHeader, for example by finding
+	// a proof-of-work nonce. It gives up with an error once stop is closed.
+	Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error)
+	// CalculateWork returns the work block adds to its chain. Fork choice prefers
+	// the chain with the most work.
+	CalculateWork(block *types.Block) *big.Int
+}
+
+// ClockedEngine is a ConsensusEngine that waits for the time at which a block
+// may be sealed. The blockchain gives it its clock, so that blocks are sealed by
+// the same time they are validated against.
+type ClockedEngine interface {
+	ConsensusEngine
+	// SetClock makes the engine tell the time from clock.
+	SetClock(clock Clock)
+}
+
+// PrunableEngine is a ConsensusEngine that caches state for every block. The
+// blockchain lets it drop the state of blocks below a checkpoint when pruning.
+type PrunableEngine interface {
+	ConsensusEngine
+	// PruneSnapshots drops the state cached for blocks below checkpoint, keeps
+	// the state of checkpoint and returns the number of dropped entries.
+	PruneSnapshots(checkpoint *types.BlockNode) int
+}
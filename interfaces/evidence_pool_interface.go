@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// EvidencePool is implemented by consensus engines that punish validators who
+// sign conflicting blocks. Evidence in the pool is included in the next blocks
+// the node produces.
+type EvidencePool interface {
+	// ReportEvidence verifies evidence received from a peer and adds it to the pool.
+	ReportEvidence(evidence types.DoubleSignEvidence) error
+	// OnEvidence registers fn to be called with every evidence new to the pool,
+	// whether it was detected locally or reported.
+	OnEvidence(fn func(evidence types.DoubleSignEvidence))
+}
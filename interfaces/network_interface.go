@@ -0,0 +1,17 @@
+package interfaces
+
+// MessageHandler processes raw inbound messages. Replies to the peer that sent
+// the message go through reply.
+type MessageHandler interface {
+	HandleMessage(data []byte, reply MessageSender)
+}
+
+// Network delivers messages between nodes.
+type Network interface {
+	// Listen starts delivering messages addressed to address to handler.
+	Listen(address string, handler MessageHandler) error
+	// Dial returns a sender for messages from one node to another.
+	Dial(from, to string) (MessageSender, error)
+	// Close stops listening and closes all connections.
+	Close() error
+}
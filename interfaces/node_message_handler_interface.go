@@ -6,4 +6,5 @@ import (
 
 type NodeMessageHandlerInterface interface {
 	HandleNodeMessage(msg *block_chain.NodeMessage)
+	HandleNodeMessageFrom(msg *block_chain.NodeMessage, reply MessageSender)
 }
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"net"
+)
+
+// Transport opens the connection streams that nodes exchange messages over.
+type Transport interface {
+	// Listen accepts connections on address.
+	Listen(address string) (net.Listener, error)
+	// Dial connects to the node listening on address.
+	Dial(address string) (net.Conn, error)
+	// Close closes every listener opened through the transport.
+	Close() error
+}
@@ -1,5 +1,42 @@
 package main
 
+import (
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN node [-config <file>] [-network dev|testnet|private] [-seal pow|instant|interval|manual] [-consensus pow|poa|pos] [-ledger account|utxo] [-signer <address>] [-address <host:port>] [-peers <list>] [...]")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN chain export -chain <file> -out <file> [-from <height>] [-to <height>] [-gzip] [-config <file>] [-network dev|testnet|private] [...]")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN chain import -chain <file> -in <file> [-config <file>] [-network dev|testnet|private] [...]")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN wallet create [-keystore <dir>] [-passphrase-file <file>]")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN wallet list [-keystore <dir>]")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN wallet import [-keystore <dir>] (-key <hex> | -mnemonic <phrase> [-account <n>] [-index <n>])")
+	fmt.Fprintln(os.Stderr, "  GO_BLOCKCHAIN wallet export [-keystore <dir>] -address <address>")
+}
+
 func main() {
-	// This is an empty main function.
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "node":
+		err = runNodeCommand(os.Args[2:])
+	case "chain":
+		err = runChainCommand(os.Args[2:])
+	case "wallet":
+		err = runWalletCommand(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -45,5 +45,15 @@ func (m *MockBlockchain) GetRoot() *types.BlockNode {
 	return args.Get(0).(*types.BlockNode)
 }
 
+func (m *MockBlockchain) CheckCheckpoint(block *types.Block) error {
+	args := m.Called(block)
+	return args.Error(0)
+}
+
+func (m *MockBlockchain) GetConsensusEngine() interfaces.ConsensusEngine {
+	args := m.Called()
+	return args.Get(0).(interfaces.ConsensusEngine)
+}
+
 // Ensure MockBlockchain implements BlockchainInterface
 var _ interfaces.BlockchainInterface = (*MockBlockchain)(nil)
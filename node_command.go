@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// runNodeCommand runs a mining node of the selected network, configured by a
+// file, the environment and flags.
+func runNodeCommand(args []string) error {
+	flags := flag.NewFlagSet("node", flag.ContinueOnError)
+	configPath := flags.String("config", "", "YAML configuration file")
+	overrides := src.BindConfigFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := src.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := overrides.Apply(&config); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		return err
+	}
+	logger, err := config.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// The blockchain validates and stamps blocks with the time the node agrees
+	// on with its peers.
+	clock := src.NewNetworkClock(src.SystemClock{})
+	chainOpts := append(config.BlockchainOptions(logger), src.WithClock(clock))
+	nodeOpts := append(config.NodeOptions(logger), src.WithNetworkClock(clock))
+	if config.Metrics.Address != "" {
+		metrics := src.NewMetrics()
+		chainOpts = append(chainOpts, src.WithMetrics(metrics))
+		nodeOpts = append(nodeOpts, src.WithNodeMetrics(metrics))
+		server := src.NewMetricsServer(config.Metrics.Address, metrics)
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Metrics server stopped", slog.Any(src.LogKeyError, err))
+			}
+		}()
+	}
+
+	transport, identity, err := config.Transport()
+	if err != nil {
+		return err
+	}
+	if transport != nil {
+		nodeOpts = append(nodeOpts, src.WithTransport(transport))
+		logger.Info("Authenticating peers over TLS", slog.String("node_id", identity.ID()))
+	}
+
+	logger.Info("Joining network", slog.String("network", profile.Name), slog.String("chain_id", profile.ChainID))
+	blockchain := profile.NewBlockchain(chainOpts...)
+	finality := config.FinalityGadget(blockchain)
+	if finality != nil {
+		nodeOpts = append(nodeOpts, src.WithFinality(finality))
+	}
+	if config.Node.Signer != "" {
+		if err := authorizeSigner(config, blockchain, finality); err != nil {
+			return err
+		}
+	}
+	node := profile.NewNode(blockchain, config.Node.Address, nodeOpts...)
+	if config.Node.RPCAddress != "" {
+		server := src.NewDevRPCServer(config.Node.RPCAddress, node)
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Dev RPC server stopped", slog.Any(src.LogKeyError, err))
+			}
+		}()
+	}
+	node.Start()
+	return nil
+}
+
+// authorizeSigner loads the configured signer key from the keystore and makes
+// the consensus engine of blockchain sign blocks and the finality gadget, if
+// any, sign votes with it.
+func authorizeSigner(config src.Config, blockchain *src.Blockchain, finality *src.FinalityGadget) error {
+	engine, ok := blockchain.GetConsensusEngine().(interface{ Authorize(*wallet.Key) })
+	if !ok && finality == nil {
+		return errors.New("a signer needs proof-of-authority or proof-of-stake consensus, or finality validators")
+	}
+	keystoreDir := config.Node.Keystore
+	if keystoreDir == "" {
+		keystoreDir = defaultKeystoreDir
+	}
+	passphrase, err := readPassphrase(config.Node.PassphraseFile)
+	if err != nil {
+		return err
+	}
+	key, err := wallet.NewKeystore(keystoreDir).Load(config.Node.Signer, passphrase)
+	if err != nil {
+		return err
+	}
+	if ok {
+		engine.Authorize(key)
+	}
+	if finality != nil {
+		finality.Authorize(key)
+	}
+	return nil
+}
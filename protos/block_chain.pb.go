@@ -323,6 +323,10 @@ type NodeMessage struct {
 	//	*NodeMessage_WelcomeResponse
 	//	*NodeMessage_PongResponse
 	//	*NodeMessage_Empty
+	//	*NodeMessage_DoubleSignEvidence
+	//	*NodeMessage_FinalityVote
+	//	*NodeMessage_FinalityCertificate
+	//	*NodeMessage_FinalityCertificateRequest
 	NodeMessageType isNodeMessage_NodeMessageType `protobuf_oneof:"node_message_type"`
 }
 
@@ -400,6 +404,34 @@ func (x *NodeMessage) GetEmpty() *Empty {
 	return nil
 }
 
+func (x *NodeMessage) GetDoubleSignEvidence() *DoubleSignEvidence {
+	if x, ok := x.GetNodeMessageType().(*NodeMessage_DoubleSignEvidence); ok {
+		return x.DoubleSignEvidence
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetFinalityVote() *FinalityVote {
+	if x, ok := x.GetNodeMessageType().(*NodeMessage_FinalityVote); ok {
+		return x.FinalityVote
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetFinalityCertificate() *FinalityCertificate {
+	if x, ok := x.GetNodeMessageType().(*NodeMessage_FinalityCertificate); ok {
+		return x.FinalityCertificate
+	}
+	return nil
+}
+
+func (x *NodeMessage) GetFinalityCertificateRequest() *FinalityCertificateRequest {
+	if x, ok := x.GetNodeMessageType().(*NodeMessage_FinalityCertificateRequest); ok {
+		return x.FinalityCertificateRequest
+	}
+	return nil
+}
+
 type isNodeMessage_NodeMessageType interface {
 	isNodeMessage_NodeMessageType()
 }
@@ -424,6 +456,22 @@ type NodeMessage_Empty struct {
 	Empty *Empty `protobuf:"bytes,5,opt,name=empty,proto3,oneof"`
 }
 
+type NodeMessage_DoubleSignEvidence struct {
+	DoubleSignEvidence *DoubleSignEvidence `protobuf:"bytes,6,opt,name=double_sign_evidence,json=doubleSignEvidence,proto3,oneof"`
+}
+
+type NodeMessage_FinalityVote struct {
+	FinalityVote *FinalityVote `protobuf:"bytes,7,opt,name=finality_vote,json=finalityVote,proto3,oneof"`
+}
+
+type NodeMessage_FinalityCertificate struct {
+	FinalityCertificate *FinalityCertificate `protobuf:"bytes,8,opt,name=finality_certificate,json=finalityCertificate,proto3,oneof"`
+}
+
+type NodeMessage_FinalityCertificateRequest struct {
+	FinalityCertificateRequest *FinalityCertificateRequest `protobuf:"bytes,9,opt,name=finality_certificate_request,json=finalityCertificateRequest,proto3,oneof"`
+}
+
 func (*NodeMessage_NodesResponse) isNodeMessage_NodeMessageType() {}
 
 func (*NodeMessage_WelcomeRequest) isNodeMessage_NodeMessageType() {}
@@ -434,6 +482,14 @@ func (*NodeMessage_PongResponse) isNodeMessage_NodeMessageType() {}
 
 func (*NodeMessage_Empty) isNodeMessage_NodeMessageType() {}
 
+func (*NodeMessage_DoubleSignEvidence) isNodeMessage_NodeMessageType() {}
+
+func (*NodeMessage_FinalityVote) isNodeMessage_NodeMessageType() {}
+
+func (*NodeMessage_FinalityCertificate) isNodeMessage_NodeMessageType() {}
+
+func (*NodeMessage_FinalityCertificateRequest) isNodeMessage_NodeMessageType() {}
+
 type NodesResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -486,7 +542,8 @@ type WelcomeRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message   []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp uint64 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 }
 
 func (x *WelcomeRequest) Reset() {
@@ -528,12 +585,20 @@ func (x *WelcomeRequest) GetMessage() []byte {
 	return nil
 }
 
+func (x *WelcomeRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
 type WelcomeResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message   []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp uint64 `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 }
 
 func (x *WelcomeResponse) Reset() {
@@ -575,6 +640,13 @@ func (x *WelcomeResponse) GetMessage() []byte {
 	return nil
 }
 
+func (x *WelcomeResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
 type PongResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -622,6 +694,270 @@ func (x *PongResponse) GetSuccess() bool {
 	return false
 }
 
+// Two different blocks with the same parent signed by the same validator
+type DoubleSignEvidence struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	First  *Block `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	Second *Block `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+}
+
+func (x *DoubleSignEvidence) Reset() {
+	*x = DoubleSignEvidence{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DoubleSignEvidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DoubleSignEvidence) ProtoMessage() {}
+
+func (x *DoubleSignEvidence) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DoubleSignEvidence.ProtoReflect.Descriptor instead.
+func (*DoubleSignEvidence) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DoubleSignEvidence) GetFirst() *Block {
+	if x != nil {
+		return x.First
+	}
+	return nil
+}
+
+func (x *DoubleSignEvidence) GetSecond() *Block {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+// A signed prevote or precommit of a finality validator on a checkpoint block
+type FinalityVote struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      uint32 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Height    uint64 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	BlockHash []byte `protobuf:"bytes,3,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	Validator []byte `protobuf:"bytes,4,opt,name=validator,proto3" json:"validator,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Round     uint32 `protobuf:"varint,6,opt,name=round,proto3" json:"round,omitempty"`
+}
+
+func (x *FinalityVote) Reset() {
+	*x = FinalityVote{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityVote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityVote) ProtoMessage() {}
+
+func (x *FinalityVote) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityVote.ProtoReflect.Descriptor instead.
+func (*FinalityVote) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FinalityVote) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *FinalityVote) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *FinalityVote) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+func (x *FinalityVote) GetValidator() []byte {
+	if x != nil {
+		return x.Validator
+	}
+	return nil
+}
+
+func (x *FinalityVote) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *FinalityVote) GetRound() uint32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+// The precommits that made a checkpoint block final
+type FinalityCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height     uint64          `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	BlockHash  []byte          `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	Precommits []*FinalityVote `protobuf:"bytes,3,rep,name=precommits,proto3" json:"precommits,omitempty"`
+	Round      uint32          `protobuf:"varint,4,opt,name=round,proto3" json:"round,omitempty"`
+}
+
+func (x *FinalityCertificate) Reset() {
+	*x = FinalityCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityCertificate) ProtoMessage() {}
+
+func (x *FinalityCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityCertificate.ProtoReflect.Descriptor instead.
+func (*FinalityCertificate) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FinalityCertificate) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *FinalityCertificate) GetBlockHash() []byte {
+	if x != nil {
+		return x.BlockHash
+	}
+	return nil
+}
+
+func (x *FinalityCertificate) GetPrecommits() []*FinalityVote {
+	if x != nil {
+		return x.Precommits
+	}
+	return nil
+}
+
+func (x *FinalityCertificate) GetRound() uint32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+// Asks for the certificate at a height, or the latest one for height 0
+type FinalityCertificateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *FinalityCertificateRequest) Reset() {
+	*x = FinalityCertificateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityCertificateRequest) ProtoMessage() {}
+
+func (x *FinalityCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityCertificateRequest.ProtoReflect.Descriptor instead.
+func (*FinalityCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FinalityCertificateRequest) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
 // ******************************* BLOCK MESSAGES
 type BlockRequest struct {
 	state         protoimpl.MessageState
@@ -634,7 +970,7 @@ type BlockRequest struct {
 func (x *BlockRequest) Reset() {
 	*x = BlockRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[7]
+		mi := &file_block_chain_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -647,7 +983,7 @@ func (x *BlockRequest) String() string {
 func (*BlockRequest) ProtoMessage() {}
 
 func (x *BlockRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[7]
+	mi := &file_block_chain_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -660,7 +996,7 @@ func (x *BlockRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockRequest.ProtoReflect.Descriptor instead.
 func (*BlockRequest) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{7}
+	return file_block_chain_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *BlockRequest) GetBlock() *Block {
@@ -683,7 +1019,7 @@ type BlockResponse struct {
 func (x *BlockResponse) Reset() {
 	*x = BlockResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[8]
+		mi := &file_block_chain_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -696,7 +1032,7 @@ func (x *BlockResponse) String() string {
 func (*BlockResponse) ProtoMessage() {}
 
 func (x *BlockResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[8]
+	mi := &file_block_chain_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -709,7 +1045,7 @@ func (x *BlockResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockResponse.ProtoReflect.Descriptor instead.
 func (*BlockResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{8}
+	return file_block_chain_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *BlockResponse) GetSuccess() bool {
@@ -742,7 +1078,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[9]
+		mi := &file_block_chain_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -755,7 +1091,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[9]
+	mi := &file_block_chain_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -768,7 +1104,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{9}
+	return file_block_chain_proto_rawDescGZIP(), []int{13}
 }
 
 type Block struct {
@@ -776,32 +1112,260 @@ type Block struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Index        uint64         `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
-	Timestamp    uint64         `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Transactions []*Transaction `protobuf:"bytes,3,rep,name=transactions,proto3" json:"transactions,omitempty"`
-	PreviousHash []byte         `protobuf:"bytes,4,opt,name=previousHash,proto3" json:"previousHash,omitempty"`
-	Hash         []byte         `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
-	Data         uint64         `protobuf:"varint,6,opt,name=data,proto3" json:"data,omitempty"`
-	Checkpoint   bool           `protobuf:"varint,7,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"` // Added this line
+	Index        uint64                `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Timestamp    uint64                `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transactions []*Transaction        `protobuf:"bytes,3,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	PreviousHash []byte                `protobuf:"bytes,4,opt,name=previousHash,proto3" json:"previousHash,omitempty"`
+	Hash         []byte                `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	Data         uint64                `protobuf:"varint,6,opt,name=data,proto3" json:"data,omitempty"`
+	Checkpoint   bool                  `protobuf:"varint,7,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"` // Added this line
+	Signer       []byte                `protobuf:"bytes,8,opt,name=signer,proto3" json:"signer,omitempty"`
+	Signature    []byte                `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+	Evidence     []*DoubleSignEvidence `protobuf:"bytes,10,rep,name=evidence,proto3" json:"evidence,omitempty"`
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Block) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Block) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Block) GetPreviousHash() []byte {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return nil
+}
+
+func (x *Block) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *Block) GetData() uint64 {
+	if x != nil {
+		return x.Data
+	}
+	return 0
+}
+
+func (x *Block) GetCheckpoint() bool {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return false
+}
+
+func (x *Block) GetSigner() []byte {
+	if x != nil {
+		return x.Signer
+	}
+	return nil
+}
+
+func (x *Block) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Block) GetEvidence() []*DoubleSignEvidence {
+	if x != nil {
+		return x.Evidence
+	}
+	return nil
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sender    []byte      `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Receiver  []byte      `protobuf:"bytes,2,opt,name=receiver,proto3" json:"receiver,omitempty"`
+	Amount    uint64      `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	PublicKey []byte      `protobuf:"bytes,5,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature []byte      `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	Type      uint32      `protobuf:"varint,7,opt,name=type,proto3" json:"type,omitempty"`
+	Inputs    []*TxInput  `protobuf:"bytes,8,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Outputs   []*TxOutput `protobuf:"bytes,9,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	// Orders the stake and unstake transactions of a validator.
+	Nonce uint64 `protobuf:"varint,10,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Transaction) GetSender() []byte {
+	if x != nil {
+		return x.Sender
+	}
+	return nil
+}
+
+func (x *Transaction) GetReceiver() []byte {
+	if x != nil {
+		return x.Receiver
+	}
+	return nil
+}
+
+func (x *Transaction) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transaction) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *Transaction) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Transaction) GetInputs() []*TxInput {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *Transaction) GetOutputs() []*TxOutput {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+func (x *Transaction) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+// Spends the output at index of the UTXO transaction with hash tx_hash
+type TxInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TxHash    []byte `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	Index     uint32 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
 }
 
-func (x *Block) Reset() {
-	*x = Block{}
+func (x *TxInput) Reset() {
+	*x = TxInput{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[10]
+		mi := &file_block_chain_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Block) String() string {
+func (x *TxInput) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Block) ProtoMessage() {}
+func (*TxInput) ProtoMessage() {}
 
-func (x *Block) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[10]
+func (x *TxInput) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -812,87 +1376,59 @@ func (x *Block) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Block.ProtoReflect.Descriptor instead.
-func (*Block) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{10}
-}
-
-func (x *Block) GetIndex() uint64 {
-	if x != nil {
-		return x.Index
-	}
-	return 0
-}
-
-func (x *Block) GetTimestamp() uint64 {
-	if x != nil {
-		return x.Timestamp
-	}
-	return 0
-}
-
-func (x *Block) GetTransactions() []*Transaction {
-	if x != nil {
-		return x.Transactions
-	}
-	return nil
-}
-
-func (x *Block) GetPreviousHash() []byte {
-	if x != nil {
-		return x.PreviousHash
-	}
-	return nil
+// Deprecated: Use TxInput.ProtoReflect.Descriptor instead.
+func (*TxInput) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *Block) GetHash() []byte {
+func (x *TxInput) GetTxHash() []byte {
 	if x != nil {
-		return x.Hash
+		return x.TxHash
 	}
 	return nil
 }
 
-func (x *Block) GetData() uint64 {
+func (x *TxInput) GetIndex() uint32 {
 	if x != nil {
-		return x.Data
+		return x.Index
 	}
 	return 0
 }
 
-func (x *Block) GetCheckpoint() bool {
+func (x *TxInput) GetSignature() []byte {
 	if x != nil {
-		return x.Checkpoint
+		return x.Signature
 	}
-	return false
+	return nil
 }
 
-type Transaction struct {
+// Locks amount to the holder of the private key of locking_key
+type TxOutput struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sender   []byte  `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
-	Receiver []byte  `protobuf:"bytes,2,opt,name=receiver,proto3" json:"receiver,omitempty"`
-	Amount   float64 `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Amount     uint64 `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	LockingKey []byte `protobuf:"bytes,2,opt,name=locking_key,json=lockingKey,proto3" json:"locking_key,omitempty"`
 }
 
-func (x *Transaction) Reset() {
-	*x = Transaction{}
+func (x *TxOutput) Reset() {
+	*x = TxOutput{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[11]
+		mi := &file_block_chain_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Transaction) String() string {
+func (x *TxOutput) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Transaction) ProtoMessage() {}
+func (*TxOutput) ProtoMessage() {}
 
-func (x *Transaction) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[11]
+func (x *TxOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -903,32 +1439,25 @@ func (x *Transaction) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
-func (*Transaction) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use TxOutput.ProtoReflect.Descriptor instead.
+func (*TxOutput) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *Transaction) GetSender() []byte {
+func (x *TxOutput) GetAmount() uint64 {
 	if x != nil {
-		return x.Sender
+		return x.Amount
 	}
-	return nil
+	return 0
 }
 
-func (x *Transaction) GetReceiver() []byte {
+func (x *TxOutput) GetLockingKey() []byte {
 	if x != nil {
-		return x.Receiver
+		return x.LockingKey
 	}
 	return nil
 }
 
-func (x *Transaction) GetAmount() float64 {
-	if x != nil {
-		return x.Amount
-	}
-	return 0
-}
-
 type BlockchainResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -940,7 +1469,7 @@ type BlockchainResponse struct {
 func (x *BlockchainResponse) Reset() {
 	*x = BlockchainResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[12]
+		mi := &file_block_chain_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -953,7 +1482,7 @@ func (x *BlockchainResponse) String() string {
 func (*BlockchainResponse) ProtoMessage() {}
 
 func (x *BlockchainResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[12]
+	mi := &file_block_chain_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -966,7 +1495,7 @@ func (x *BlockchainResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockchainResponse.ProtoReflect.Descriptor instead.
 func (*BlockchainResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{12}
+	return file_block_chain_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *BlockchainResponse) GetBlocks() []*Block {
@@ -987,7 +1516,7 @@ type BlocksResponse struct {
 func (x *BlocksResponse) Reset() {
 	*x = BlocksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[13]
+		mi := &file_block_chain_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1000,7 +1529,7 @@ func (x *BlocksResponse) String() string {
 func (*BlocksResponse) ProtoMessage() {}
 
 func (x *BlocksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[13]
+	mi := &file_block_chain_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1013,7 +1542,7 @@ func (x *BlocksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlocksResponse.ProtoReflect.Descriptor instead.
 func (*BlocksResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{13}
+	return file_block_chain_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *BlocksResponse) GetBlocks() []*Block {
@@ -1034,7 +1563,7 @@ type TransactionPoolResponse struct {
 func (x *TransactionPoolResponse) Reset() {
 	*x = TransactionPoolResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[14]
+		mi := &file_block_chain_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1047,7 +1576,7 @@ func (x *TransactionPoolResponse) String() string {
 func (*TransactionPoolResponse) ProtoMessage() {}
 
 func (x *TransactionPoolResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[14]
+	mi := &file_block_chain_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1060,7 +1589,7 @@ func (x *TransactionPoolResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TransactionPoolResponse.ProtoReflect.Descriptor instead.
 func (*TransactionPoolResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{14}
+	return file_block_chain_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *TransactionPoolResponse) GetTransactions() []*Transaction {
@@ -1081,7 +1610,7 @@ type LatestBlockResponse struct {
 func (x *LatestBlockResponse) Reset() {
 	*x = LatestBlockResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[15]
+		mi := &file_block_chain_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1094,7 +1623,7 @@ func (x *LatestBlockResponse) String() string {
 func (*LatestBlockResponse) ProtoMessage() {}
 
 func (x *LatestBlockResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[15]
+	mi := &file_block_chain_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1107,7 +1636,7 @@ func (x *LatestBlockResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LatestBlockResponse.ProtoReflect.Descriptor instead.
 func (*LatestBlockResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{15}
+	return file_block_chain_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *LatestBlockResponse) GetBlock() *Block {
@@ -1128,7 +1657,7 @@ type BlockUpdateRequest struct {
 func (x *BlockUpdateRequest) Reset() {
 	*x = BlockUpdateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[16]
+		mi := &file_block_chain_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1141,7 +1670,7 @@ func (x *BlockUpdateRequest) String() string {
 func (*BlockUpdateRequest) ProtoMessage() {}
 
 func (x *BlockUpdateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[16]
+	mi := &file_block_chain_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1154,7 +1683,7 @@ func (x *BlockUpdateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockUpdateRequest.ProtoReflect.Descriptor instead.
 func (*BlockUpdateRequest) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{16}
+	return file_block_chain_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *BlockUpdateRequest) GetBlock() *Block {
@@ -1175,7 +1704,7 @@ type BlockUpdateResponse struct {
 func (x *BlockUpdateResponse) Reset() {
 	*x = BlockUpdateResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[17]
+		mi := &file_block_chain_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1188,7 +1717,7 @@ func (x *BlockUpdateResponse) String() string {
 func (*BlockUpdateResponse) ProtoMessage() {}
 
 func (x *BlockUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[17]
+	mi := &file_block_chain_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1201,7 +1730,7 @@ func (x *BlockUpdateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockUpdateResponse.ProtoReflect.Descriptor instead.
 func (*BlockUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{17}
+	return file_block_chain_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *BlockUpdateResponse) GetBlock() *Block {
@@ -1220,7 +1749,7 @@ type GetLatestBlockRequest struct {
 func (x *GetLatestBlockRequest) Reset() {
 	*x = GetLatestBlockRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[18]
+		mi := &file_block_chain_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1233,7 +1762,7 @@ func (x *GetLatestBlockRequest) String() string {
 func (*GetLatestBlockRequest) ProtoMessage() {}
 
 func (x *GetLatestBlockRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[18]
+	mi := &file_block_chain_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1246,7 +1775,7 @@ func (x *GetLatestBlockRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLatestBlockRequest.ProtoReflect.Descriptor instead.
 func (*GetLatestBlockRequest) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{18}
+	return file_block_chain_proto_rawDescGZIP(), []int{24}
 }
 
 type GetBlockRequest struct {
@@ -1260,7 +1789,7 @@ type GetBlockRequest struct {
 func (x *GetBlockRequest) Reset() {
 	*x = GetBlockRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_block_chain_proto_msgTypes[19]
+		mi := &file_block_chain_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1273,7 +1802,7 @@ func (x *GetBlockRequest) String() string {
 func (*GetBlockRequest) ProtoMessage() {}
 
 func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_block_chain_proto_msgTypes[19]
+	mi := &file_block_chain_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1286,7 +1815,7 @@ func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlockRequest.ProtoReflect.Descriptor instead.
 func (*GetBlockRequest) Descriptor() ([]byte, []int) {
-	return file_block_chain_proto_rawDescGZIP(), []int{19}
+	return file_block_chain_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *GetBlockRequest) GetHash() []byte {
@@ -1296,6 +1825,86 @@ func (x *GetBlockRequest) GetHash() []byte {
 	return nil
 }
 
+// A message recorded by a node for later replay
+type CaptureRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp int64        `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Peer      string       `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+	Outbound  bool         `protobuf:"varint,3,opt,name=outbound,proto3" json:"outbound,omitempty"`
+	Message   *MainMessage `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Raw       []byte       `protobuf:"bytes,5,opt,name=raw,proto3" json:"raw,omitempty"`
+}
+
+func (x *CaptureRecord) Reset() {
+	*x = CaptureRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_chain_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureRecord) ProtoMessage() {}
+
+func (x *CaptureRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_block_chain_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureRecord.ProtoReflect.Descriptor instead.
+func (*CaptureRecord) Descriptor() ([]byte, []int) {
+	return file_block_chain_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CaptureRecord) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *CaptureRecord) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *CaptureRecord) GetOutbound() bool {
+	if x != nil {
+		return x.Outbound
+	}
+	return false
+}
+
+func (x *CaptureRecord) GetMessage() *MainMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *CaptureRecord) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
 var File_block_chain_proto protoreflect.FileDescriptor
 
 var file_block_chain_proto_rawDesc = []byte{
@@ -1361,7 +1970,7 @@ var file_block_chain_proto_rawDesc = []byte{
 	0x0a, 0x05, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e,
 	0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x05, 0x65, 0x6d,
 	0x70, 0x74, 0x79, 0x42, 0x14, 0x0a, 0x12, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x22, 0xc5, 0x02, 0x0a, 0x0b, 0x4e, 0x6f,
+	0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x22, 0x84, 0x05, 0x0a, 0x0b, 0x4e, 0x6f,
 	0x64, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3c, 0x0a, 0x0e, 0x6e, 0x6f, 0x64,
 	0x65, 0x73, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65,
@@ -1380,79 +1989,171 @@ var file_block_chain_proto_rawDesc = []byte{
 	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x70, 0x6f, 0x6e, 0x67, 0x52,
 	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x05, 0x65, 0x6d, 0x70, 0x74, 0x79,
 	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x05, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x13, 0x0a, 0x11,
-	0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70,
-	0x65, 0x22, 0x25, 0x0a, 0x0d, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0x2a, 0x0a, 0x0e, 0x57, 0x65, 0x6c, 0x63,
-	0x6f, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x22, 0x2b, 0x0a, 0x0f, 0x57, 0x65, 0x6c, 0x63, 0x6f, 0x6d, 0x65, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x22, 0x28, 0x0a, 0x0c, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x31, 0x0a, 0x0c, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x05, 0x62,
-	0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69,
-	0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x66,
-	0x0a, 0x0d, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
-	0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22,
-	0xde, 0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64,
-	0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12,
-	0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x35, 0x0a,
-	0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
-	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73,
-	0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x72, 0x65, 0x76,
-	0x69, 0x6f, 0x75, 0x73, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04,
-	0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74,
-	0x22, 0x59, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69,
-	0x76, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69,
-	0x76, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x01, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x39, 0x0a, 0x12, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x23, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06,
-	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x35, 0x0a, 0x0e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63,
-	0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x50, 0x0a,
-	0x17, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6f, 0x6c,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
-	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x52, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22,
-	0x38, 0x0a, 0x13, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f,
-	0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x37, 0x0a, 0x12, 0x42, 0x6c, 0x6f,
-	0x63, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b,
-	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f,
-	0x63, 0x6b, 0x22, 0x38, 0x0a, 0x13, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f,
-	0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x17, 0x0a, 0x15,
-	0x47, 0x65, 0x74, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x42, 0x1f, 0x5a, 0x1d,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x05, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x4c, 0x0a, 0x14,
+	0x64, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x5f, 0x65, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x44, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x45, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x63, 0x65, 0x48, 0x00, 0x52, 0x12, 0x64, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x53, 0x69,
+	0x67, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x39, 0x0a, 0x0d, 0x66, 0x69,
+	0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x5f, 0x76, 0x6f, 0x74, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x56, 0x6f, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x4e, 0x0a, 0x14, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x48, 0x00,
+	0x52, 0x13, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x64, 0x0a, 0x1c, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6d, 0x61,
+	0x69, 0x6e, 0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
+	0x1a, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x13, 0x0a, 0x11, 0x6e,
+	0x6f, 0x64, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65,
+	0x22, 0x25, 0x0a, 0x0d, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c,
+	0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0x48, 0x0a, 0x0e, 0x57, 0x65, 0x6c, 0x63, 0x6f,
+	0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x22, 0x49, 0x0a, 0x0f, 0x57, 0x65, 0x6c, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x28, 0x0a, 0x0c,
+	0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x5c, 0x0a, 0x12, 0x44, 0x6f, 0x75, 0x62, 0x6c, 0x65,
+	0x53, 0x69, 0x67, 0x6e, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x05,
+	0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61,
+	0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12,
+	0x23, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x22, 0xab, 0x01, 0x0a, 0x0c, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x1c, 0x0a, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x72, 0x6f, 0x75,
+	0x6e, 0x64, 0x22, 0x96, 0x01, 0x0a, 0x13, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73,
+	0x68, 0x12, 0x32, 0x0a, 0x0a, 0x70, 0x72, 0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x46, 0x69, 0x6e,
+	0x61, 0x6c, 0x69, 0x74, 0x79, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x0a, 0x70, 0x72, 0x65, 0x63, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x22, 0x34, 0x0a, 0x1a, 0x46,
+	0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x22, 0x31, 0x0a, 0x0c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x66, 0x0a, 0x0d, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x07, 0x0a, 0x05,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0xca, 0x02, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
+	0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x12, 0x35, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e,
+	0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72,
+	0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0c, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12,
+	0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x34, 0x0a, 0x08,
+	0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x44, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x53, 0x69, 0x67, 0x6e,
+	0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x08, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e,
+	0x63, 0x65, 0x22, 0x97, 0x02, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1d,
+	0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x25, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x54, 0x78, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x06,
+	0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x54,
+	0x78, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73,
+	0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x22, 0x56, 0x0a, 0x07,
+	0x54, 0x78, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x78, 0x5f, 0x68, 0x61,
+	0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x74, 0x78, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x22, 0x43, 0x0a, 0x08, 0x54, 0x78, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6c, 0x6f, 0x63, 0x6b,
+	0x69, 0x6e, 0x67, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x6c,
+	0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x22, 0x39, 0x0a, 0x12, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x23, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x22, 0x35, 0x0a, 0x0e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x50, 0x0a, 0x17, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6f, 0x6c, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x38, 0x0a,
+	0x13, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x37, 0x0a, 0x12, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a,
+	0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x22, 0x38, 0x0a, 0x13, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x17, 0x0a, 0x15, 0x47, 0x65,
+	0x74, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x9c, 0x01, 0x0a, 0x0d, 0x43,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x65,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x65, 0x65, 0x72, 0x12, 0x1a,
+	0x0a, 0x08, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x2b, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61,
+	0x69, 0x6e, 0x2e, 0x4d, 0x61, 0x69, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x61, 0x77, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x72, 0x61, 0x77, 0x42, 0x1f, 0x5a, 0x1d, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -1467,62 +2168,80 @@ func file_block_chain_proto_rawDescGZIP() []byte {
 	return file_block_chain_proto_rawDescData
 }
 
-var file_block_chain_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_block_chain_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_block_chain_proto_goTypes = []any{
-	(*MainMessage)(nil),             // 0: main.MainMessage
-	(*BlockMessage)(nil),            // 1: main.BlockMessage
-	(*NodeMessage)(nil),             // 2: main.NodeMessage
-	(*NodesResponse)(nil),           // 3: main.NodesResponse
-	(*WelcomeRequest)(nil),          // 4: main.WelcomeRequest
-	(*WelcomeResponse)(nil),         // 5: main.WelcomeResponse
-	(*PongResponse)(nil),            // 6: main.PongResponse
-	(*BlockRequest)(nil),            // 7: main.BlockRequest
-	(*BlockResponse)(nil),           // 8: main.BlockResponse
-	(*Empty)(nil),                   // 9: main.Empty
-	(*Block)(nil),                   // 10: main.Block
-	(*Transaction)(nil),             // 11: main.Transaction
-	(*BlockchainResponse)(nil),      // 12: main.BlockchainResponse
-	(*BlocksResponse)(nil),          // 13: main.BlocksResponse
-	(*TransactionPoolResponse)(nil), // 14: main.TransactionPoolResponse
-	(*LatestBlockResponse)(nil),     // 15: main.LatestBlockResponse
-	(*BlockUpdateRequest)(nil),      // 16: main.BlockUpdateRequest
-	(*BlockUpdateResponse)(nil),     // 17: main.BlockUpdateResponse
-	(*GetLatestBlockRequest)(nil),   // 18: main.GetLatestBlockRequest
-	(*GetBlockRequest)(nil),         // 19: main.GetBlockRequest
+	(*MainMessage)(nil),                // 0: main.MainMessage
+	(*BlockMessage)(nil),               // 1: main.BlockMessage
+	(*NodeMessage)(nil),                // 2: main.NodeMessage
+	(*NodesResponse)(nil),              // 3: main.NodesResponse
+	(*WelcomeRequest)(nil),             // 4: main.WelcomeRequest
+	(*WelcomeResponse)(nil),            // 5: main.WelcomeResponse
+	(*PongResponse)(nil),               // 6: main.PongResponse
+	(*DoubleSignEvidence)(nil),         // 7: main.DoubleSignEvidence
+	(*FinalityVote)(nil),               // 8: main.FinalityVote
+	(*FinalityCertificate)(nil),        // 9: main.FinalityCertificate
+	(*FinalityCertificateRequest)(nil), // 10: main.FinalityCertificateRequest
+	(*BlockRequest)(nil),               // 11: main.BlockRequest
+	(*BlockResponse)(nil),              // 12: main.BlockResponse
+	(*Empty)(nil),                      // 13: main.Empty
+	(*Block)(nil),                      // 14: main.Block
+	(*Transaction)(nil),                // 15: main.Transaction
+	(*TxInput)(nil),                    // 16: main.TxInput
+	(*TxOutput)(nil),                   // 17: main.TxOutput
+	(*BlockchainResponse)(nil),         // 18: main.BlockchainResponse
+	(*BlocksResponse)(nil),             // 19: main.BlocksResponse
+	(*TransactionPoolResponse)(nil),    // 20: main.TransactionPoolResponse
+	(*LatestBlockResponse)(nil),        // 21: main.LatestBlockResponse
+	(*BlockUpdateRequest)(nil),         // 22: main.BlockUpdateRequest
+	(*BlockUpdateResponse)(nil),        // 23: main.BlockUpdateResponse
+	(*GetLatestBlockRequest)(nil),      // 24: main.GetLatestBlockRequest
+	(*GetBlockRequest)(nil),            // 25: main.GetBlockRequest
+	(*CaptureRecord)(nil),              // 26: main.CaptureRecord
 }
 var file_block_chain_proto_depIdxs = []int32{
 	1,  // 0: main.MainMessage.block_message:type_name -> main.BlockMessage
 	2,  // 1: main.MainMessage.node_message:type_name -> main.NodeMessage
-	7,  // 2: main.BlockMessage.block_request:type_name -> main.BlockRequest
-	8,  // 3: main.BlockMessage.block_response:type_name -> main.BlockResponse
-	12, // 4: main.BlockMessage.blockchain_response:type_name -> main.BlockchainResponse
-	13, // 5: main.BlockMessage.blocks_response:type_name -> main.BlocksResponse
-	14, // 6: main.BlockMessage.transaction_pool_response:type_name -> main.TransactionPoolResponse
-	15, // 7: main.BlockMessage.latest_block_response:type_name -> main.LatestBlockResponse
-	16, // 8: main.BlockMessage.block_update_request:type_name -> main.BlockUpdateRequest
-	17, // 9: main.BlockMessage.block_update_response:type_name -> main.BlockUpdateResponse
-	18, // 10: main.BlockMessage.get_latest_block_request:type_name -> main.GetLatestBlockRequest
-	19, // 11: main.BlockMessage.get_block_request:type_name -> main.GetBlockRequest
-	9,  // 12: main.BlockMessage.empty:type_name -> main.Empty
+	11, // 2: main.BlockMessage.block_request:type_name -> main.BlockRequest
+	12, // 3: main.BlockMessage.block_response:type_name -> main.BlockResponse
+	18, // 4: main.BlockMessage.blockchain_response:type_name -> main.BlockchainResponse
+	19, // 5: main.BlockMessage.blocks_response:type_name -> main.BlocksResponse
+	20, // 6: main.BlockMessage.transaction_pool_response:type_name -> main.TransactionPoolResponse
+	21, // 7: main.BlockMessage.latest_block_response:type_name -> main.LatestBlockResponse
+	22, // 8: main.BlockMessage.block_update_request:type_name -> main.BlockUpdateRequest
+	23, // 9: main.BlockMessage.block_update_response:type_name -> main.BlockUpdateResponse
+	24, // 10: main.BlockMessage.get_latest_block_request:type_name -> main.GetLatestBlockRequest
+	25, // 11: main.BlockMessage.get_block_request:type_name -> main.GetBlockRequest
+	13, // 12: main.BlockMessage.empty:type_name -> main.Empty
 	3,  // 13: main.NodeMessage.nodes_response:type_name -> main.NodesResponse
 	4,  // 14: main.NodeMessage.welcome_request:type_name -> main.WelcomeRequest
 	5,  // 15: main.NodeMessage.welcome_response:type_name -> main.WelcomeResponse
 	6,  // 16: main.NodeMessage.pong_response:type_name -> main.PongResponse
-	9,  // 17: main.NodeMessage.empty:type_name -> main.Empty
-	10, // 18: main.BlockRequest.block:type_name -> main.Block
-	10, // 19: main.BlockResponse.block:type_name -> main.Block
-	11, // 20: main.Block.transactions:type_name -> main.Transaction
-	10, // 21: main.BlockchainResponse.blocks:type_name -> main.Block
-	10, // 22: main.BlocksResponse.blocks:type_name -> main.Block
-	11, // 23: main.TransactionPoolResponse.transactions:type_name -> main.Transaction
-	10, // 24: main.LatestBlockResponse.block:type_name -> main.Block
-	10, // 25: main.BlockUpdateRequest.block:type_name -> main.Block
-	10, // 26: main.BlockUpdateResponse.block:type_name -> main.Block
-	27, // [27:27] is the sub-list for method output_type
-	27, // [27:27] is the sub-list for method input_type
-	27, // [27:27] is the sub-list for extension type_name
-	27, // [27:27] is the sub-list for extension extendee
-	0,  // [0:27] is the sub-list for field type_name
+	13, // 17: main.NodeMessage.empty:type_name -> main.Empty
+	7,  // 18: main.NodeMessage.double_sign_evidence:type_name -> main.DoubleSignEvidence
+	8,  // 19: main.NodeMessage.finality_vote:type_name -> main.FinalityVote
+	9,  // 20: main.NodeMessage.finality_certificate:type_name -> main.FinalityCertificate
+	10, // 21: main.NodeMessage.finality_certificate_request:type_name -> main.FinalityCertificateRequest
+	14, // 22: main.DoubleSignEvidence.first:type_name -> main.Block
+	14, // 23: main.DoubleSignEvidence.second:type_name -> main.Block
+	8,  // 24: main.FinalityCertificate.precommits:type_name -> main.FinalityVote
+	14, // 25: main.BlockRequest.block:type_name -> main.Block
+	14, // 26: main.BlockResponse.block:type_name -> main.Block
+	15, // 27: main.Block.transactions:type_name -> main.Transaction
+	7,  // 28: main.Block.evidence:type_name -> main.DoubleSignEvidence
+	16, // 29: main.Transaction.inputs:type_name -> main.TxInput
+	17, // 30: main.Transaction.outputs:type_name -> main.TxOutput
+	14, // 31: main.BlockchainResponse.blocks:type_name -> main.Block
+	14, // 32: main.BlocksResponse.blocks:type_name -> main.Block
+	15, // 33: main.TransactionPoolResponse.transactions:type_name -> main.Transaction
+	14, // 34: main.LatestBlockResponse.block:type_name -> main.Block
+	14, // 35: main.BlockUpdateRequest.block:type_name -> main.Block
+	14, // 36: main.BlockUpdateResponse.block:type_name -> main.Block
+	0,  // 37: main.CaptureRecord.message:type_name -> main.MainMessage
+	38, // [38:38] is the sub-list for method output_type
+	38, // [38:38] is the sub-list for method input_type
+	38, // [38:38] is the sub-list for extension type_name
+	38, // [38:38] is the sub-list for extension extendee
+	0,  // [0:38] is the sub-list for field type_name
 }
 
 func init() { file_block_chain_proto_init() }
@@ -1616,7 +2335,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[7].Exporter = func(v any, i int) any {
-			switch v := v.(*BlockRequest); i {
+			switch v := v.(*DoubleSignEvidence); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1628,7 +2347,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[8].Exporter = func(v any, i int) any {
-			switch v := v.(*BlockResponse); i {
+			switch v := v.(*FinalityVote); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1640,7 +2359,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[9].Exporter = func(v any, i int) any {
-			switch v := v.(*Empty); i {
+			switch v := v.(*FinalityCertificate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1652,7 +2371,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[10].Exporter = func(v any, i int) any {
-			switch v := v.(*Block); i {
+			switch v := v.(*FinalityCertificateRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1664,7 +2383,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[11].Exporter = func(v any, i int) any {
-			switch v := v.(*Transaction); i {
+			switch v := v.(*BlockRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1676,7 +2395,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[12].Exporter = func(v any, i int) any {
-			switch v := v.(*BlockchainResponse); i {
+			switch v := v.(*BlockResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1688,7 +2407,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[13].Exporter = func(v any, i int) any {
-			switch v := v.(*BlocksResponse); i {
+			switch v := v.(*Empty); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1700,7 +2419,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[14].Exporter = func(v any, i int) any {
-			switch v := v.(*TransactionPoolResponse); i {
+			switch v := v.(*Block); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1712,7 +2431,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[15].Exporter = func(v any, i int) any {
-			switch v := v.(*LatestBlockResponse); i {
+			switch v := v.(*Transaction); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1724,7 +2443,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[16].Exporter = func(v any, i int) any {
-			switch v := v.(*BlockUpdateRequest); i {
+			switch v := v.(*TxInput); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1736,7 +2455,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[17].Exporter = func(v any, i int) any {
-			switch v := v.(*BlockUpdateResponse); i {
+			switch v := v.(*TxOutput); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1748,7 +2467,7 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[18].Exporter = func(v any, i int) any {
-			switch v := v.(*GetLatestBlockRequest); i {
+			switch v := v.(*BlockchainResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1760,6 +2479,78 @@ func file_block_chain_proto_init() {
 			}
 		}
 		file_block_chain_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*BlocksResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*TransactionPoolResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*LatestBlockResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[22].Exporter = func(v any, i int) any {
+			switch v := v.(*BlockUpdateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[23].Exporter = func(v any, i int) any {
+			switch v := v.(*BlockUpdateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[24].Exporter = func(v any, i int) any {
+			switch v := v.(*GetLatestBlockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_chain_proto_msgTypes[25].Exporter = func(v any, i int) any {
 			switch v := v.(*GetBlockRequest); i {
 			case 0:
 				return &v.state
@@ -1771,6 +2562,18 @@ func file_block_chain_proto_init() {
 				return nil
 			}
 		}
+		file_block_chain_proto_msgTypes[26].Exporter = func(v any, i int) any {
+			switch v := v.(*CaptureRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_block_chain_proto_msgTypes[0].OneofWrappers = []any{
 		(*MainMessage_BlockMessage)(nil),
@@ -1795,6 +2598,10 @@ func file_block_chain_proto_init() {
 		(*NodeMessage_WelcomeResponse)(nil),
 		(*NodeMessage_PongResponse)(nil),
 		(*NodeMessage_Empty)(nil),
+		(*NodeMessage_DoubleSignEvidence)(nil),
+		(*NodeMessage_FinalityVote)(nil),
+		(*NodeMessage_FinalityCertificate)(nil),
+		(*NodeMessage_FinalityCertificateRequest)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1802,7 +2609,7 @@ func file_block_chain_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_block_chain_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   20,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -2,7 +2,9 @@ package src
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
 	"sync"
 	"time"
 
@@ -11,37 +13,182 @@ import (
 )
 
 // Blockchain represents the blockchain.
+//
+// Blockchain is safe for concurrent use. Blocks are never modified once they are
+// in the tree: changes such as setting the checkpoint flag or pruning replace the
+// block of a node with an updated copy. Block nodes returned by the getters are
+// snapshots: copies of the node, its parent and its children that can be read
+// without locking. The parent and children of a snapshot do not link further
+// into the tree; use GetBlock to walk on.
 type Blockchain struct {
-	root *types.BlockNode
-	mux  sync.Mutex
+	root  *types.BlockNode
+	head  *types.BlockNode
+	nodes map[string]*types.BlockNode
+	index *chainIndex
+	mux   sync.RWMutex
+
+	// ledger is the ledger model; utxos is the UTXO set of the canonical chain
+	// and only kept on the UTXO ledger.
+	ledger LedgerModel
+	utxos  *utxoSet
+
+	checkpoints *checkpointSet
+	rules       *RuleSet
+	clock       interfaces.Clock
+	metrics     *Metrics
+	logger      *slog.Logger
+	engine      interfaces.ConsensusEngine
+	chainID     string
+	// finality is set when checkpoints need the agreement of a finality gadget
+	// instead of being pinned automatically; finalized is the latest final one.
+	finality  bool
+	finalized *Checkpoint
+	// archive keeps Prune from dropping the transactions of canonical blocks.
+	archive bool
 }
 
+// DefaultDifficulty is the number of "0" characters a block hash starts with by default.
+const DefaultDifficulty = 3
+
+// BlockchainOption configures a Blockchain.
+type BlockchainOption func(*Blockchain)
+
 // NewBlockchain creates a new Blockchain.
-func NewBlockchain() *Blockchain {
-	blockchain := &Blockchain{}
+func NewBlockchain(opts ...BlockchainOption) *Blockchain {
+	blockchain := newBlockchain(opts)
 	blockchain.createGenesisBlock()
 	return blockchain
 }
 
+// NewBlockchainFromGenesis creates a new Blockchain rooted at the given genesis block.
+func NewBlockchainFromGenesis(genesis *types.Block, opts ...BlockchainOption) *Blockchain {
+	blockchain := newBlockchain(opts)
+	blockchain.resetTree(&types.BlockNode{
+		Block:  genesis,
+		Parent: nil,
+		Childs: make([]*types.BlockNode, 0),
+	})
+	return blockchain
+}
+
+// newBlockchain creates a Blockchain without blocks and applies the options.
+func newBlockchain(opts []BlockchainOption) *Blockchain {
+	blockchain := &Blockchain{
+		checkpoints: newCheckpointSet(DefaultCheckpointInterval),
+		rules:       DefaultRules(),
+		clock:       SystemClock{},
+		logger:      slog.Default(),
+		engine:      NewProofOfWork(DefaultDifficulty),
+	}
+	for _, opt := range opts {
+		opt(blockchain)
+	}
+	if engine, ok := blockchain.engine.(interfaces.ClockedEngine); ok {
+		engine.SetClock(blockchain.clock)
+	}
+	if _, ok := blockchain.engine.(InstantSeal); ok {
+		if err := checkInstantSeal(blockchain.chainID); err != nil {
+			blockchain.logger.Error("Refusing blocks sealed without proof of work", errorAttr(err))
+			blockchain.engine = InstantSeal{err: err}
+		}
+	}
+	return blockchain
+}
+
+// WithClock makes the blockchain tell the time from clock instead of the system clock.
+func WithClock(clock interfaces.Clock) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.clock = clock
+	}
+}
+
+// WithChainID sets the ID of the network the blockchain belongs to. Signed
+// transactions are only valid on the network they were signed for.
+func WithChainID(chainID string) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.chainID = chainID
+	}
+}
+
+// ChainID returns the ID of the network the blockchain belongs to.
+func (bc *Blockchain) ChainID() string {
+	return bc.chainID
+}
+
+// WithLogger makes the blockchain write its log records to logger.
+func WithLogger(logger *slog.Logger) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.logger = logger
+	}
+}
+
 // createGenesisBlock creates the genesis block.
 func (bc *Blockchain) createGenesisBlock() {
 	genesisBlock := &types.Block{
 		Index:        0,
-		Timestamp:    uint64(time.Now().Unix()),
+		Timestamp:    uint64(bc.clock.Now().Unix()),
 		Transactions: make([]types.Transaction, 0),
 		PreviousHash: []byte("0"),
 		Data:         0,
 	}
-	bc.root = &types.BlockNode{
+	bc.resetTree(&types.BlockNode{
 		Block:  genesisBlock,
 		Parent: nil,
 		Childs: make([]*types.BlockNode, 0),
+	})
+}
+
+// resetTree makes root the only block of the blockchain and rebuilds the indexes.
+func (bc *Blockchain) resetTree(root *types.BlockNode) {
+	bc.root = root
+	bc.head = root
+	root.Hash = root.Block.CalculateHash()
+	root.TotalWork = new(big.Int)
+	bc.nodes = map[string]*types.BlockNode{string(root.Hash): root}
+	bc.index = newChainIndex()
+	bc.index.connect(root)
+	if bc.ledger == LedgerUTXO {
+		bc.utxos = newUTXOSet()
+		bc.utxos.connect(root)
 	}
 }
 
 // GetRoot returns the root block node.
 func (bc *Blockchain) GetRoot() *types.BlockNode {
-	return bc.root
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	return snapshot(bc.root)
+}
+
+// snapshot returns a copy of node that can be read without holding the lock.
+// The copy shares the immutable block. Its parent and children are copies too,
+// but without parents and children of their own, as the nodes of the tree are
+// updated in place under the lock.
+func snapshot(node *types.BlockNode) *types.BlockNode {
+	if node == nil {
+		return nil
+	}
+	snap := detached(node)
+	snap.Parent = detached(node.Parent)
+	snap.Childs = make([]*types.BlockNode, len(node.Childs))
+	for i, child := range node.Childs {
+		snap.Childs[i] = detached(child)
+	}
+	return snap
+}
+
+// detached returns a copy of node without its parent and children.
+func detached(node *types.BlockNode) *types.BlockNode {
+	if node == nil {
+		return nil
+	}
+	return &types.BlockNode{
+		Block:     node.Block,
+		Hash:      node.Hash,
+		Pruned:    node.Pruned,
+		TotalWork: node.TotalWork,
+	}
 }
 
 // AddBlock adds a new block to the blockchain.
@@ -49,69 +196,250 @@ func (bc *Blockchain) AddBlock(parent *types.BlockNode, block *types.Block) erro
 	bc.mux.Lock()
 	defer bc.mux.Unlock()
 
-	if err := bc.ValidateBlock(block, parent.Block); err != nil {
+	// parent may be a snapshot, so the node in the tree is looked up by hash.
+	parentHash := parent.Hash
+	if parentHash == nil {
+		parentHash = parent.Block.CalculateHash()
+	}
+	parent = bc.nodes[string(parentHash)]
+	if parent == nil {
+		return ErrUnknownParent
+	}
+
+	if err := bc.timeValidation(block, bc.validationContext(parent)); err != nil {
+		return err
+	}
+
+	if err := bc.checkCheckpoints(parent, block); err != nil {
 		return err
 	}
 
+	if _, exists := bc.nodes[string(block.CalculateHash())]; exists {
+		return ErrBlockExists
+	}
+
+	blockNode := bc.attachBlock(parent, block)
+	bc.chooseHead(blockNode)
+
+	return nil
+}
+
+// AdoptChain adds a branch of consecutive blocks, typically received from a peer.
+// Leading blocks that are already known are skipped; the parent of the first
+// unknown block is the common ancestor with the existing tree. Every block of
+// the branch is validated before anything is attached, so an invalid block
+// leaves the blockchain untouched. The branch is attached as a fork and fork
+// choice decides whether it becomes the canonical chain.
+func (bc *Blockchain) AdoptChain(blocks []*types.Block) error {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	start := 0
+	for start < len(blocks) && bc.nodes[string(blocks[start].CalculateHash())] != nil {
+		start++
+	}
+	if start == len(blocks) {
+		return nil
+	}
+	branch := blocks[start:]
+
+	ancestor := bc.nodes[string(branch[0].PreviousHash)]
+	if ancestor == nil {
+		return ErrNoCommonAncestor
+	}
+
+	ctx := bc.validationContext(ancestor)
+	for _, block := range branch {
+		if err := bc.timeValidation(block, ctx); err != nil {
+			return fmt.Errorf("block %d: %w", block.Index, err)
+		}
+		if err := bc.checkCheckpoints(ancestor, block); err != nil {
+			return fmt.Errorf("block %d: %w", block.Index, err)
+		}
+		ctx = ctx.extend(block)
+	}
+
+	tip := ancestor
+	for _, block := range branch {
+		tip = bc.attachBlock(tip, block)
+	}
+	bc.chooseHead(tip)
+
+	return nil
+}
+
+// attachBlock inserts an already validated block below parent and returns its node.
+func (bc *Blockchain) attachBlock(parent *types.BlockNode, block *types.Block) *types.BlockNode {
 	blockNode := &types.BlockNode{
 		Block:  block,
 		Parent: parent,
 		Childs: make([]*types.BlockNode, 0),
+		Hash:   block.CalculateHash(),
+	}
+	blockNode.TotalWork = new(big.Int).Add(parent.TotalWork, bc.engine.CalculateWork(block))
+
+	// The checkpoint flag is local metadata; it is set by ApproveBlock once the block is canonical.
+	if block.Checkpoint {
+		unflagged := *block
+		unflagged.Checkpoint = false
+		blockNode.Block = &unflagged
 	}
 
 	parent.Childs = append(parent.Childs, blockNode)
+	bc.nodes[string(blockNode.Hash)] = blockNode
 
-	// Call ApproveBlock to check and set checkpoint
-	bc.ApproveBlock(blockNode)
+	return blockNode
+}
 
-	return nil
+// chooseHead applies fork choice: the chain with the most work, as calculated by
+// the consensus engine, wins, and the first seen branch is kept on ties. A chain
+// conflicting with the latest finalized checkpoint never wins, and a chain
+// through it wins over one that does not reach it.
+func (bc *Blockchain) chooseHead(candidate *types.BlockNode) {
+	if bc.finalized != nil {
+		if bc.conflictsWithFinalized(candidate) {
+			return
+		}
+		if final := bc.includesFinalized(candidate); final != bc.includesFinalized(bc.head) {
+			if final {
+				bc.setHead(candidate)
+			}
+			return
+		}
+	}
+	if candidate.TotalWork.Cmp(bc.head.TotalWork) > 0 {
+		bc.setHead(candidate)
+	}
 }
 
-// ApproveBlock sets the checkpoint flag for the block if it meets the criteria.
+// setHead makes newHead the tip of the canonical chain, disconnecting the blocks
+// of the old branch and connecting the blocks of the new one in the indexes.
+func (bc *Blockchain) setHead(newHead *types.BlockNode) {
+	oldNode, newNode := bc.head, newHead
+	var connect []*types.BlockNode
+
+	disconnected := 0
+	for newNode.Block.Index > oldNode.Block.Index {
+		connect = append(connect, newNode)
+		newNode = newNode.Parent
+	}
+	for oldNode.Block.Index > newNode.Block.Index {
+		bc.disconnect(oldNode)
+		oldNode = oldNode.Parent
+		disconnected++
+	}
+	for oldNode != newNode {
+		bc.disconnect(oldNode)
+		connect = append(connect, newNode)
+		oldNode, newNode = oldNode.Parent, newNode.Parent
+		disconnected++
+	}
+	if disconnected > 0 {
+		bc.logger.Info("Chain reorganized", blockHashAttr(newHead.Hash, newHead.Block.Index), slog.Int("depth", disconnected))
+		if bc.metrics != nil {
+			bc.metrics.ReorgDepth.Observe(float64(disconnected))
+		}
+	}
+
+	for i := len(connect) - 1; i >= 0; i-- {
+		bc.connect(connect[i])
+		// Call ApproveBlock to check and set checkpoint
+		bc.ApproveBlock(connect[i])
+	}
+	bc.head = newHead
+	bc.logger.Debug("New head", blockHashAttr(newHead.Hash, newHead.Block.Index))
+}
+
+// connect adds a block that became part of the canonical chain to the indexes.
+func (bc *Blockchain) connect(node *types.BlockNode) {
+	bc.index.connect(node)
+	if bc.utxos != nil {
+		bc.utxos.connect(node)
+	}
+}
+
+// disconnect removes a block that is no longer part of the canonical chain from
+// the indexes. Blocks must be disconnected from the tip downwards.
+func (bc *Blockchain) disconnect(node *types.BlockNode) {
+	bc.index.disconnect(node)
+	if bc.utxos != nil {
+		bc.utxos.disconnect(node)
+	}
+}
+
+// ApproveBlock sets the checkpoint flag for a canonical block. Unless a finality
+// gadget decides on checkpoints, blocks at every checkpoint interval become
+// automatic checkpoints, which are persisted if a checkpoint file is configured.
 func (bc *Blockchain) ApproveBlock(blockNode *types.BlockNode) {
-	if blockNode.Block.Index%10 == 0 {
-		blockNode.Block.Checkpoint = true
+	block := blockNode.Block
+	blockHash := blockNode.Hash
+
+	if !bc.finality && bc.checkpoints.isAutomatic(block.Index) && bc.checkpoints.hashAt(block.Index) == nil {
+		bc.checkpoints.add(Checkpoint{Height: block.Index, Hash: blockHash})
+		bc.saveCheckpoints()
+	}
+
+	if checkpoint := bytes.Equal(bc.checkpoints.hashAt(block.Index), blockHash); checkpoint != block.Checkpoint {
+		flagged := *block
+		flagged.Checkpoint = checkpoint
+		blockNode.Block = &flagged
 	}
 }
 
 // ValidateBlock validates a block against its parent block.
 func (bc *Blockchain) ValidateBlock(block *types.Block, parentBlock *types.Block) error {
-	if block.Index != parentBlock.Index+1 {
-		return errors.New("Block index is not valid")
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	ctx := bc.validationContext(&types.BlockNode{Block: parentBlock})
+	if parent := bc.nodes[string(parentBlock.CalculateHash())]; parent != nil {
+		ctx = bc.validationContext(parent)
 	}
+	return bc.validateBlock(block, ctx)
+}
 
-	if !bytes.Equal(block.PreviousHash, parentBlock.CalculateHash()) {
-		return errors.New("Previous hash is not valid")
+// validateBlock checks that block extends ctx.Parent, passes the validation rules
+// and is accepted by the consensus engine. The caller must hold bc.mux.
+func (bc *Blockchain) validateBlock(block *types.Block, ctx *ValidationContext) error {
+	if block.Index != ctx.Parent.Index+1 {
+		return fmt.Errorf("%w: %d after parent %d", ErrInvalidIndex, block.Index, ctx.Parent.Index)
 	}
 
-	hashPrefix := block.CalculateHash()[:3]
-	if !bytes.Equal(hashPrefix, []byte("000")) {
-		return errors.New("Block hash is not valid")
+	if !bytes.Equal(block.PreviousHash, ctx.Parent.CalculateHash()) {
+		return ErrPrevHashMismatch
 	}
 
-	return nil
-}
+	if err := bc.rules.Validate(ctx, block); err != nil {
+		return err
+	}
 
-// convertToBlockNodes converts a slice of blocks to a slice of block nodes.
-func (bc *Blockchain) convertToBlockNodes(blocks []*types.Block) []*types.BlockNode {
-	blockNodes := make([]*types.BlockNode, len(blocks))
-	for i, block := range blocks {
-		blockNodes[i] = &types.BlockNode{
-			Block:  block,
-			Parent: nil, // You need to set the correct parent here
-			Childs: make([]*types.BlockNode, 0),
-		}
+	if err := bc.checkLedger(ctx, block); err != nil {
+		return err
 	}
-	return blockNodes
+
+	return bc.engine.VerifyHeader(ctx.parentNode, block)
 }
 
-// ReplaceBlocks replaces the current blocks with new blocks.
-func (bc *Blockchain) ReplaceBlocks(blocks []*types.Block) {
-	bc.mux.Lock()
-	defer bc.mux.Unlock()
+// timeValidation validates a block like validateBlock and records how long it took.
+// The caller must hold bc.mux.
+func (bc *Blockchain) timeValidation(block *types.Block, ctx *ValidationContext) error {
+	if bc.metrics == nil {
+		return bc.validateBlock(block, ctx)
+	}
+	start := time.Now()
+	err := bc.validateBlock(block, ctx)
+	bc.metrics.BlockValidationSeconds.Observe(time.Since(start).Seconds())
+	return err
+}
 
-	blockNodes := bc.convertToBlockNodes(blocks)
-	bc.root = blockNodes[0] // Assuming the first block is the root
+// validationContext returns the context for validating a child of parent.
+// The caller must hold bc.mux.
+func (bc *Blockchain) validationContext(parent *types.BlockNode) *ValidationContext {
+	timestamps := make([]uint64, 0, MedianTimeSpan)
+	for node := parent; node != nil && len(timestamps) < MedianTimeSpan; node = node.Parent {
+		timestamps = append(timestamps, node.Block.Timestamp)
+	}
+	return &ValidationContext{Parent: parent.Block, PastTimestamps: timestamps, Now: bc.clock.Now(), ChainID: bc.chainID, parentNode: parent, utxos: bc.utxoView(parent)}
 }
 
 // BlockExists checks if a block exists in the blockchain.
@@ -120,6 +448,7 @@ func (bc *Blockchain) BlockExists(hash []byte) bool {
 }
 
 // traverseTree traverses the blockchain tree and applies a callback function to each node.
+// The caller must hold bc.mux.
 func (bc *Blockchain) traverseTree(callback func(node *types.BlockNode) bool) {
 	var queue []*types.BlockNode
 
@@ -141,45 +470,113 @@ func (bc *Blockchain) traverseTree(callback func(node *types.BlockNode) bool) {
 
 // GetBlock returns a block node by its hash.
 func (bc *Blockchain) GetBlock(hash []byte) *types.BlockNode {
-	var foundNode *types.BlockNode
-	bc.traverseTree(func(node *types.BlockNode) bool {
-		calculatedHash := node.Block.CalculateHash()
-		if bytes.Equal(calculatedHash, hash) {
-			foundNode = node
-			return true
-		}
-		return false
-	})
-	return foundNode
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	return snapshot(bc.nodes[string(hash)])
 }
 
-// GetLatestBlock returns the latest block in the blockchain.
+// GetLatestBlock returns the head of the canonical chain.
 func (bc *Blockchain) GetLatestBlock() *types.Block {
-	var longestPath []*types.BlockNode
-	bc.traverseTree(func(node *types.BlockNode) bool {
-		if len(node.Childs) > len(longestPath) {
-			longestPath = node.Childs
-		}
-		return false
-	})
-	if len(longestPath) == 0 {
-		return bc.root.Block
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	return bc.head.Block
+}
+
+// GetBlockByHeight returns the canonical block node at the given height.
+func (bc *Blockchain) GetBlockByHeight(height uint64) *types.BlockNode {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	hash := bc.index.hashAt(height)
+	if hash == nil {
+		return nil
 	}
-	return longestPath[len(longestPath)-1].Block
+	return snapshot(bc.nodes[string(hash)])
 }
 
-// GenerateNewBlock generates a new block with the given transactions.
+// GetTransaction returns a canonical transaction by its hash together with its location.
+// If the same transaction was included more than once, the most recent inclusion is returned.
+// For blocks whose transactions have been pruned only the location is returned.
+func (bc *Blockchain) GetTransaction(hash []byte) (*types.Transaction, *TxLocation) {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	locations := bc.index.byTx[string(hash)]
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	location := locations[len(locations)-1]
+	node := bc.nodes[string(location.BlockHash)]
+	if node.Pruned {
+		return nil, &location
+	}
+	return &node.Block.Transactions[location.Position], &location
+}
+
+// GetAddressTransactions returns the locations of all canonical transactions
+// sent or received by the given address, ordered by height.
+func (bc *Blockchain) GetAddressTransactions(address []byte) []TxLocation {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	locations := bc.index.byAddress[string(address)]
+	result := make([]TxLocation, len(locations))
+	copy(result, locations)
+	return result
+}
+
+// GenerateNewBlock generates a new block with the given transactions on top of
+// the head, prepared and finalized by the consensus engine but not sealed.
 func (bc *Blockchain) GenerateNewBlock(transaction []types.Transaction) *types.Block {
-	latestBlock := bc.GetLatestBlock()
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	latestBlock := bc.head.Block
+	ctx := bc.validationContext(bc.head)
+
+	// The timestamp must be after the median of the past blocks, even if blocks
+	// follow each other within a second.
+	timestamp := uint64(bc.clock.Now().Unix())
+	if median := ctx.MedianTimePast(); timestamp <= median {
+		timestamp = median + 1
+	}
 	newBlock := &types.Block{
 		Index:        latestBlock.Index + 1,
-		Timestamp:    uint64(time.Now().Unix()),
+		Timestamp:    timestamp,
 		Transactions: transaction,
 		PreviousHash: latestBlock.CalculateHash(),
 		Data:         0,
 	}
+	// A block the engine fails to prepare is still returned; it fails validation
+	// with the same error when it is added.
+	if err := bc.engine.Prepare(bc.head, newBlock); err != nil {
+		bc.logger.Warn("Failed to prepare block", blockAttr(newBlock), errorAttr(err))
+	} else if err := bc.engine.Finalize(bc.head, newBlock); err != nil {
+		bc.logger.Warn("Failed to finalize block", blockAttr(newBlock), errorAttr(err))
+	}
 	return newBlock
 }
 
+// GetConsensusEngine returns the engine that produces and validates blocks.
+func (bc *Blockchain) GetConsensusEngine() interfaces.ConsensusEngine {
+	return bc.engine
+}
+
+// forkCount returns the number of tips besides the head of the canonical chain.
+func (bc *Blockchain) forkCount() int {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	tips := 0
+	for _, node := range bc.nodes {
+		if len(node.Childs) == 0 {
+			tips++
+		}
+	}
+	return tips - 1
+}
+
 // Ensure Blockchain implements BlockchainInterface
 var _ interfaces.BlockchainInterface = (*Blockchain)(nil)
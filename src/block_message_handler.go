@@ -1,165 +1,320 @@
 package src
 
 import (
-	"log"
+	"bytes"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
 	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
 	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
-	"google.golang.org/protobuf/proto"
 )
 
+// maxOrphanBlocks limits the number of blocks kept while their ancestors are being fetched.
+const maxOrphanBlocks = 256
+
+// orphanExpiry is how long a block waits for its parent before it is dropped.
+const orphanExpiry = 10 * time.Minute
+
+// orphanBlock is a block waiting for its parent and the time it arrived.
+type orphanBlock struct {
+	block *types.Block
+	added time.Time
+}
+
 // BlockMessageHandlerImpl handles block-related messages.
 type BlockMessageHandlerImpl struct {
 	blockchain    interfaces.BlockchainInterface
 	messageSender interfaces.MessageSender
+	orphans       map[string][]orphanBlock
+	orphanCount   int
+	orphansMux    sync.Mutex
+	clock         interfaces.Clock
+	onInvalid     func(reply interfaces.MessageSender, err error)
+	metrics       *Metrics
+	logger        *slog.Logger
 }
 
-// NewBlockMessageHandler creates a new BlockMessageHandlerImpl.
+// NewBlockMessageHandler creates a new BlockMessageHandlerImpl. Newly accepted
+// blocks are relayed through messageSender.
 func NewBlockMessageHandler(blockchain interfaces.BlockchainInterface, messageSender interfaces.MessageSender) *BlockMessageHandlerImpl {
-	return &BlockMessageHandlerImpl{blockchain: blockchain, messageSender: messageSender}
+	return &BlockMessageHandlerImpl{
+		blockchain:    blockchain,
+		messageSender: messageSender,
+		orphans:       make(map[string][]orphanBlock),
+		clock:         SystemClock{},
+		logger:        slog.Default(),
+	}
+}
+
+// OnInvalidMessage registers a function called with the peer and the error whenever
+// a block received from a peer is rejected, so that the peer can be penalized.
+func (h *BlockMessageHandlerImpl) OnInvalidMessage(fn func(reply interfaces.MessageSender, err error)) {
+	h.onInvalid = fn
+}
+
+// SetLogger makes the handler write its log records to logger.
+func (h *BlockMessageHandlerImpl) SetLogger(logger *slog.Logger) {
+	h.logger = logger
+}
+
+// SetClock makes the handler age its orphan blocks by clock.
+func (h *BlockMessageHandlerImpl) SetClock(clock interfaces.Clock) {
+	h.orphansMux.Lock()
+	defer h.orphansMux.Unlock()
+
+	h.clock = clock
 }
 
-// HandleBlockMessage processes incoming block messages.
+// SetMetrics makes the handler count rejected blocks and report its orphan blocks in m.
+func (h *BlockMessageHandlerImpl) SetMetrics(m *Metrics) {
+	h.metrics = m
+	m.GaugeFunc("node_orphan_blocks", "Blocks waiting for their parent.", func() float64 {
+		return float64(h.OrphanCount())
+	})
+}
+
+// OrphanCount returns the number of blocks waiting for their parent.
+func (h *BlockMessageHandlerImpl) OrphanCount() int {
+	h.orphansMux.Lock()
+	defer h.orphansMux.Unlock()
+
+	return h.orphanCount
+}
+
+// reject logs why a block received from reply was refused and reports it.
+func (h *BlockMessageHandlerImpl) reject(reply interfaces.MessageSender, block *types.Block, message string, err error) {
+	h.logger.Warn(message, peerAttr(reply), blockAttr(block), errorAttr(err))
+	if h.metrics != nil {
+		h.metrics.BlocksRejected.Add(1)
+	}
+	if h.onInvalid != nil {
+		h.onInvalid(reply, err)
+	}
+}
+
+// HandleBlockMessage processes incoming block messages, replying through the handler's message sender.
 func (h *BlockMessageHandlerImpl) HandleBlockMessage(msg *block_chain.BlockMessage) {
+	h.HandleBlockMessageFrom(msg, h.messageSender)
+}
+
+// HandleBlockMessageFrom processes a block message received from a peer. Replies go to reply.
+func (h *BlockMessageHandlerImpl) HandleBlockMessageFrom(msg *block_chain.BlockMessage, reply interfaces.MessageSender) {
 	switch blockMsg := msg.BlockMessageType.(type) {
 	case *block_chain.BlockMessage_GetLatestBlockRequest:
-		h.handleGetLatestBlock(nil)
+		h.sendBlock(reply, h.blockchain.GetLatestBlock(), "Latest block")
 	case *block_chain.BlockMessage_GetBlockRequest_:
-		h.handleGetBlockRequest(blockMsg.GetBlockRequest_.Hash)
+		h.handleGetBlockRequest(blockMsg.GetBlockRequest_.GetHash(), reply)
 	case *block_chain.BlockMessage_BlockResponse:
-		h.handleBlockResponse(blockMsg.BlockResponse.Message)
+		h.handleBlockResponse(blockMsg.BlockResponse, reply)
 	}
 }
 
-// handleGetLatestBlock processes a request for the latest block.
-func (h *BlockMessageHandlerImpl) handleGetLatestBlock(data []byte) {
-	if data != nil {
-		getLatestBlockRequest := &block_chain.GetLatestBlockRequest{}
-		err := proto.Unmarshal(data, getLatestBlockRequest)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-	}
-	h.SendLatestBlock()
-}
-
 // handleGetBlockRequest processes a request for a specific block.
-func (h *BlockMessageHandlerImpl) handleGetBlockRequest(hash []byte) {
-	getBlockRequest := &block_chain.GetBlockRequest{Hash: hash}
-	block := h.blockchain.GetBlock(getBlockRequest.GetHash())
-	if block != nil {
-		h.SendBlock(block)
+func (h *BlockMessageHandlerImpl) handleGetBlockRequest(hash []byte, reply interfaces.MessageSender) {
+	block := h.blockchain.GetBlock(hash)
+	// Pruned blocks cannot be validated by peers, so they are not served.
+	if block != nil && !block.Pruned {
+		h.sendBlock(reply, block.Block, "Block")
 	}
 }
 
 // handleBlockResponse processes a block response message.
-func (h *BlockMessageHandlerImpl) handleBlockResponse(data []byte) {
-	blockResponse := &block_chain.BlockResponse{}
-	err := proto.Unmarshal(data, blockResponse)
-	if err != nil {
-		log.Println(err)
+func (h *BlockMessageHandlerImpl) handleBlockResponse(blockResponse *block_chain.BlockResponse, reply interfaces.MessageSender) {
+	if blockResponse.GetBlock() == nil {
+		h.reject(reply, nil, "Received block response without a block", ErrMalformedMessage)
 		return
 	}
 	block := types.BlockFromProto(blockResponse.GetBlock())
-	blockHash := block.CalculateHash()
-	if !h.blockchain.BlockExists(blockHash) {
-		h.GetBlock(block.PreviousHash)
-	} else {
-		parent := h.blockchain.GetBlock(block.PreviousHash)
-		if parent != nil {
-			// Validate the block before adding it to the blockchain
-			err := h.blockchain.ValidateBlock(block, parent.Block)
-			if err != nil {
-				log.Println("Received invalid block: ", err)
-			} else {
-				err := h.blockchain.AddBlock(parent, block)
-				if err != nil {
-					log.Println(err)
-				} else {
-					// Send a success message
-					h.SendBlock(parent)
-				}
-			}
-		}
+	// Refuse blocks from peers whose chain conflicts with a checkpoint instead of
+	// walking back through their ancestors.
+	if err := h.blockchain.CheckCheckpoint(block); err != nil {
+		h.reject(reply, block, "Refusing block that conflicts with a checkpoint", err)
+		return
 	}
+	h.acceptBlock(block, reply)
 }
 
-// SendBlock sends a block to the message sender.
-func (h *BlockMessageHandlerImpl) SendBlock(blockNode *types.BlockNode) {
-	protoBlock := blockNode.Block.ToProto()
+// acceptBlock adds a block received from a peer. A block whose parent is unknown is
+// kept aside while the parent is requested from the same peer; once the parent is
+// added, the blocks waiting for it are accepted too.
+func (h *BlockMessageHandlerImpl) acceptBlock(block *types.Block, reply interfaces.MessageSender) {
+	blockHash := block.CalculateHash()
+	if h.blockchain.BlockExists(blockHash) {
+		return
+	}
 
-	blockResponse := &block_chain.BlockResponse{
-		Success: true,
-		Message: []byte("Block"),
-		Block:   protoBlock,
+	parent := h.blockchain.GetBlock(block.PreviousHash)
+	if parent == nil {
+		h.logger.Debug("Requesting parent of orphan block", peerAttr(reply), blockAttr(block))
+		h.addOrphan(block)
+		h.requestBlock(reply, block.PreviousHash)
+		return
 	}
 
-	data, err := EncodeMessage(blockResponse)
-	if err != nil {
-		log.Fatal(err)
+	// Validate the block before adding it to the blockchain
+	if err := h.blockchain.ValidateBlock(block, parent.Block); err != nil {
+		h.reject(reply, block, "Received invalid block", err)
+		return
 	}
+	if err := h.blockchain.AddBlock(parent, block); err != nil {
+		h.reject(reply, block, "Failed to add block", err)
+		return
+	}
+	h.logger.Info("Accepted block", peerAttr(reply), blockAttr(block))
+	h.AnnounceBlock(block)
 
-	err = h.messageSender.SendMsg(data)
-	if err != nil {
-		log.Fatal(err)
+	for _, orphan := range h.takeOrphans(blockHash) {
+		h.acceptBlock(orphan, reply)
 	}
 }
 
-// SendLatestBlock sends the latest block to the message sender.
-func (h *BlockMessageHandlerImpl) SendLatestBlock() {
-	latestBlock := h.blockchain.GetLatestBlock()
-
-	protoBlock := latestBlock.ToProto()
+// addOrphan keeps a block whose parent is not known yet. Blocks that waited longer
+// than orphanExpiry are dropped first; if the pool is still full, the oldest block
+// makes room for the new one.
+func (h *BlockMessageHandlerImpl) addOrphan(block *types.Block) {
+	h.orphansMux.Lock()
+	defer h.orphansMux.Unlock()
 
-	blockResponse := &block_chain.BlockResponse{
-		Success: true,
-		Message: []byte("Latest block"),
-		Block:   protoBlock,
+	key := string(block.PreviousHash)
+	blockHash := block.CalculateHash()
+	for _, orphan := range h.orphans[key] {
+		if bytes.Equal(orphan.block.CalculateHash(), blockHash) {
+			return
+		}
 	}
-
-	data, err := EncodeMessage(blockResponse)
-	if err != nil {
-		log.Fatal(err)
+	now := h.clock.Now()
+	h.expireOrphans(now)
+	if h.orphanCount >= maxOrphanBlocks {
+		h.evictOldestOrphan()
 	}
+	h.orphans[key] = append(h.orphans[key], orphanBlock{block: block, added: now})
+	h.orphanCount++
+}
 
-	err = h.messageSender.SendMsg(data)
-	if err != nil {
-		log.Fatal(err)
+// expireOrphans drops the blocks that have waited for their parent since before
+// now minus orphanExpiry. The caller must hold orphansMux.
+func (h *BlockMessageHandlerImpl) expireOrphans(now time.Time) {
+	for key, orphans := range h.orphans {
+		kept := orphans[:0]
+		for _, orphan := range orphans {
+			if now.Sub(orphan.added) < orphanExpiry {
+				kept = append(kept, orphan)
+			}
+		}
+		h.orphanCount -= len(orphans) - len(kept)
+		if len(kept) == 0 {
+			delete(h.orphans, key)
+		} else {
+			h.orphans[key] = kept
+		}
 	}
 }
 
-// GetBlock requests a block by its hash.
-func (h *BlockMessageHandlerImpl) GetBlock(blockHash []byte) {
-	getBlockRequest := &block_chain.GetBlockRequest{
-		Hash: blockHash,
+// evictOldestOrphan drops the block that has waited longest for its parent. The
+// caller must hold orphansMux.
+func (h *BlockMessageHandlerImpl) evictOldestOrphan() {
+	var oldestKey string
+	oldest := -1
+	var oldestAdded time.Time
+	for key, orphans := range h.orphans {
+		for i, orphan := range orphans {
+			if oldest < 0 || orphan.added.Before(oldestAdded) {
+				oldestKey, oldest, oldestAdded = key, i, orphan.added
+			}
+		}
 	}
-
-	data, err := EncodeMessage(getBlockRequest)
-	if err != nil {
-		log.Printf("Failed to encode message: %v", err)
+	if oldest < 0 {
 		return
 	}
+	orphans := h.orphans[oldestKey]
+	h.logger.Warn("Orphan block pool is full, dropping oldest block", blockAttr(orphans[oldest].block))
+	orphans = append(orphans[:oldest], orphans[oldest+1:]...)
+	if len(orphans) == 0 {
+		delete(h.orphans, oldestKey)
+	} else {
+		h.orphans[oldestKey] = orphans
+	}
+	h.orphanCount--
+}
 
-	err = h.messageSender.SendMsg(data)
-	if err != nil {
-		log.Printf("Failed to send message: %v", err)
+// takeOrphans removes and returns the blocks waiting for the given parent.
+func (h *BlockMessageHandlerImpl) takeOrphans(parentHash []byte) []*types.Block {
+	h.orphansMux.Lock()
+	defer h.orphansMux.Unlock()
+
+	orphans := h.orphans[string(parentHash)]
+	delete(h.orphans, string(parentHash))
+	h.orphanCount -= len(orphans)
+	blocks := make([]*types.Block, len(orphans))
+	for i, orphan := range orphans {
+		blocks[i] = orphan.block
 	}
+	return blocks
+}
+
+// AnnounceBlock relays a block to the handler's message sender.
+func (h *BlockMessageHandlerImpl) AnnounceBlock(block *types.Block) {
+	h.sendBlock(h.messageSender, block, "Block")
+}
+
+// SendBlock sends a block to the message sender.
+func (h *BlockMessageHandlerImpl) SendBlock(blockNode *types.BlockNode) {
+	h.sendBlock(h.messageSender, blockNode.Block, "Block")
+}
+
+// SendLatestBlock sends the latest block to the message sender.
+func (h *BlockMessageHandlerImpl) SendLatestBlock() {
+	h.sendBlock(h.messageSender, h.blockchain.GetLatestBlock(), "Latest block")
+}
+
+// sendBlock sends a block response to sender.
+func (h *BlockMessageHandlerImpl) sendBlock(sender interfaces.MessageSender, block *types.Block, message string) {
+	h.send(sender, &block_chain.BlockMessage{
+		BlockMessageType: &block_chain.BlockMessage_BlockResponse{
+			BlockResponse: &block_chain.BlockResponse{
+				Success: true,
+				Message: []byte(message),
+				Block:   block.ToProto(),
+			},
+		},
+	})
+}
+
+// GetBlock requests a block by its hash.
+func (h *BlockMessageHandlerImpl) GetBlock(blockHash []byte) {
+	h.requestBlock(h.messageSender, blockHash)
+}
+
+// requestBlock asks sender for the block with the given hash.
+func (h *BlockMessageHandlerImpl) requestBlock(sender interfaces.MessageSender, blockHash []byte) {
+	h.send(sender, &block_chain.BlockMessage{
+		BlockMessageType: &block_chain.BlockMessage_GetBlockRequest_{
+			GetBlockRequest_: &block_chain.GetBlockRequest{Hash: blockHash},
+		},
+	})
 }
 
 // GetLatestBlock requests the latest block.
 func (h *BlockMessageHandlerImpl) GetLatestBlock() {
-	emptyMessage := &block_chain.Empty{}
-	data, err := EncodeMessage(emptyMessage)
+	h.send(h.messageSender, &block_chain.BlockMessage{
+		BlockMessageType: &block_chain.BlockMessage_GetLatestBlockRequest{
+			GetLatestBlockRequest: &block_chain.GetLatestBlockRequest{},
+		},
+	})
+}
+
+// send encodes a block message and sends it to sender.
+func (h *BlockMessageHandlerImpl) send(sender interfaces.MessageSender, msg *block_chain.BlockMessage) {
+	data, err := EncodeBlockMessage(msg)
 	if err != nil {
-		log.Printf("Failed to encode message: %v", err)
+		h.logger.Error("Failed to encode message", errorAttr(err))
 		return
 	}
 
-	err = h.messageSender.SendMsg(data)
-	if err != nil {
-		log.Printf("Failed to send message: %v", err)
+	if err := sender.SendMsg(data); err != nil {
+		h.logger.Warn("Failed to send message", peerAttr(sender), slog.String(LogKeyMessageType, innerMessageType(msg.ProtoReflect())), errorAttr(err))
 	}
 }
 
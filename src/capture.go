@@ -0,0 +1,246 @@
+package src
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxCaptureRecordSize limits the size of a single record in a capture file; a
+// record is a message plus a few fields.
+const maxCaptureRecordSize = MaxMessageSize + 4096
+
+// CapturedMessage is a message a node received or sent.
+type CapturedMessage struct {
+	Time     time.Time
+	Peer     string
+	Outbound bool
+	// Message is the decoded message, or nil if it could not be decoded.
+	Message *block_chain.MainMessage
+	// Data is the message as it went over the wire.
+	Data []byte
+}
+
+// Recorder writes every message a node receives and sends to a capture file, so
+// that what the node saw can be replayed later.
+type Recorder struct {
+	mux    sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewRecorder creates a Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// CreateRecorder creates a Recorder that writes to a new capture file at path.
+func CreateRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{w: file, closer: file}, nil
+}
+
+// Record appends a message to the capture. Messages that cannot be decoded are
+// kept as raw bytes.
+func (r *Recorder) Record(msg CapturedMessage) error {
+	record := &block_chain.CaptureRecord{
+		Timestamp: msg.Time.UnixNano(),
+		Peer:      msg.Peer,
+		Outbound:  msg.Outbound,
+		Message:   msg.Message,
+	}
+	if record.Message == nil {
+		var message block_chain.MainMessage
+		if err := proto.Unmarshal(msg.Data, &message); err == nil {
+			record.Message = &message
+		} else {
+			record.Raw = msg.Data
+		}
+	}
+
+	data, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return writeFrame(r.w, data)
+}
+
+// Close closes the capture file if the Recorder created it.
+func (r *Recorder) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// CaptureReader reads the messages written by a Recorder.
+type CaptureReader struct {
+	r *bufio.Reader
+}
+
+// NewCaptureReader creates a CaptureReader.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next message of the capture, or io.EOF when the capture is exhausted.
+func (cr *CaptureReader) Next() (*CapturedMessage, error) {
+	size, err := binary.ReadUvarint(cr.r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read record length: %w", err)
+	}
+	if size > maxCaptureRecordSize {
+		return nil, fmt.Errorf("capture record of %d bytes exceeds the limit", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return nil, fmt.Errorf("failed to read capture record: %w", err)
+	}
+
+	var record block_chain.CaptureRecord
+	if err := proto.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode capture record: %w", err)
+	}
+
+	msg := &CapturedMessage{
+		Time:     time.Unix(0, record.GetTimestamp()),
+		Peer:     record.GetPeer(),
+		Outbound: record.GetOutbound(),
+		Message:  record.GetMessage(),
+		Data:     record.GetRaw(),
+	}
+	if msg.Message != nil {
+		if msg.Data, err = proto.Marshal(msg.Message); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Replayer feeds a capture into a fresh node. The node must be created with the
+// replayer's network, and its blockchain with the replayer's clock, so that
+// messages are handled at the times they were recorded and nothing leaves the
+// process.
+type Replayer struct {
+	clock   *ManualClock
+	network *replayNetwork
+}
+
+// NewReplayer creates a Replayer.
+func NewReplayer() *Replayer {
+	return &Replayer{
+		clock:   NewManualClock(time.Unix(0, 0)),
+		network: &replayNetwork{},
+	}
+}
+
+// Clock returns the clock replayed messages are handled at.
+func (r *Replayer) Clock() *ManualClock {
+	return r.clock
+}
+
+// Network returns the network that collects the messages the node sends.
+func (r *Replayer) Network() interfaces.Network {
+	return r.network
+}
+
+// Replay delivers the inbound messages of capture to node in order, setting the
+// clock to the time each one was recorded, and returns how many were delivered.
+// Recorded outbound messages are skipped; the node produces its own, see Sent.
+func (r *Replayer) Replay(node *Node, capture io.Reader) (int, error) {
+	cr := NewCaptureReader(capture)
+	delivered := 0
+	for {
+		msg, err := cr.Next()
+		if err == io.EOF {
+			return delivered, nil
+		}
+		if err != nil {
+			return delivered, err
+		}
+		if msg.Outbound {
+			continue
+		}
+
+		r.clock.Set(msg.Time)
+		node.HandleMessage(msg.Data, &replaySender{network: r.network, peer: msg.Peer})
+		delivered++
+	}
+}
+
+// Sent returns the messages the node sent while the capture was replayed.
+func (r *Replayer) Sent() []CapturedMessage {
+	return r.network.sentMessages()
+}
+
+// replayNetwork is a Network that delivers nothing and keeps what is sent over it.
+type replayNetwork struct {
+	mux  sync.Mutex
+	sent []CapturedMessage
+}
+
+func (n *replayNetwork) Listen(address string, handler interfaces.MessageHandler) error {
+	return nil
+}
+
+func (n *replayNetwork) Dial(from, to string) (interfaces.MessageSender, error) {
+	return &replaySender{network: n, peer: to}, nil
+}
+
+func (n *replayNetwork) Close() error {
+	return nil
+}
+
+// sentMessages returns a copy of the messages sent so far.
+func (n *replayNetwork) sentMessages() []CapturedMessage {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	sent := make([]CapturedMessage, len(n.sent))
+	copy(sent, n.sent)
+	return sent
+}
+
+// replaySender keeps the messages sent to a peer during a replay.
+type replaySender struct {
+	network *replayNetwork
+	peer    string
+}
+
+func (s *replaySender) SendMsg(data []byte) error {
+	var message block_chain.MainMessage
+	captured := CapturedMessage{Peer: s.peer, Outbound: true, Data: data}
+	if err := proto.Unmarshal(data, &message); err == nil {
+		captured.Message = &message
+	}
+
+	s.network.mux.Lock()
+	defer s.network.mux.Unlock()
+
+	s.network.sent = append(s.network.sent, captured)
+	return nil
+}
+
+// PeerAddress returns the address of the recorded peer.
+func (s *replaySender) PeerAddress() string {
+	return s.peer
+}
@@ -0,0 +1,94 @@
+package src
+
+import (
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// TxLocation identifies a transaction inside a block of the canonical chain.
+type TxLocation struct {
+	BlockHash []byte
+	Height    uint64
+	Position  int
+}
+
+// chainIndex holds the secondary indexes of the canonical chain.
+// It is updated only through connect and disconnect, so it always
+// reflects the path from the root to the current head.
+type chainIndex struct {
+	byHeight  [][]byte
+	byTx      map[string][]TxLocation
+	byAddress map[string][]TxLocation
+}
+
+// newChainIndex creates an empty chainIndex.
+func newChainIndex() *chainIndex {
+	return &chainIndex{
+		byHeight:  make([][]byte, 0),
+		byTx:      make(map[string][]TxLocation),
+		byAddress: make(map[string][]TxLocation),
+	}
+}
+
+// connect adds a block that became part of the canonical chain.
+func (ci *chainIndex) connect(node *types.BlockNode) {
+	block, blockHash := node.Block, node.Hash
+	ci.byHeight = append(ci.byHeight[:block.Index], blockHash)
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		location := TxLocation{BlockHash: blockHash, Height: block.Index, Position: i}
+
+		txKey := string(tx.CalculateHash())
+		ci.byTx[txKey] = append(ci.byTx[txKey], location)
+
+		ci.byAddress[string(tx.Sender)] = append(ci.byAddress[string(tx.Sender)], location)
+		if string(tx.Receiver) != string(tx.Sender) {
+			ci.byAddress[string(tx.Receiver)] = append(ci.byAddress[string(tx.Receiver)], location)
+		}
+	}
+}
+
+// disconnect removes a block that is no longer part of the canonical chain.
+// Blocks must be disconnected from the tip downwards.
+func (ci *chainIndex) disconnect(node *types.BlockNode) {
+	block := node.Block
+	if uint64(len(ci.byHeight)) > block.Index {
+		ci.byHeight = ci.byHeight[:block.Index]
+	}
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+
+		txKey := string(tx.CalculateHash())
+		ci.byTx[txKey] = removeLocations(ci.byTx[txKey], block.Index)
+		if len(ci.byTx[txKey]) == 0 {
+			delete(ci.byTx, txKey)
+		}
+
+		for _, address := range []string{string(tx.Sender), string(tx.Receiver)} {
+			ci.byAddress[address] = removeLocations(ci.byAddress[address], block.Index)
+			if len(ci.byAddress[address]) == 0 {
+				delete(ci.byAddress, address)
+			}
+		}
+	}
+}
+
+// hashAt returns the canonical block hash at the given height, or nil.
+func (ci *chainIndex) hashAt(height uint64) []byte {
+	if height >= uint64(len(ci.byHeight)) {
+		return nil
+	}
+	return ci.byHeight[height]
+}
+
+// removeLocations drops every location at the given height.
+func removeLocations(locations []TxLocation, height uint64) []TxLocation {
+	kept := locations[:0]
+	for _, location := range locations {
+		if location.Height != height {
+			kept = append(kept, location)
+		}
+	}
+	return kept
+}
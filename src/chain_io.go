@@ -0,0 +1,229 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxChainRecordSize limits the size of a single block record in a chain file.
+const maxChainRecordSize = 32 << 20
+
+// gzipMagic is the header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ErrBlockPruned is returned when exporting a block whose transactions have been
+// pruned. Blockchains created with WithArchive keep them.
+var ErrBlockPruned = errors.New("transactions of the block have been pruned")
+
+// ChainWriter writes blocks as a stream of length-prefixed protobuf records.
+type ChainWriter struct {
+	w  *bufio.Writer
+	gz *gzip.Writer
+}
+
+// NewChainWriter creates a ChainWriter, optionally gzip-compressing the stream.
+func NewChainWriter(w io.Writer, compress bool) *ChainWriter {
+	cw := &ChainWriter{}
+	if compress {
+		cw.gz = gzip.NewWriter(w)
+		w = cw.gz
+	}
+	cw.w = bufio.NewWriter(w)
+	return cw
+}
+
+// WriteBlock appends a block record to the stream.
+func (cw *ChainWriter) WriteBlock(block *types.Block) error {
+	data, err := proto.Marshal(block.ToProto())
+	if err != nil {
+		return err
+	}
+	var prefix [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(prefix[:], uint64(len(data)))
+	if _, err := cw.w.Write(prefix[:n]); err != nil {
+		return err
+	}
+	_, err = cw.w.Write(data)
+	return err
+}
+
+// Close flushes the stream. It does not close the underlying writer.
+func (cw *ChainWriter) Close() error {
+	if err := cw.w.Flush(); err != nil {
+		return err
+	}
+	if cw.gz != nil {
+		return cw.gz.Close()
+	}
+	return nil
+}
+
+// ChainReader reads blocks written by a ChainWriter.
+type ChainReader struct {
+	r *bufio.Reader
+}
+
+// NewChainReader creates a ChainReader. Gzip-compressed streams are detected automatically.
+func NewChainReader(r io.Reader) (*ChainReader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err == nil && bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(gz)
+	}
+	return &ChainReader{r: br}, nil
+}
+
+// Next returns the next block of the stream, or io.EOF when the stream is exhausted.
+func (cr *ChainReader) Next() (*types.Block, error) {
+	size, err := binary.ReadUvarint(cr.r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read record length: %w", err)
+	}
+	if size > maxChainRecordSize {
+		return nil, fmt.Errorf("block record of %d bytes exceeds the limit", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return nil, fmt.Errorf("failed to read block record: %w", err)
+	}
+
+	var pbBlock block_chain.Block
+	if err := proto.Unmarshal(data, &pbBlock); err != nil {
+		return nil, fmt.Errorf("failed to decode block record: %w", err)
+	}
+	return types.BlockFromProto(&pbBlock), nil
+}
+
+// ImportProgress reports the state of a running chain import.
+type ImportProgress struct {
+	Read     int
+	Imported int
+	Skipped  int
+	Height   uint64
+}
+
+// ExportChain writes the canonical blocks with heights in [from, to] to w.
+// It returns the number of exported blocks.
+func ExportChain(bc *Blockchain, w io.Writer, from, to uint64, compress bool) (int, error) {
+	latest := bc.GetLatestBlock().Index
+	if to > latest {
+		to = latest
+	}
+
+	cw := NewChainWriter(w, compress)
+	exported := 0
+	for height := from; height <= to; height++ {
+		node := bc.GetBlockByHeight(height)
+		if node == nil {
+			return exported, fmt.Errorf("missing canonical block at height %d", height)
+		}
+		if node.Pruned {
+			return exported, fmt.Errorf("block %d: %w", height, ErrBlockPruned)
+		}
+		if err := cw.WriteBlock(node.Block); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	return exported, cw.Close()
+}
+
+// ImportChain reads blocks from r and adds them to bc, re-validating every block.
+// Blocks that are already known are skipped. The progress callback, if not nil,
+// is called after every block.
+func ImportChain(bc *Blockchain, r io.Reader, progress func(ImportProgress)) (ImportProgress, error) {
+	cr, err := NewChainReader(r)
+	if err != nil {
+		return ImportProgress{}, err
+	}
+	return importRecords(bc, cr, ImportProgress{}, progress)
+}
+
+// importRecords imports the remaining records of cr into bc, continuing from state.
+func importRecords(bc *Blockchain, cr *ChainReader, state ImportProgress, progress func(ImportProgress)) (ImportProgress, error) {
+	for {
+		block, err := cr.Next()
+		if err == io.EOF {
+			return state, nil
+		}
+		if err != nil {
+			return state, err
+		}
+		state.Read++
+		state.Height = block.Index
+
+		if err := importBlock(bc, block); err != nil {
+			if !errors.Is(err, ErrBlockExists) {
+				return state, fmt.Errorf("block %d: %w", block.Index, err)
+			}
+			state.Skipped++
+		} else {
+			state.Imported++
+		}
+
+		if progress != nil {
+			progress(state)
+		}
+	}
+}
+
+// LoadChain creates a new blockchain with the given options from a chain stream
+// that starts with a genesis block. The options must match the chain the stream
+// was exported from, such as its ledger model and consensus engine.
+func LoadChain(r io.Reader, progress func(ImportProgress), opts ...BlockchainOption) (*Blockchain, ImportProgress, error) {
+	cr, err := NewChainReader(r)
+	if err != nil {
+		return nil, ImportProgress{}, err
+	}
+
+	genesis, err := cr.Next()
+	if err == io.EOF {
+		return nil, ImportProgress{}, errors.New("chain stream is empty")
+	}
+	if err != nil {
+		return nil, ImportProgress{}, err
+	}
+	if genesis.Index != 0 {
+		return nil, ImportProgress{}, fmt.Errorf("chain stream starts at height %d instead of the genesis block", genesis.Index)
+	}
+
+	bc := NewBlockchainFromGenesis(genesis, opts...)
+	state, err := importRecords(bc, cr, ImportProgress{Read: 1, Skipped: 1}, progress)
+	return bc, state, err
+}
+
+// importBlock validates block against its parent and adds it to bc.
+func importBlock(bc *Blockchain, block *types.Block) error {
+	if bc.BlockExists(block.CalculateHash()) {
+		return ErrBlockExists
+	}
+	if block.Index == 0 {
+		return ErrGenesisMismatch
+	}
+
+	parent := bc.GetBlock(block.PreviousHash)
+	if parent == nil {
+		return ErrUnknownParent
+	}
+	if err := bc.ValidateBlock(block, parent.Block); err != nil {
+		return err
+	}
+	return bc.AddBlock(parent, block)
+}
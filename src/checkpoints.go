@@ -0,0 +1,237 @@
+package src
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// DefaultCheckpointInterval is the distance between automatic checkpoints.
+const DefaultCheckpointInterval = 10
+
+// Checkpoint pins the canonical block at a given height.
+type Checkpoint struct {
+	Height uint64
+	Hash   []byte
+}
+
+// checkpointRecord is the on-disk representation of a Checkpoint.
+type checkpointRecord struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// checkpointSet keeps the hard-coded, persisted and automatic checkpoints of a blockchain.
+type checkpointSet struct {
+	byHeight map[uint64][]byte
+	interval uint64
+	path     string
+}
+
+// newCheckpointSet creates a checkpointSet with automatic checkpoints every interval blocks.
+func newCheckpointSet(interval uint64) *checkpointSet {
+	return &checkpointSet{
+		byHeight: make(map[uint64][]byte),
+		interval: interval,
+	}
+}
+
+// add records a checkpoint. It fails if a different hash is already pinned at that height.
+func (cs *checkpointSet) add(checkpoint Checkpoint) error {
+	if existing, ok := cs.byHeight[checkpoint.Height]; ok {
+		if !bytes.Equal(existing, checkpoint.Hash) {
+			return fmt.Errorf("conflicting checkpoints at height %d", checkpoint.Height)
+		}
+		return nil
+	}
+	cs.byHeight[checkpoint.Height] = checkpoint.Hash
+	return nil
+}
+
+// hashAt returns the checkpointed hash at height, or nil.
+func (cs *checkpointSet) hashAt(height uint64) []byte {
+	return cs.byHeight[height]
+}
+
+// latest returns the highest checkpoint at or below maxHeight.
+func (cs *checkpointSet) latest(maxHeight uint64) *Checkpoint {
+	var latest *Checkpoint
+	for height, hash := range cs.byHeight {
+		if height <= maxHeight && (latest == nil || height > latest.Height) {
+			latest = &Checkpoint{Height: height, Hash: hash}
+		}
+	}
+	return latest
+}
+
+// isAutomatic reports whether a block at height becomes a checkpoint once it is canonical.
+func (cs *checkpointSet) isAutomatic(height uint64) bool {
+	return cs.interval > 0 && height > 0 && height%cs.interval == 0
+}
+
+// list returns all checkpoints ordered by height.
+func (cs *checkpointSet) list() []Checkpoint {
+	checkpoints := make([]Checkpoint, 0, len(cs.byHeight))
+	for height, hash := range cs.byHeight {
+		checkpoints = append(checkpoints, Checkpoint{Height: height, Hash: hash})
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Height < checkpoints[j].Height
+	})
+	return checkpoints
+}
+
+// load reads the checkpoints persisted at cs.path. A missing file is not an error.
+func (cs *checkpointSet) load() error {
+	data, err := os.ReadFile(cs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []checkpointRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse checkpoint file %s: %w", cs.path, err)
+	}
+	for _, record := range records {
+		hash, err := hex.DecodeString(record.Hash)
+		if err != nil {
+			return fmt.Errorf("invalid checkpoint hash at height %d: %w", record.Height, err)
+		}
+		if err := cs.add(Checkpoint{Height: record.Height, Hash: hash}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// save writes all checkpoints to cs.path, replacing the file atomically.
+func (cs *checkpointSet) save() error {
+	checkpoints := cs.list()
+	records := make([]checkpointRecord, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		records[i] = checkpointRecord{Height: checkpoint.Height, Hash: hex.EncodeToString(checkpoint.Hash)}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := cs.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, cs.path)
+}
+
+// WithCheckpoints adds hard-coded checkpoints to the blockchain.
+func WithCheckpoints(checkpoints ...Checkpoint) BlockchainOption {
+	return func(bc *Blockchain) {
+		for _, checkpoint := range checkpoints {
+			if err := bc.checkpoints.add(checkpoint); err != nil {
+				bc.logger.Warn("Ignoring checkpoint", errorAttr(err))
+			}
+		}
+	}
+}
+
+// WithCheckpointInterval sets the distance between automatic checkpoints. Zero disables them.
+func WithCheckpointInterval(interval uint64) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.checkpoints.interval = interval
+	}
+}
+
+// WithCheckpointFile persists checkpoints in the given file, so that they survive restarts.
+// Checkpoints already stored in the file are loaded and enforced like hard-coded ones.
+func WithCheckpointFile(path string) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.checkpoints.path = path
+		if err := bc.checkpoints.load(); err != nil {
+			bc.logger.Warn("Failed to load checkpoints", errorAttr(err))
+		}
+	}
+}
+
+// GetCheckpoints returns all known checkpoints ordered by height.
+func (bc *Blockchain) GetCheckpoints() []Checkpoint {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	return bc.checkpoints.list()
+}
+
+// CheckCheckpoint verifies that a block does not conflict with a checkpoint: a block
+// at a checkpointed height must have the pinned hash, and an unknown block must not
+// fork the canonical chain at or below the latest checkpoint.
+func (bc *Blockchain) CheckCheckpoint(block *types.Block) error {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	blockHash := block.CalculateHash()
+	if hash := bc.checkpoints.hashAt(block.Index); hash != nil && !bytes.Equal(hash, blockHash) {
+		return fmt.Errorf("%w at height %d", ErrCheckpointConflict, block.Index)
+	}
+	if bc.nodes[string(blockHash)] != nil {
+		return nil
+	}
+	if latest := bc.canonicalCheckpoint(); latest != nil && block.Index <= latest.Height {
+		return fmt.Errorf("%w at height %d", ErrBelowCheckpoint, latest.Height)
+	}
+	return nil
+}
+
+// canonicalCheckpoint returns the latest checkpoint at or below the head if it
+// is on the canonical chain. A checkpoint the canonical chain conflicts with
+// only pins its own height, so that the branch through it can still be added.
+// The caller must hold bc.mux.
+func (bc *Blockchain) canonicalCheckpoint() *Checkpoint {
+	latest := bc.checkpoints.latest(bc.head.Block.Index)
+	if latest == nil || !bytes.Equal(bc.index.hashAt(latest.Height), latest.Hash) {
+		return nil
+	}
+	return latest
+}
+
+// saveCheckpoints persists the checkpoints if a checkpoint file is configured.
+// The caller must hold bc.mux.
+func (bc *Blockchain) saveCheckpoints() {
+	if bc.checkpoints.path == "" {
+		return
+	}
+	if err := bc.checkpoints.save(); err != nil {
+		bc.logger.Error("Failed to save checkpoints", errorAttr(err))
+	}
+}
+
+// checkCheckpoints verifies that a block descending from ancestor respects all checkpoints.
+func (bc *Blockchain) checkCheckpoints(ancestor *types.BlockNode, block *types.Block) error {
+	if hash := bc.checkpoints.hashAt(block.Index); hash != nil && !bytes.Equal(hash, block.CalculateHash()) {
+		return fmt.Errorf("%w at height %d", ErrCheckpointConflict, block.Index)
+	}
+
+	latest := bc.canonicalCheckpoint()
+	if latest == nil {
+		return nil
+	}
+	if ancestor.Block.Index < latest.Height {
+		return fmt.Errorf("%w at height %d", ErrBelowCheckpoint, latest.Height)
+	}
+
+	node := ancestor
+	for node.Block.Index > latest.Height {
+		node = node.Parent
+	}
+	if !bytes.Equal(node.Hash, latest.Hash) {
+		return fmt.Errorf("%w at height %d", ErrBelowCheckpoint, latest.Height)
+	}
+	return nil
+}
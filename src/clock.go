@@ -0,0 +1,151 @@
+package src
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+)
+
+// MaxClockAdjustment limits how far the network-adjusted time may move away from
+// the local clock. If peers disagree with the local clock by more, the local
+// clock is probably wrong and the adjustment is dropped.
+const MaxClockAdjustment = 70 * time.Minute
+
+// Bounds on the peer samples a NetworkClock keeps.
+const (
+	minClockSamples = 3
+	maxClockSamples = 200
+)
+
+// SystemClock tells the time of the operating system.
+type SystemClock struct{}
+
+// Now returns the current local time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a clock that only moves when told to. It is meant for tests.
+type ManualClock struct {
+	mux sync.Mutex
+	now time.Time
+}
+
+// NewManualClock creates a ManualClock set to now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the time the clock is set to.
+func (c *ManualClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.now
+}
+
+// Set sets the clock to now.
+func (c *ManualClock) Set(now time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.now = now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// NetworkClock is a local clock corrected by the median offset of the times peers
+// report during the handshake, so that nodes with a skewed clock still agree with
+// the network on block timestamps.
+type NetworkClock struct {
+	base    interfaces.Clock
+	mux     sync.Mutex
+	offsets map[string]time.Duration
+	offset  time.Duration
+	logger  *slog.Logger
+}
+
+// NewNetworkClock creates a NetworkClock on top of base.
+func NewNetworkClock(base interfaces.Clock) *NetworkClock {
+	return &NetworkClock{
+		base:    base,
+		offsets: make(map[string]time.Duration),
+		logger:  slog.Default(),
+	}
+}
+
+// SetLogger makes the clock write its log records to logger.
+func (c *NetworkClock) SetLogger(logger *slog.Logger) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.logger = logger
+}
+
+// Now returns the network-adjusted time.
+func (c *NetworkClock) Now() time.Time {
+	return c.base.Now().Add(c.Offset())
+}
+
+// LocalNow returns the time of the underlying clock, which is what the node
+// reports to its peers.
+func (c *NetworkClock) LocalNow() time.Time {
+	return c.base.Now()
+}
+
+// localClock tells the local time of a NetworkClock, which its peers cannot move.
+type localClock struct {
+	clock *NetworkClock
+}
+
+// Now returns the time of the underlying clock of the NetworkClock.
+func (c localClock) Now() time.Time {
+	return c.clock.LocalNow()
+}
+
+// Offset returns the current adjustment of the local clock.
+func (c *NetworkClock) Offset() time.Duration {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.offset
+}
+
+// AddSample records the time reported by peer. Only the latest sample of every
+// peer counts, and the time is adjusted once enough peers have reported.
+func (c *NetworkClock) AddSample(peer string, peerTime time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if _, known := c.offsets[peer]; !known && len(c.offsets) >= maxClockSamples {
+		return
+	}
+	c.offsets[peer] = peerTime.Sub(c.base.Now())
+	if len(c.offsets) < minClockSamples {
+		return
+	}
+
+	offsets := make([]time.Duration, 0, len(c.offsets))
+	for _, offset := range c.offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	median := offsets[len(offsets)/2]
+
+	if median > MaxClockAdjustment || median < -MaxClockAdjustment {
+		if c.offset != 0 {
+			c.logger.Warn("Peers disagree with the local clock, check the system time", slog.Duration("offset", median))
+		}
+		c.offset = 0
+		return
+	}
+	c.offset = median
+}
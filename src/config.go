@@ -0,0 +1,725 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvPrefix prefixes the environment variables that override the configuration.
+const ConfigEnvPrefix = "GOB_"
+
+// Config holds the settings of a node and its blockchain. Values are taken from
+// the defaults, then a YAML file, then environment variables, then flags.
+type Config struct {
+	// Network names the network profile the node joins; see LookupNetwork.
+	Network string        `yaml:"network"`
+	Node    NodeConfig    `yaml:"node"`
+	Chain   ChainConfig   `yaml:"chain"`
+	Log     LogConfig     `yaml:"log"`
+	Metrics MetricsConfig `yaml:"metrics"`
+}
+
+// NodeConfig holds the settings of a node.
+type NodeConfig struct {
+	// Address is where the node listens. Empty selects the network's default address.
+	Address        string        `yaml:"address"`
+	Peers          []string      `yaml:"peers"`
+	MiningInterval time.Duration `yaml:"mining_interval"`
+	// Seal selects how blocks are produced. Modes without proof of work are
+	// only allowed on the dev network.
+	Seal SealMode `yaml:"seal"`
+	// RPCAddress is where the dev RPC is served. Empty disables it.
+	RPCAddress string `yaml:"rpc_address"`
+	// Signer is the address of the key a proof-of-authority or proof-of-stake
+	// node signs blocks with, and a finality validator signs votes with. The
+	// key is loaded from Keystore with the passphrase in PassphraseFile.
+	Signer         string `yaml:"signer"`
+	Keystore       string `yaml:"keystore"`
+	PassphraseFile string `yaml:"passphrase_file"`
+	// CoinbaseKey is the hex-encoded public key the coinbases of mined UTXO
+	// blocks pay the block subsidy to. Empty mines coinbases that pay nothing.
+	CoinbaseKey string `yaml:"coinbase_key"`
+	// PruneInterval is the time between two prunings of the block tree, which
+	// drop the side branches below the latest checkpoint. Zero disables pruning.
+	PruneInterval time.Duration `yaml:"prune_interval"`
+	// PruneForkDepth also drops side branches that forked off more than that
+	// many blocks below the head. Zero keeps them.
+	PruneForkDepth uint64 `yaml:"prune_fork_depth"`
+	// PruneTransactions also drops the transactions of canonical blocks below
+	// the latest checkpoint.
+	PruneTransactions bool `yaml:"prune_transactions"`
+	// TLS encrypts the connections of the node and authenticates its peers by
+	// the node ID of their key. Peers can then be given as <node ID>@<address>,
+	// so that they have to present that node ID.
+	TLS bool `yaml:"tls"`
+	// IdentityFile holds the key the node authenticates itself with over TLS.
+	// It is created if it does not exist.
+	IdentityFile string `yaml:"identity_file"`
+	// AllowedPeers are the node IDs of the only peers a TLS node accepts and
+	// connects to. Empty allows every peer.
+	AllowedPeers []string `yaml:"allowed_peers"`
+}
+
+// ChainConfig holds the settings of a blockchain. Zero values keep the settings
+// of the network profile.
+type ChainConfig struct {
+	// ChainID names a private network; other networks have a fixed chain ID.
+	ChainID            string `yaml:"chain_id"`
+	Difficulty         int    `yaml:"difficulty"`
+	CheckpointInterval uint64 `yaml:"checkpoint_interval"`
+	CheckpointFile     string `yaml:"checkpoint_file"`
+	// Consensus selects the consensus engine of a private network.
+	Consensus ConsensusType `yaml:"consensus"`
+	// Signers are the hex-encoded public keys of the genesis proof-of-authority signers.
+	Signers []string `yaml:"signers"`
+	// Validators are the genesis proof-of-stake validators, each a hex-encoded
+	// public key, its stake in base units and optionally its unstaked funds in
+	// base units, separated by colons.
+	Validators []string `yaml:"validators"`
+	// BlockPeriod is the minimum time between two proof-of-authority blocks, or
+	// the length of a proof-of-stake proposal round.
+	BlockPeriod time.Duration `yaml:"block_period"`
+	// Ledger selects the ledger model of a dev or private network: account
+	// transfers or UTXO transactions.
+	Ledger LedgerModel `yaml:"ledger"`
+	// FinalityValidators are the hex-encoded public keys of the validators that
+	// finalize checkpoint blocks. Empty leaves checkpoints to each node.
+	FinalityValidators []string `yaml:"finality_validators"`
+	// CertificateFile persists the finality certificates, so that final
+	// checkpoints survive restarts.
+	CertificateFile string `yaml:"certificate_file"`
+}
+
+// ConsensusType names a consensus engine.
+type ConsensusType string
+
+// Supported consensus engines.
+const (
+	ConsensusProofOfWork      ConsensusType = "pow"
+	ConsensusProofOfAuthority ConsensusType = "poa"
+	ConsensusProofOfStake     ConsensusType = "pos"
+)
+
+// LogConfig holds the logging settings.
+type LogConfig struct {
+	Level  string    `yaml:"level"`
+	Format LogFormat `yaml:"format"`
+}
+
+// MetricsConfig holds the settings of the metrics endpoint.
+type MetricsConfig struct {
+	// Address is where /metrics is served. Empty disables the endpoint.
+	Address string `yaml:"address"`
+}
+
+// DefaultConfig returns the default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Network: DefaultNetwork,
+		Node: NodeConfig{
+			MiningInterval: DefaultMiningInterval,
+			Seal:           SealProofOfWork,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+	}
+}
+
+// LoadConfig returns the default configuration overridden by the YAML file at
+// path, if path is not empty, and by the environment. It does not validate the
+// result, so that flags can still override it.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, err
+		}
+		if err := config.decodeYAML(data); err != nil {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	if err := config.ApplyEnv(os.LookupEnv); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// decodeYAML overrides the configuration with the settings in data. Unknown
+// settings are refused, so that typos do not go unnoticed.
+func (c *Config) decodeYAML(data []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// configSetting is a setting that can be overridden by an environment variable and a flag.
+type configSetting struct {
+	flag  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+// env returns the environment variable of the setting.
+func (s configSetting) env() string {
+	return ConfigEnvPrefix + strings.ToUpper(strings.ReplaceAll(s.flag, "-", "_"))
+}
+
+// configSettings are the settings that can be overridden by environment variables and flags.
+var configSettings = []configSetting{
+	{"network", "network to join: dev, testnet or private", func(c *Config, v string) error {
+		c.Network = v
+		return nil
+	}},
+	{"address", "address the node listens on", func(c *Config, v string) error {
+		c.Node.Address = v
+		return nil
+	}},
+	{"peers", "comma-separated addresses of nodes to connect to", func(c *Config, v string) error {
+		c.Node.Peers = nil
+		for _, peer := range strings.Split(v, ",") {
+			if peer = strings.TrimSpace(peer); peer != "" {
+				c.Node.Peers = append(c.Node.Peers, peer)
+			}
+		}
+		return nil
+	}},
+	{"mining-interval", "pause between mining two blocks", func(c *Config, v string) (err error) {
+		c.Node.MiningInterval, err = time.ParseDuration(v)
+		return err
+	}},
+	{"seal", "how blocks are produced: pow, or on dev networks instant, interval or manual", func(c *Config, v string) error {
+		c.Node.Seal = SealMode(v)
+		return nil
+	}},
+	{"rpc-address", "address to serve the dev RPC on, empty disables it", func(c *Config, v string) error {
+		c.Node.RPCAddress = v
+		return nil
+	}},
+	{"coinbase-key", "hex public key the coinbases of mined UTXO blocks pay to", func(c *Config, v string) error {
+		c.Node.CoinbaseKey = v
+		return nil
+	}},
+	{"prune-interval", "time between two prunings of the block tree, 0 disables pruning", func(c *Config, v string) (err error) {
+		c.Node.PruneInterval, err = time.ParseDuration(v)
+		return err
+	}},
+	{"prune-fork-depth", "depth below the head beyond which pruning drops side branches, 0 keeps them", func(c *Config, v string) (err error) {
+		c.Node.PruneForkDepth, err = strconv.ParseUint(v, 10, 64)
+		return err
+	}},
+	{"prune-transactions", "whether pruning drops the transactions of blocks below the latest checkpoint", func(c *Config, v string) (err error) {
+		c.Node.PruneTransactions, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"tls", "whether to encrypt connections and authenticate peers by node ID", func(c *Config, v string) (err error) {
+		c.Node.TLS, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"identity-file", "file holding the key the node authenticates itself with over TLS", func(c *Config, v string) error {
+		c.Node.IdentityFile = v
+		return nil
+	}},
+	{"allowed-peers", "comma-separated node IDs of the only peers a TLS node accepts, empty allows every peer", func(c *Config, v string) error {
+		c.Node.AllowedPeers = nil
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				c.Node.AllowedPeers = append(c.Node.AllowedPeers, id)
+			}
+		}
+		return nil
+	}},
+	{"chain-id", "chain ID of a private network", func(c *Config, v string) error {
+		c.Chain.ChainID = v
+		return nil
+	}},
+	{"difficulty", `number of "0" characters a block hash starts with, 0 keeps the network's`, func(c *Config, v string) (err error) {
+		c.Chain.Difficulty, err = strconv.Atoi(v)
+		return err
+	}},
+	{"checkpoint-interval", "distance between automatic checkpoints, 0 keeps the network's", func(c *Config, v string) (err error) {
+		c.Chain.CheckpointInterval, err = strconv.ParseUint(v, 10, 64)
+		return err
+	}},
+	{"checkpoint-file", "file to persist checkpoints in", func(c *Config, v string) error {
+		c.Chain.CheckpointFile = v
+		return nil
+	}},
+	{"consensus", "consensus engine of a private network: pow, poa or pos", func(c *Config, v string) error {
+		c.Chain.Consensus = ConsensusType(v)
+		return nil
+	}},
+	{"ledger", "ledger model of a dev or private network: account or utxo", func(c *Config, v string) error {
+		c.Chain.Ledger = LedgerModel(v)
+		return nil
+	}},
+	{"signers", "comma-separated hex public keys of the proof-of-authority signers", func(c *Config, v string) error {
+		c.Chain.Signers = nil
+		for _, signer := range strings.Split(v, ",") {
+			if signer = strings.TrimSpace(signer); signer != "" {
+				c.Chain.Signers = append(c.Chain.Signers, signer)
+			}
+		}
+		return nil
+	}},
+	{"validators", "comma-separated proof-of-stake validators as <hex public key>:<stake>[:<funds>]", func(c *Config, v string) error {
+		c.Chain.Validators = nil
+		for _, validator := range strings.Split(v, ",") {
+			if validator = strings.TrimSpace(validator); validator != "" {
+				c.Chain.Validators = append(c.Chain.Validators, validator)
+			}
+		}
+		return nil
+	}},
+	{"block-period", "minimum time between two proof-of-authority blocks or length of a proof-of-stake round", func(c *Config, v string) (err error) {
+		c.Chain.BlockPeriod, err = time.ParseDuration(v)
+		return err
+	}},
+	{"finality-validators", "comma-separated hex public keys of the validators that finalize checkpoints", func(c *Config, v string) error {
+		c.Chain.FinalityValidators = nil
+		for _, validator := range strings.Split(v, ",") {
+			if validator = strings.TrimSpace(validator); validator != "" {
+				c.Chain.FinalityValidators = append(c.Chain.FinalityValidators, validator)
+			}
+		}
+		return nil
+	}},
+	{"certificate-file", "file to persist finality certificates in", func(c *Config, v string) error {
+		c.Chain.CertificateFile = v
+		return nil
+	}},
+	{"signer", "address of the key to sign proof-of-authority or proof-of-stake blocks or finality votes with", func(c *Config, v string) error {
+		c.Node.Signer = v
+		return nil
+	}},
+	{"keystore", "keystore directory holding the signer key", func(c *Config, v string) error {
+		c.Node.Keystore = v
+		return nil
+	}},
+	{"passphrase-file", "file holding the passphrase of the signer key", func(c *Config, v string) error {
+		c.Node.PassphraseFile = v
+		return nil
+	}},
+	{"log-level", "minimum level of log records: debug, info, warn or error", func(c *Config, v string) error {
+		c.Log.Level = v
+		return nil
+	}},
+	{"log-format", "format of log records: text or json", func(c *Config, v string) error {
+		c.Log.Format = LogFormat(v)
+		return nil
+	}},
+	{"metrics-address", "address to serve /metrics on, empty disables it", func(c *Config, v string) error {
+		c.Metrics.Address = v
+		return nil
+	}},
+}
+
+// ApplyEnv overrides the configuration with the environment variables found by
+// lookup, such as GOB_ADDRESS or GOB_MINING_INTERVAL.
+func (c *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	for _, setting := range configSettings {
+		if value, ok := lookup(setting.env()); ok {
+			if err := setting.set(c, value); err != nil {
+				return fmt.Errorf("invalid %s: %w", setting.env(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// ConfigFlags are command line flags that override a configuration.
+type ConfigFlags struct {
+	fs     *flag.FlagSet
+	values map[string]*string
+}
+
+// BindConfigFlags registers a flag for every setting on fs.
+func BindConfigFlags(fs *flag.FlagSet) *ConfigFlags {
+	cf := &ConfigFlags{fs: fs, values: make(map[string]*string)}
+	for _, setting := range configSettings {
+		cf.values[setting.flag] = fs.String(setting.flag, "", fmt.Sprintf("%s (env %s)", setting.usage, setting.env()))
+	}
+	return cf
+}
+
+// Apply overrides the configuration with the flags that were set on the command line.
+func (cf *ConfigFlags) Apply(c *Config) error {
+	set := make(map[string]bool)
+	cf.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, setting := range configSettings {
+		if set[setting.flag] {
+			if err := setting.set(c, *cf.values[setting.flag]); err != nil {
+				return fmt.Errorf("invalid -%s: %w", setting.flag, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that every setting has a usable value.
+func (c *Config) Validate() error {
+	var errs []error
+	if profile, err := c.Profile(); err != nil {
+		errs = append(errs, err)
+	} else if err := profile.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("network %s: %w", profile.Name, err))
+	} else if err := profile.CheckSealMode(c.Node.Seal); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Node.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Node.Address); err != nil {
+			errs = append(errs, fmt.Errorf("node address: %w", err))
+		}
+	}
+	for _, peer := range c.Node.Peers {
+		id, address := splitPeerID(peer)
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			errs = append(errs, fmt.Errorf("peer: %w", err))
+		}
+		if id == "" {
+			continue
+		}
+		if err := ValidateNodeID(id); err != nil {
+			errs = append(errs, fmt.Errorf("peer: %w", err))
+		} else if !c.Node.TLS {
+			errs = append(errs, fmt.Errorf("peer %s: a node ID needs tls", peer))
+		}
+	}
+	errs = append(errs, c.validateTLS()...)
+	if c.Node.MiningInterval < 0 {
+		errs = append(errs, errors.New("mining interval must not be negative"))
+	}
+	if c.Node.PruneInterval < 0 {
+		errs = append(errs, errors.New("prune interval must not be negative"))
+	}
+	if err := c.Node.Seal.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Node.RPCAddress != "" {
+		if _, _, err := net.SplitHostPort(c.Node.RPCAddress); err != nil {
+			errs = append(errs, fmt.Errorf("rpc address: %w", err))
+		} else if !c.Node.Seal.instantSeal() {
+			errs = append(errs, errors.New("the dev RPC needs a seal mode without proof of work"))
+		}
+	}
+	if err := c.Chain.Ledger.Validate(); err != nil {
+		errs = append(errs, err)
+	} else if c.Chain.Ledger == LedgerUTXO && c.Network != NetworkDev && c.Network != NetworkPrivate {
+		errs = append(errs, fmt.Errorf("the UTXO ledger can only be used on the %s and %s networks", NetworkDev, NetworkPrivate))
+	}
+	if c.Node.CoinbaseKey != "" {
+		if c.Chain.Ledger != LedgerUTXO {
+			errs = append(errs, errors.New("a coinbase key needs the UTXO ledger"))
+		}
+		if _, err := c.coinbaseKey(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, c.validateConsensus()...)
+	errs = append(errs, c.validateFinality()...)
+	if c.Node.Signer != "" {
+		if err := wallet.ValidateAddress(c.Node.Signer); err != nil {
+			errs = append(errs, fmt.Errorf("signer: %w", err))
+		}
+	}
+	if _, err := c.logLevel(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Log.Format != LogFormatText && c.Log.Format != LogFormatJSON {
+		errs = append(errs, fmt.Errorf("unknown log format %q", c.Log.Format))
+	}
+	if c.Metrics.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Address); err != nil {
+			errs = append(errs, fmt.Errorf("metrics address: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTLS checks the TLS settings.
+func (c *Config) validateTLS() []error {
+	if !c.Node.TLS {
+		if c.Node.IdentityFile != "" || len(c.Node.AllowedPeers) > 0 {
+			return []error{errors.New("an identity file and allowed peers need tls")}
+		}
+		return nil
+	}
+	var errs []error
+	if c.Node.IdentityFile == "" {
+		errs = append(errs, errors.New("tls needs an identity file"))
+	}
+	for _, id := range c.Node.AllowedPeers {
+		if err := ValidateNodeID(id); err != nil {
+			errs = append(errs, fmt.Errorf("allowed peer: %w", err))
+		}
+	}
+	return errs
+}
+
+// validateConsensus checks the consensus settings.
+func (c *Config) validateConsensus() []error {
+	var errs []error
+	switch c.Chain.Consensus {
+	case "", ConsensusProofOfWork:
+		if len(c.Chain.Signers) > 0 || len(c.Chain.Validators) > 0 {
+			errs = append(errs, errors.New("signers need proof-of-authority or proof-of-stake consensus"))
+		}
+		if c.Node.Signer != "" && len(c.Chain.FinalityValidators) == 0 {
+			errs = append(errs, errors.New("a signer needs proof-of-authority or proof-of-stake consensus, or finality validators"))
+		}
+		return errs
+	case ConsensusProofOfAuthority:
+		if len(c.Chain.Signers) == 0 {
+			errs = append(errs, errors.New("proof-of-authority consensus needs signers"))
+		}
+		if len(c.Chain.Validators) > 0 {
+			errs = append(errs, errors.New("validators need proof-of-stake consensus"))
+		}
+		if _, err := c.signerKeys(); err != nil {
+			errs = append(errs, err)
+		}
+	case ConsensusProofOfStake:
+		if len(c.Chain.Validators) == 0 {
+			errs = append(errs, errors.New("proof-of-stake consensus needs validators"))
+		}
+		if len(c.Chain.Signers) > 0 {
+			errs = append(errs, errors.New("signers need proof-of-authority consensus"))
+		}
+		if _, err := c.validators(); err != nil {
+			errs = append(errs, err)
+		}
+	default:
+		return append(errs, fmt.Errorf("unknown consensus %q", c.Chain.Consensus))
+	}
+
+	if c.Network != NetworkPrivate {
+		errs = append(errs, fmt.Errorf("consensus can only be set for the %s network", NetworkPrivate))
+	}
+	if c.Chain.BlockPeriod < 0 {
+		errs = append(errs, errors.New("block period must not be negative"))
+	}
+	return errs
+}
+
+// validateFinality checks the finality settings.
+func (c *Config) validateFinality() []error {
+	if len(c.Chain.FinalityValidators) == 0 {
+		if c.Chain.CertificateFile != "" {
+			return []error{errors.New("a certificate file needs finality validators")}
+		}
+		return nil
+	}
+	var errs []error
+	if c.Network != NetworkPrivate {
+		errs = append(errs, fmt.Errorf("finality validators can only be set for the %s network", NetworkPrivate))
+	}
+	if _, err := c.finalityValidators(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// signerKeys decodes the configured proof-of-authority signers.
+func (c *Config) signerKeys() ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(c.Chain.Signers))
+	for _, signer := range c.Chain.Signers {
+		key, err := hex.DecodeString(signer)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("signer %q is not a hex-encoded %d-byte public key", signer, ed25519.PublicKeySize)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// coinbaseKey decodes the configured coinbase key.
+func (c *Config) coinbaseKey() (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(c.Node.CoinbaseKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("coinbase key %q is not a hex-encoded %d-byte public key", c.Node.CoinbaseKey, ed25519.PublicKeySize)
+	}
+	return key, nil
+}
+
+// finalityValidators decodes the configured finality validators.
+func (c *Config) finalityValidators() ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(c.Chain.FinalityValidators))
+	for _, validator := range c.Chain.FinalityValidators {
+		key, err := hex.DecodeString(validator)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("finality validator %q is not a hex-encoded %d-byte public key", validator, ed25519.PublicKeySize)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// validators decodes the configured proof-of-stake validators.
+func (c *Config) validators() ([]Validator, error) {
+	validators := make([]Validator, 0, len(c.Chain.Validators))
+	for _, validator := range c.Chain.Validators {
+		fields := strings.Split(validator, ":")
+		key, err := hex.DecodeString(fields[0])
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("validator %q does not start with a hex-encoded %d-byte public key", validator, ed25519.PublicKeySize)
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("validator %q is not <public key>:<stake>[:<funds>]", validator)
+		}
+		amounts := make([]uint64, 2)
+		for i, field := range fields[1:] {
+			if amounts[i], err = strconv.ParseUint(field, 10, 64); err != nil {
+				return nil, fmt.Errorf("validator %q has an invalid amount %q", validator, field)
+			}
+		}
+		if amounts[0] == 0 && amounts[1] == 0 {
+			return nil, fmt.Errorf("validator %q has neither stake nor funds", validator)
+		}
+		validators = append(validators, Validator{PublicKey: key, Stake: amounts[0], Funds: amounts[1]})
+	}
+	return validators, nil
+}
+
+// logLevel parses the configured log level.
+func (c *Config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		return level, fmt.Errorf("unknown log level %q", c.Log.Level)
+	}
+	return level, nil
+}
+
+// Logger creates the configured logger writing to w.
+func (c *Config) Logger(w io.Writer) (*slog.Logger, error) {
+	level, err := c.logLevel()
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(w, level, c.Log.Format)
+}
+
+// Profile returns the profile of the configured network with the chain settings
+// of the configuration applied.
+func (c *Config) Profile() (NetworkProfile, error) {
+	profile, err := LookupNetwork(c.Network)
+	if err != nil {
+		return profile, err
+	}
+	if c.Chain.ChainID != "" {
+		if profile.Name != NetworkPrivate {
+			return profile, fmt.Errorf("chain ID can only be set for the %s network", NetworkPrivate)
+		}
+		profile.ChainID = c.Chain.ChainID
+	}
+	if c.Chain.Difficulty != 0 {
+		profile.Difficulty = c.Chain.Difficulty
+	}
+	if c.Chain.CheckpointInterval != 0 {
+		profile.CheckpointInterval = c.Chain.CheckpointInterval
+	}
+	return profile, nil
+}
+
+// BlockchainOptions returns the options that apply the configuration to a
+// blockchain, on top of the settings of its Profile.
+func (c *Config) BlockchainOptions(logger *slog.Logger) []BlockchainOption {
+	opts := []BlockchainOption{
+		WithLogger(logger),
+	}
+	if c.Chain.Ledger != "" {
+		opts = append(opts, WithLedgerModel(c.Chain.Ledger))
+	}
+	if c.Node.Seal.instantSeal() {
+		opts = append(opts, WithoutProofOfWork())
+	}
+	// Invalid signers and validators are reported by Validate.
+	switch c.Chain.Consensus {
+	case ConsensusProofOfAuthority:
+		signers, _ := c.signerKeys()
+		opts = append(opts, WithConsensusEngine(NewProofOfAuthority(signers, WithBlockPeriod(c.Chain.BlockPeriod))))
+	case ConsensusProofOfStake:
+		validators, _ := c.validators()
+		opts = append(opts, WithConsensusEngine(NewProofOfStake(validators, WithProposalPeriod(c.Chain.BlockPeriod))))
+	}
+	if c.Chain.CheckpointFile != "" {
+		opts = append(opts, WithCheckpointFile(c.Chain.CheckpointFile))
+	}
+	return opts
+}
+
+// FinalityGadget returns the finality gadget of the configured finality
+// validators for blockchain, or nil if none are configured.
+func (c *Config) FinalityGadget(blockchain *Blockchain) *FinalityGadget {
+	if len(c.Chain.FinalityValidators) == 0 {
+		return nil
+	}
+	// Invalid finality validators are reported by Validate.
+	validators, _ := c.finalityValidators()
+	var opts []FinalityOption
+	if c.Chain.CertificateFile != "" {
+		opts = append(opts, WithCertificateFile(c.Chain.CertificateFile))
+	}
+	return NewFinalityGadget(blockchain, validators, opts...)
+}
+
+// Transport returns the TLS transport of the node and the identity it
+// authenticates itself with, loading or creating the identity file. Both are nil
+// if TLS is disabled.
+func (c *Config) Transport() (interfaces.Transport, *Identity, error) {
+	if !c.Node.TLS {
+		return nil, nil, nil
+	}
+	identity, err := LoadOrCreateIdentity(c.Node.IdentityFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport, err := NewTLSTransport(NewTCPTransport(), identity, c.Node.AllowedPeers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return transport, identity, nil
+}
+
+// NodeOptions returns the options that apply the configuration to a node, on top
+// of the settings of its Profile.
+func (c *Config) NodeOptions(logger *slog.Logger) []NodeOption {
+	opts := []NodeOption{
+		WithNodeLogger(logger),
+		WithMiningInterval(c.Node.MiningInterval),
+		WithSealMode(c.Node.Seal),
+		WithPeers(c.Node.Peers...),
+	}
+	if c.Node.CoinbaseKey != "" {
+		// An invalid coinbase key is reported by Validate.
+		key, _ := c.coinbaseKey()
+		opts = append(opts, WithCoinbaseKey(key))
+	}
+	if c.Node.PruneInterval > 0 {
+		opts = append(opts, WithPruning(c.Node.PruneInterval, PruneOptions{
+			ForkDepth:        c.Node.PruneForkDepth,
+			BelowCheckpoint:  true,
+			DropTransactions: c.Node.PruneTransactions,
+		}))
+	}
+	return opts
+}
@@ -0,0 +1,474 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// DefaultOutOfTurnDelay is the unit of the random delay an out-of-turn signer
+// waits before sealing, giving the in-turn signer a head start.
+const DefaultOutOfTurnDelay = 500 * time.Millisecond
+
+// SnapshotDepth is the number of blocks below the highest verified block whose
+// snapshots the signature-based engines keep. Older snapshots are dropped and
+// recomputed from the blocks of their ancestors if needed again.
+const SnapshotDepth = 1024
+
+// The work a block of a signature-based engine declares in its Data field.
+const (
+	outOfTurnWork = 1
+	inTurnWork    = 2
+)
+
+// Errors returned by the proof-of-authority engine.
+var (
+	ErrInvalidBlockSignature = errors.New("block signature is invalid")
+	ErrUnauthorizedSigner    = errors.New("block signer is not authorized")
+	ErrRecentlySigned        = errors.New("signer has signed a recent block")
+	ErrBlockTooEarly         = errors.New("block is sealed before the block period has passed")
+	ErrInvalidVote           = errors.New("signer vote is invalid")
+	ErrMissingSnapshot       = errors.New("signer set of parent block is unknown")
+	ErrNoSignerKey           = errors.New("no signer key is authorized")
+	ErrWrongTurn             = errors.New("block work does not match the turn of its signer")
+)
+
+// ProofOfAuthority is the consensus engine in which a set of signers take turns
+// to sign blocks. The signer whose turn it is seals after the block period; the
+// others wait a random extra delay, and their blocks count for less work.
+// Signers vote with AddSignerVote and RemoveSignerVote transactions, and a
+// candidate is added or removed once more than half of the signers voted for it.
+//
+// Blocks declare in Data whether they were signed in turn, so that their work
+// is known without the signer set of their parent.
+type ProofOfAuthority struct {
+	signers        [][]byte
+	period         time.Duration
+	outOfTurnDelay time.Duration
+
+	mux       sync.Mutex
+	clock     interfaces.Clock
+	snapshots map[string]*signerSnapshot
+	// pinned is the hash of the checkpoint whose snapshot is kept regardless of
+	// its depth, since the snapshots below it are pruned.
+	pinned string
+	key    *wallet.Key
+}
+
+var (
+	_ interfaces.ClockedEngine  = (*ProofOfAuthority)(nil)
+	_ interfaces.PrunableEngine = (*ProofOfAuthority)(nil)
+)
+
+// ProofOfAuthorityOption configures a ProofOfAuthority engine.
+type ProofOfAuthorityOption func(*ProofOfAuthority)
+
+// WithBlockPeriod sets the minimum time between two blocks.
+func WithBlockPeriod(period time.Duration) ProofOfAuthorityOption {
+	return func(e *ProofOfAuthority) {
+		e.period = period
+	}
+}
+
+// WithOutOfTurnDelay sets the unit of the random delay of out-of-turn signers.
+func WithOutOfTurnDelay(delay time.Duration) ProofOfAuthorityOption {
+	return func(e *ProofOfAuthority) {
+		e.outOfTurnDelay = delay
+	}
+}
+
+// NewProofOfAuthority creates a proof-of-authority engine whose genesis signers
+// are the given public keys.
+func NewProofOfAuthority(signers []ed25519.PublicKey, opts ...ProofOfAuthorityOption) *ProofOfAuthority {
+	e := &ProofOfAuthority{
+		outOfTurnDelay: DefaultOutOfTurnDelay,
+		clock:          SystemClock{},
+		snapshots:      make(map[string]*signerSnapshot),
+	}
+	for _, signer := range signers {
+		e.signers = append(e.signers, []byte(signer))
+	}
+	sortKeys(e.signers)
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Authorize makes the engine seal blocks with key.
+func (e *ProofOfAuthority) Authorize(key *wallet.Key) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.key = key
+}
+
+// SetClock makes the engine wait for block timestamps by clock.
+func (e *ProofOfAuthority) SetClock(clock interfaces.Clock) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.clock = clock
+}
+
+// Signers returns the signers authorized to sign the child of parent.
+func (e *ProofOfAuthority) Signers(parent *types.BlockNode) ([]ed25519.PublicKey, error) {
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]ed25519.PublicKey, len(snap.signers))
+	for i, signer := range snap.signers {
+		signers[i] = ed25519.PublicKey(signer)
+	}
+	return signers, nil
+}
+
+// VerifyHeader checks that block is signed by a signer authorized after parent
+// who did not sign a recent block, that it declares whether the signer is in
+// turn, that it respects the block period and that its votes are cast by signers.
+func (e *ProofOfAuthority) VerifyHeader(parent *types.BlockNode, block *types.Block) error {
+	if !wallet.Verify(block.Signer, block.SealHash(), block.Signature) {
+		return ErrInvalidBlockSignature
+	}
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return err
+	}
+	if !snap.isSigner(block.Signer) {
+		return fmt.Errorf("%w: %s", ErrUnauthorizedSigner, hex.EncodeToString(block.Signer))
+	}
+	if snap.signedRecently(block.Signer, block.Index) {
+		return ErrRecentlySigned
+	}
+	if want := snap.work(block.Signer, block.Index); block.Data != want {
+		return fmt.Errorf("%w: declared %d instead of %d", ErrWrongTurn, block.Data, want)
+	}
+	if earliest := parent.Block.Timestamp + uint64(e.period/time.Second); parent.Block.Index > 0 && block.Timestamp < earliest {
+		return fmt.Errorf("%w: %d < %d", ErrBlockTooEarly, block.Timestamp, earliest)
+	}
+	next, err := snap.apply(block)
+	if err != nil {
+		return err
+	}
+	e.mux.Lock()
+	e.snapshots[string(block.CalculateHash())] = next
+	e.pruneSnapshots(block.Index)
+	e.mux.Unlock()
+	return nil
+}
+
+// Prepare makes the authorized key the signer of block, declares whether it is
+// in turn and delays the timestamp of block until the block period has passed.
+func (e *ProofOfAuthority) Prepare(parent *types.BlockNode, block *types.Block) error {
+	e.mux.Lock()
+	key := e.key
+	e.mux.Unlock()
+	if key == nil {
+		return ErrNoSignerKey
+	}
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return err
+	}
+	block.Signer = key.PublicKey()
+	block.Data = snap.work(block.Signer, block.Index)
+	if earliest := parent.Block.Timestamp + uint64(e.period/time.Second); block.Timestamp < earliest {
+		block.Timestamp = earliest
+	}
+	return nil
+}
+
+// Finalize does nothing; votes are applied when blocks are verified.
+func (e *ProofOfAuthority) Finalize(parent *types.BlockNode, block *types.Block) error {
+	return nil
+}
+
+// Seal waits until the block may be sealed and signs it with the authorized
+// key. Out-of-turn signers wait a random extra delay.
+func (e *ProofOfAuthority) Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	e.mux.Lock()
+	key := e.key
+	snap := e.snapshots[string(block.PreviousHash)]
+	e.mux.Unlock()
+	if key == nil {
+		return nil, ErrNoSignerKey
+	}
+	if snap == nil {
+		return nil, ErrMissingSnapshot
+	}
+	signer := []byte(key.PublicKey())
+	if !snap.isSigner(signer) {
+		return nil, ErrUnauthorizedSigner
+	}
+	if snap.signedRecently(signer, block.Index) {
+		return nil, ErrRecentlySigned
+	}
+
+	delay := time.Unix(int64(block.Timestamp), 0).Sub(e.now())
+	if !snap.inTurn(signer, block.Index) {
+		delay += time.Duration(rand.Intn(len(snap.signers)/2+1)+1) * e.outOfTurnDelay
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-stop:
+			return nil, ErrSealAborted
+		case <-timer.C:
+		}
+	}
+
+	sealed := *block
+	sealed.Signer = signer
+	sealed.Signature = key.Sign(sealed.SealHash())
+	return &sealed, nil
+}
+
+// CalculateWork returns 2 for blocks signed in turn and 1 for the others, so that
+// fork choice prefers chains signed in turn. It trusts the work a block
+// declares, which VerifyHeader checked.
+func (e *ProofOfAuthority) CalculateWork(block *types.Block) *big.Int {
+	return declaredWork(block)
+}
+
+// now returns the time of the engine's clock.
+func (e *ProofOfAuthority) now() time.Time {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return e.clock.Now()
+}
+
+// pruneSnapshots drops the snapshots of blocks more than SnapshotDepth below
+// height, except the pinned one. The caller must hold mux.
+func (e *ProofOfAuthority) pruneSnapshots(height uint64) {
+	for hash, snap := range e.snapshots {
+		if snap.height+SnapshotDepth < height && hash != e.pinned {
+			delete(e.snapshots, hash)
+		}
+	}
+}
+
+// PruneSnapshots drops the snapshots of blocks below checkpoint and pins the
+// snapshot of checkpoint, from which the snapshots of later blocks are replayed
+// once the transactions below it are pruned.
+func (e *ProofOfAuthority) PruneSnapshots(checkpoint *types.BlockNode) int {
+	if _, err := e.snapshot(checkpoint); err != nil {
+		return 0
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.pinned = string(checkpoint.Hash)
+	dropped := 0
+	for hash, snap := range e.snapshots {
+		if snap.height < checkpoint.Block.Index {
+			delete(e.snapshots, hash)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// snapshot returns the signer set after node, replaying the votes of the
+// ancestors whose snapshot is not cached yet.
+func (e *ProofOfAuthority) snapshot(node *types.BlockNode) (*signerSnapshot, error) {
+	var pending []*types.BlockNode
+	var snap *signerSnapshot
+	e.mux.Lock()
+	for snap == nil {
+		hash := node.Hash
+		if hash == nil {
+			hash = node.Block.CalculateHash()
+		}
+		if cached := e.snapshots[string(hash)]; cached != nil {
+			snap = cached
+			break
+		}
+		if node.Block.Index == 0 {
+			snap = &signerSnapshot{signers: e.signers, recents: make(map[uint64][]byte), tally: make(map[string]map[string]bool)}
+			e.snapshots[string(hash)] = snap
+			break
+		}
+		if node.Parent == nil || node.Pruned {
+			e.mux.Unlock()
+			return nil, ErrMissingSnapshot
+		}
+		pending = append(pending, node)
+		node = node.Parent
+	}
+	e.mux.Unlock()
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		next, err := snap.apply(pending[i].Block)
+		if err != nil {
+			return nil, err
+		}
+		snap = next
+		e.mux.Lock()
+		e.snapshots[string(pending[i].Block.CalculateHash())] = snap
+		e.mux.Unlock()
+	}
+	return snap, nil
+}
+
+// signerSnapshot is the state of the signer set after a block.
+type signerSnapshot struct {
+	// height is the height of the block.
+	height uint64
+	// signers are the public keys of the authorized signers, sorted.
+	signers [][]byte
+	// recents maps the heights of recent blocks to their signers.
+	recents map[uint64][]byte
+	// tally maps a vote, the type followed by the candidate, to the signers who cast it.
+	tally map[string]map[string]bool
+}
+
+// isSigner reports whether key is an authorized signer.
+func (s *signerSnapshot) isSigner(key []byte) bool {
+	for _, signer := range s.signers {
+		if bytes.Equal(signer, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// inTurn reports whether it is the turn of key to sign the block at height.
+func (s *signerSnapshot) inTurn(key []byte, height uint64) bool {
+	return len(s.signers) > 0 && bytes.Equal(s.signers[height%uint64(len(s.signers))], key)
+}
+
+// work returns the work of the block at height signed by key.
+func (s *signerSnapshot) work(key []byte, height uint64) uint64 {
+	if s.inTurn(key, height) {
+		return inTurnWork
+	}
+	return outOfTurnWork
+}
+
+// recentLimit is the number of consecutive blocks a signer may sign only one of.
+func (s *signerSnapshot) recentLimit() uint64 {
+	return uint64(len(s.signers)/2 + 1)
+}
+
+// signedRecently reports whether key signed one of the blocks that keep it from
+// signing the block at height.
+func (s *signerSnapshot) signedRecently(key []byte, height uint64) bool {
+	for recent, signer := range s.recents {
+		if recent+s.recentLimit() > height && bytes.Equal(signer, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the snapshot after block, whose signature has been verified.
+func (s *signerSnapshot) apply(block *types.Block) (*signerSnapshot, error) {
+	next := &signerSnapshot{
+		height:  block.Index,
+		signers: append([][]byte(nil), s.signers...),
+		recents: make(map[uint64][]byte, len(s.recents)+1),
+		tally:   make(map[string]map[string]bool, len(s.tally)),
+	}
+	for height, signer := range s.recents {
+		next.recents[height] = signer
+	}
+	for vote, voters := range s.tally {
+		next.tally[vote] = make(map[string]bool, len(voters))
+		for voter := range voters {
+			next.tally[vote][voter] = true
+		}
+	}
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if tx.Type != types.AddSignerVote && tx.Type != types.RemoveSignerVote {
+			continue
+		}
+		if err := next.vote(tx); err != nil {
+			return nil, &TransactionError{Position: i, Err: err}
+		}
+	}
+
+	next.recents[block.Index] = block.Signer
+	for height := range next.recents {
+		if height+next.recentLimit() <= block.Index {
+			delete(next.recents, height)
+		}
+	}
+	return next, nil
+}
+
+// vote counts a vote and adds or removes its candidate once more than half of
+// the signers cast it. The signature of the vote has been verified by the
+// SignatureRule.
+func (s *signerSnapshot) vote(tx *types.Transaction) error {
+	candidate := tx.Receiver
+	switch {
+	case len(tx.PublicKey) == 0:
+		return fmt.Errorf("%w: vote is not signed", ErrInvalidVote)
+	case !s.isSigner(tx.PublicKey):
+		return fmt.Errorf("%w: voter is not a signer", ErrInvalidVote)
+	case len(candidate) != ed25519.PublicKeySize:
+		return fmt.Errorf("%w: candidate must be a %d-byte public key", ErrInvalidVote, ed25519.PublicKeySize)
+	case tx.Type == types.AddSignerVote && s.isSigner(candidate):
+		return fmt.Errorf("%w: candidate is already a signer", ErrInvalidVote)
+	case tx.Type == types.RemoveSignerVote && !s.isSigner(candidate):
+		return fmt.Errorf("%w: candidate is not a signer", ErrInvalidVote)
+	}
+
+	key := string(rune(tx.Type)) + string(candidate)
+	if s.tally[key] == nil {
+		s.tally[key] = make(map[string]bool)
+	}
+	s.tally[key][string(tx.PublicKey)] = true
+	if len(s.tally[key]) <= len(s.signers)/2 {
+		return nil
+	}
+
+	if tx.Type == types.AddSignerVote {
+		s.signers = append(s.signers, candidate)
+		sortKeys(s.signers)
+	} else {
+		for i, signer := range s.signers {
+			if bytes.Equal(signer, candidate) {
+				s.signers = append(s.signers[:i:i], s.signers[i+1:]...)
+				break
+			}
+		}
+		// Votes cast by the removed signer no longer count.
+		for _, voters := range s.tally {
+			delete(voters, string(candidate))
+		}
+	}
+	delete(s.tally, string(rune(types.AddSignerVote))+string(candidate))
+	delete(s.tally, string(rune(types.RemoveSignerVote))+string(candidate))
+	return nil
+}
+
+// declaredWork returns the work block declares in its Data field, counting
+// anything but in-turn work as out of turn.
+func declaredWork(block *types.Block) *big.Int {
+	if block.Data == inTurnWork {
+		return big.NewInt(inTurnWork)
+	}
+	return big.NewInt(outOfTurnWork)
+}
+
+// sortKeys sorts public keys in ascending byte order, which is the signing order.
+func sortKeys(keys [][]byte) {
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+}
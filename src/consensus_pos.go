@@ -0,0 +1,711 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// DefaultMaxEvidenceAge is the number of blocks after a double signing during
+// which evidence of it can still slash the offender.
+const DefaultMaxEvidenceAge = 1000
+
+// maxProposerRounds bounds the rounds Prepare searches for one in which the
+// authorized validator is the proposer.
+const maxProposerRounds = 10000
+
+// Errors returned by the proof-of-stake engine.
+var (
+	ErrWrongProposer   = errors.New("block is not signed by the selected proposer")
+	ErrNoValidators    = errors.New("no validator has enough stake")
+	ErrNotProposer     = errors.New("authorized validator is not a proposer of the next block")
+	ErrInvalidStake    = errors.New("stake transaction is invalid")
+	ErrInvalidEvidence = errors.New("double-sign evidence is invalid")
+	ErrEvidenceKnown   = errors.New("double-sign evidence is already known")
+)
+
+// Validator is a public key with the stake it put up to propose blocks.
+type Validator struct {
+	PublicKey ed25519.PublicKey
+	Stake     uint64
+	// Funds are the genesis funds of the validator that are not staked.
+	Funds uint64
+}
+
+// ProofOfStake is the consensus engine in which validators propose blocks with
+// a probability proportional to their stake. Time after a block is divided in
+// rounds of the proposal period; the proposer of every height and round is drawn
+// deterministically, weighted by stake, and only it may sign a block with a
+// timestamp in its round. Blocks proposed in the first round count for more work.
+//
+// Validators change their stake with StakeTransaction and UnstakeTransaction
+// transactions, which move funds between their stake and the funds they were
+// given at genesis, so staking never creates funds. Each one carries the next
+// nonce of its validator, so that it cannot be included twice. A validator who signs two
+// blocks with the same parent can be slashed by including DoubleSignEvidence in
+// a later block: its stake is burnt and it may not stake again.
+//
+// Blocks declare in Data whether they were proposed in the first round, so that
+// their work is known without the snapshot of their parent.
+type ProofOfStake struct {
+	genesis        map[string]Validator
+	period         time.Duration
+	minStake       uint64
+	maxEvidenceAge uint64
+
+	mux       sync.Mutex
+	clock     interfaces.Clock
+	snapshots map[string]*stakeSnapshot
+	// pinned is the hash of the checkpoint whose snapshot is kept regardless of
+	// its depth, since the snapshots below it are pruned.
+	pinned string
+	key    *wallet.Key
+	// proposals maps a parent hash and a signer to the first valid block seen
+	// with them, to detect double signing.
+	proposals map[string]*types.Block
+	// evidence holds the evidence waiting to be included in a block, by hash.
+	evidence  map[string]types.DoubleSignEvidence
+	listeners []func(types.DoubleSignEvidence)
+}
+
+var (
+	_ interfaces.ClockedEngine  = (*ProofOfStake)(nil)
+	_ interfaces.EvidencePool   = (*ProofOfStake)(nil)
+	_ interfaces.PrunableEngine = (*ProofOfStake)(nil)
+)
+
+// ProofOfStakeOption configures a ProofOfStake engine.
+type ProofOfStakeOption func(*ProofOfStake)
+
+// WithProposalPeriod sets the length of a proposal round, which is also the
+// minimum time between two blocks. Rounds last at least a second.
+func WithProposalPeriod(period time.Duration) ProofOfStakeOption {
+	return func(e *ProofOfStake) {
+		e.period = period
+	}
+}
+
+// WithMinimumStake sets the stake a validator needs to propose blocks.
+func WithMinimumStake(stake uint64) ProofOfStakeOption {
+	return func(e *ProofOfStake) {
+		e.minStake = stake
+	}
+}
+
+// WithMaxEvidenceAge sets the number of blocks during which evidence can be included.
+func WithMaxEvidenceAge(blocks uint64) ProofOfStakeOption {
+	return func(e *ProofOfStake) {
+		e.maxEvidenceAge = blocks
+	}
+}
+
+// NewProofOfStake creates a proof-of-stake engine with the given genesis validators.
+func NewProofOfStake(validators []Validator, opts ...ProofOfStakeOption) *ProofOfStake {
+	e := &ProofOfStake{
+		genesis:        make(map[string]Validator),
+		maxEvidenceAge: DefaultMaxEvidenceAge,
+		clock:          SystemClock{},
+		snapshots:      make(map[string]*stakeSnapshot),
+		proposals:      make(map[string]*types.Block),
+		evidence:       make(map[string]types.DoubleSignEvidence),
+	}
+	for _, validator := range validators {
+		genesis := e.genesis[string(validator.PublicKey)]
+		genesis.Stake += validator.Stake
+		genesis.Funds += validator.Funds
+		e.genesis[string(validator.PublicKey)] = genesis
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Authorize makes the engine propose blocks with key.
+func (e *ProofOfStake) Authorize(key *wallet.Key) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.key = key
+}
+
+// SetClock makes the engine wait for block timestamps by clock.
+func (e *ProofOfStake) SetClock(clock interfaces.Clock) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.clock = clock
+}
+
+// Validators returns the validators, their stake and their unstaked funds after
+// parent, sorted by public key. Validators below the minimum stake are included.
+func (e *ProofOfStake) Validators(parent *types.BlockNode) ([]Validator, error) {
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]Validator, 0, len(snap.stakes))
+	for _, key := range snap.sortedKeys() {
+		validators = append(validators, Validator{PublicKey: ed25519.PublicKey(key), Stake: snap.stakes[key], Funds: snap.funds[key]})
+	}
+	return validators, nil
+}
+
+// NextStakeNonce returns the nonce the next stake or unstake transaction of the
+// validator with the given public key must carry in a child of parent.
+func (e *ProofOfStake) NextStakeNonce(parent *types.BlockNode, publicKey ed25519.PublicKey) (uint64, error) {
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return 0, err
+	}
+	return snap.nonces[string(publicKey)] + 1, nil
+}
+
+// Proposer returns the validator that may propose the child of parent in round.
+func (e *ProofOfStake) Proposer(parent *types.BlockNode, round uint64) (ed25519.PublicKey, error) {
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return nil, err
+	}
+	proposer := snap.proposer(parent.Block.Index+1, round, e.minStake)
+	if proposer == nil {
+		return nil, ErrNoValidators
+	}
+	return proposer, nil
+}
+
+// ReportEvidence verifies evidence of double signing and adds it to the pool.
+// It returns ErrEvidenceKnown if the pool already holds it.
+func (e *ProofOfStake) ReportEvidence(evidence types.DoubleSignEvidence) error {
+	if err := verifyEvidence(evidence); err != nil {
+		return err
+	}
+	if !e.addEvidence(evidence) {
+		return ErrEvidenceKnown
+	}
+	return nil
+}
+
+// OnEvidence registers fn to be called with every evidence new to the pool.
+func (e *ProofOfStake) OnEvidence(fn func(types.DoubleSignEvidence)) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.listeners = append(e.listeners, fn)
+}
+
+// PendingEvidence returns the evidence waiting to be included in a block.
+func (e *ProofOfStake) PendingEvidence() []types.DoubleSignEvidence {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return e.pendingEvidence()
+}
+
+// VerifyHeader checks that block is signed by the proposer of its round and
+// declares whether the round is the first, and applies its evidence and stake
+// transactions. A valid block whose signer
+// already signed another block with the same parent is evidence of double
+// signing, which is added to the pool.
+func (e *ProofOfStake) VerifyHeader(parent *types.BlockNode, block *types.Block) error {
+	if !wallet.Verify(block.Signer, block.SealHash(), block.Signature) {
+		return ErrInvalidBlockSignature
+	}
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return err
+	}
+	round, err := e.round(parent.Block.Timestamp, block.Timestamp)
+	if err != nil {
+		return err
+	}
+	proposer := snap.proposer(block.Index, round, e.minStake)
+	if proposer == nil {
+		return ErrNoValidators
+	}
+	if !bytes.Equal(proposer, block.Signer) {
+		return fmt.Errorf("%w: %s in round %d", ErrWrongProposer, hex.EncodeToString(block.Signer), round)
+	}
+	if want := roundWork(round); block.Data != want {
+		return fmt.Errorf("%w: declared %d instead of %d", ErrWrongTurn, block.Data, want)
+	}
+	next, err := snap.apply(block, e.maxEvidenceAge)
+	if err != nil {
+		return err
+	}
+	e.mux.Lock()
+	e.snapshots[string(block.CalculateHash())] = next
+	e.pruneSnapshots(block.Index)
+	e.mux.Unlock()
+	e.recordProposal(block)
+	return nil
+}
+
+// Prepare makes the authorized key the signer of block and moves its timestamp
+// to the start of the first round, from the current one on, in which the key is
+// the proposer. The block declares whether that round is the first.
+func (e *ProofOfStake) Prepare(parent *types.BlockNode, block *types.Block) error {
+	e.mux.Lock()
+	key := e.key
+	e.mux.Unlock()
+	if key == nil {
+		return ErrNoSignerKey
+	}
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return err
+	}
+	if snap.proposer(block.Index, 0, e.minStake) == nil {
+		return ErrNoValidators
+	}
+
+	period := e.periodSeconds()
+	round := uint64(0)
+	if earliest := parent.Block.Timestamp + period; block.Timestamp > earliest {
+		round = (block.Timestamp-parent.Block.Timestamp)/period - 1
+	}
+	signer := []byte(key.PublicKey())
+	for end := round + maxProposerRounds; round < end; round++ {
+		if bytes.Equal(snap.proposer(block.Index, round, e.minStake), signer) {
+			block.Data = roundWork(round)
+			block.Signer = signer
+			block.Timestamp = parent.Block.Timestamp + period*(round+1)
+			return nil
+		}
+	}
+	return ErrNotProposer
+}
+
+// Finalize includes the pending evidence that can still slash its offender
+// after parent, and drops the evidence that no longer can.
+func (e *ProofOfStake) Finalize(parent *types.BlockNode, block *types.Block) error {
+	snap, err := e.snapshot(parent)
+	if err != nil {
+		return err
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	block.Evidence = nil
+	included := make(map[string]bool)
+	for _, evidence := range e.pendingEvidence() {
+		offender := string(evidence.Offender())
+		if snap.slashed[offender] || evidence.First.Index+e.maxEvidenceAge < block.Index {
+			delete(e.evidence, string(evidence.Hash()))
+			continue
+		}
+		if snap.stakes[offender] == 0 || included[offender] || evidence.First.Index >= block.Index {
+			continue
+		}
+		included[offender] = true
+		block.Evidence = append(block.Evidence, evidence)
+	}
+	return nil
+}
+
+// Seal waits until the round of block starts and signs it with the authorized key.
+func (e *ProofOfStake) Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	e.mux.Lock()
+	key := e.key
+	snap := e.snapshots[string(block.PreviousHash)]
+	e.mux.Unlock()
+	if key == nil {
+		return nil, ErrNoSignerKey
+	}
+	if snap == nil {
+		return nil, ErrMissingSnapshot
+	}
+	round, err := e.round(snap.timestamp, block.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	signer := []byte(key.PublicKey())
+	if !bytes.Equal(snap.proposer(block.Index, round, e.minStake), signer) {
+		return nil, ErrNotProposer
+	}
+
+	if delay := time.Unix(int64(block.Timestamp), 0).Sub(e.now()); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-stop:
+			return nil, ErrSealAborted
+		case <-timer.C:
+		}
+	}
+
+	sealed := *block
+	sealed.Signer = signer
+	sealed.Signature = key.Sign(sealed.SealHash())
+	return &sealed, nil
+}
+
+// CalculateWork returns 2 for blocks proposed in the first round and 1 for the
+// others, so that fork choice prefers chains whose proposers were online. It
+// trusts the work a block declares, which VerifyHeader checked.
+func (e *ProofOfStake) CalculateWork(block *types.Block) *big.Int {
+	return declaredWork(block)
+}
+
+// now returns the time of the engine's clock.
+func (e *ProofOfStake) now() time.Time {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return e.clock.Now()
+}
+
+// pruneSnapshots drops the snapshots of blocks more than SnapshotDepth below
+// height, except the pinned one. The caller must hold mux.
+func (e *ProofOfStake) pruneSnapshots(height uint64) {
+	for hash, snap := range e.snapshots {
+		if snap.height+SnapshotDepth < height && hash != e.pinned {
+			delete(e.snapshots, hash)
+		}
+	}
+}
+
+// PruneSnapshots drops the snapshots of blocks below checkpoint and pins the
+// snapshot of checkpoint, from which the snapshots of later blocks are replayed
+// once the transactions below it are pruned.
+func (e *ProofOfStake) PruneSnapshots(checkpoint *types.BlockNode) int {
+	if _, err := e.snapshot(checkpoint); err != nil {
+		return 0
+	}
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.pinned = string(checkpoint.Hash)
+	dropped := 0
+	for hash, snap := range e.snapshots {
+		if snap.height < checkpoint.Block.Index {
+			delete(e.snapshots, hash)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// roundWork returns the work of a block proposed in round.
+func roundWork(round uint64) uint64 {
+	if round == 0 {
+		return inTurnWork
+	}
+	return outOfTurnWork
+}
+
+// periodSeconds returns the length of a round in seconds.
+func (e *ProofOfStake) periodSeconds() uint64 {
+	if period := uint64(e.period / time.Second); period > 0 {
+		return period
+	}
+	return 1
+}
+
+// round returns the round in which a block with timestamp follows a parent
+// with parentTimestamp.
+func (e *ProofOfStake) round(parentTimestamp, timestamp uint64) (uint64, error) {
+	period := e.periodSeconds()
+	if earliest := parentTimestamp + period; timestamp < earliest {
+		return 0, fmt.Errorf("%w: %d < %d", ErrBlockTooEarly, timestamp, earliest)
+	}
+	return (timestamp-parentTimestamp)/period - 1, nil
+}
+
+// recordProposal remembers the signer of block, a valid block, and adds
+// evidence to the pool if it signed another block with the same parent.
+// Proposals older than the maximum evidence age are forgotten.
+func (e *ProofOfStake) recordProposal(block *types.Block) {
+	key := string(block.PreviousHash) + string(block.Signer)
+	e.mux.Lock()
+	first := e.proposals[key]
+	if first == nil {
+		e.proposals[key] = block
+	}
+	for k, proposal := range e.proposals {
+		if proposal.Index+e.maxEvidenceAge < block.Index {
+			delete(e.proposals, k)
+		}
+	}
+	e.mux.Unlock()
+
+	if first != nil && !bytes.Equal(first.CalculateHash(), block.CalculateHash()) {
+		e.addEvidence(types.DoubleSignEvidence{First: first, Second: block})
+	}
+}
+
+// addEvidence adds verified evidence to the pool and notifies the listeners.
+// It reports whether the evidence was new.
+func (e *ProofOfStake) addEvidence(evidence types.DoubleSignEvidence) bool {
+	hash := string(evidence.Hash())
+	e.mux.Lock()
+	if _, ok := e.evidence[hash]; ok {
+		e.mux.Unlock()
+		return false
+	}
+	e.evidence[hash] = evidence
+	listeners := append([]func(types.DoubleSignEvidence){}, e.listeners...)
+	e.mux.Unlock()
+
+	for _, listener := range listeners {
+		listener(evidence)
+	}
+	return true
+}
+
+// pendingEvidence returns the evidence in the pool, sorted by hash. The caller
+// must hold mux.
+func (e *ProofOfStake) pendingEvidence() []types.DoubleSignEvidence {
+	hashes := make([]string, 0, len(e.evidence))
+	for hash := range e.evidence {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	evidence := make([]types.DoubleSignEvidence, len(hashes))
+	for i, hash := range hashes {
+		evidence[i] = e.evidence[hash]
+	}
+	return evidence
+}
+
+// snapshot returns the stakes after node, replaying the blocks of the ancestors
+// whose snapshot is not cached yet.
+func (e *ProofOfStake) snapshot(node *types.BlockNode) (*stakeSnapshot, error) {
+	var pending []*types.BlockNode
+	var snap *stakeSnapshot
+	e.mux.Lock()
+	for snap == nil {
+		hash := node.Hash
+		if hash == nil {
+			hash = node.Block.CalculateHash()
+		}
+		if cached := e.snapshots[string(hash)]; cached != nil {
+			snap = cached
+			break
+		}
+		if node.Block.Index == 0 {
+			snap = &stakeSnapshot{stakes: make(map[string]uint64), funds: make(map[string]uint64), slashed: make(map[string]bool), nonces: make(map[string]uint64), timestamp: node.Block.Timestamp}
+			for key, validator := range e.genesis {
+				if validator.Stake > 0 {
+					snap.stakes[key] = validator.Stake
+				}
+				if validator.Funds > 0 {
+					snap.funds[key] = validator.Funds
+				}
+			}
+			e.snapshots[string(hash)] = snap
+			break
+		}
+		if node.Parent == nil || node.Pruned {
+			e.mux.Unlock()
+			return nil, ErrMissingSnapshot
+		}
+		pending = append(pending, node)
+		node = node.Parent
+	}
+	e.mux.Unlock()
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		next, err := snap.apply(pending[i].Block, e.maxEvidenceAge)
+		if err != nil {
+			return nil, err
+		}
+		snap = next
+		e.mux.Lock()
+		e.snapshots[string(pending[i].Block.CalculateHash())] = snap
+		e.mux.Unlock()
+	}
+	return snap, nil
+}
+
+// verifyEvidence checks that evidence holds two different blocks with the same
+// parent, both signed by the offender.
+func verifyEvidence(evidence types.DoubleSignEvidence) error {
+	first, second := evidence.First, evidence.Second
+	switch {
+	case first == nil || second == nil:
+		return fmt.Errorf("%w: evidence needs two blocks", ErrInvalidEvidence)
+	case first.Index != second.Index || !bytes.Equal(first.PreviousHash, second.PreviousHash):
+		return fmt.Errorf("%w: blocks have different parents", ErrInvalidEvidence)
+	case len(first.Signer) != ed25519.PublicKeySize || !bytes.Equal(first.Signer, second.Signer):
+		return fmt.Errorf("%w: blocks have different signers", ErrInvalidEvidence)
+	case bytes.Equal(first.CalculateHash(), second.CalculateHash()):
+		return fmt.Errorf("%w: blocks are the same", ErrInvalidEvidence)
+	case !wallet.Verify(first.Signer, first.SealHash(), first.Signature),
+		!wallet.Verify(second.Signer, second.SealHash(), second.Signature):
+		return fmt.Errorf("%w: %v", ErrInvalidEvidence, ErrInvalidBlockSignature)
+	}
+	return nil
+}
+
+// stakeSnapshot is the state of the validator set after a block.
+type stakeSnapshot struct {
+	// height is the height of the block.
+	height uint64
+	// stakes maps the public keys of validators to their stake.
+	stakes map[string]uint64
+	// funds maps the public keys of validators to their genesis funds that are
+	// not staked.
+	funds map[string]uint64
+	// slashed holds the public keys of the validators slashed for double
+	// signing, who may not stake again.
+	slashed map[string]bool
+	// nonces maps the public keys of validators to the nonce of their last
+	// stake or unstake transaction.
+	nonces map[string]uint64
+	// timestamp is the timestamp of the block.
+	timestamp uint64
+}
+
+// sortedKeys returns the public keys of the validators in ascending byte order.
+func (s *stakeSnapshot) sortedKeys() []string {
+	keys := make([]string, 0, len(s.stakes))
+	for key := range s.stakes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// proposer draws the proposer of the block at height in round among the
+// validators with at least minStake, weighted by stake. It returns nil if no
+// validator has enough stake.
+func (s *stakeSnapshot) proposer(height, round, minStake uint64) []byte {
+	var keys []string
+	total := new(big.Int)
+	for _, key := range s.sortedKeys() {
+		if stake := s.stakes[key]; stake > 0 && stake >= minStake {
+			keys = append(keys, key)
+			total.Add(total, new(big.Int).SetUint64(stake))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var seed [16]byte
+	binary.BigEndian.PutUint64(seed[:8], height)
+	binary.BigEndian.PutUint64(seed[8:], round)
+	hash := sha256.Sum256(seed[:])
+	target := new(big.Int).Mod(new(big.Int).SetBytes(hash[:]), total)
+	for _, key := range keys {
+		stake := new(big.Int).SetUint64(s.stakes[key])
+		if target.Cmp(stake) < 0 {
+			return []byte(key)
+		}
+		target.Sub(target, stake)
+	}
+	return nil
+}
+
+// apply returns the snapshot after block, whose signature has been verified.
+// The offenders of its evidence are slashed before its transactions change the
+// stakes.
+func (s *stakeSnapshot) apply(block *types.Block, maxEvidenceAge uint64) (*stakeSnapshot, error) {
+	next := &stakeSnapshot{
+		height:    block.Index,
+		stakes:    make(map[string]uint64, len(s.stakes)),
+		funds:     make(map[string]uint64, len(s.funds)),
+		slashed:   make(map[string]bool, len(s.slashed)),
+		nonces:    make(map[string]uint64, len(s.nonces)),
+		timestamp: block.Timestamp,
+	}
+	for key, stake := range s.stakes {
+		next.stakes[key] = stake
+	}
+	for key, funds := range s.funds {
+		next.funds[key] = funds
+	}
+	for key := range s.slashed {
+		next.slashed[key] = true
+	}
+	for key, nonce := range s.nonces {
+		next.nonces[key] = nonce
+	}
+
+	for _, evidence := range block.Evidence {
+		if err := next.slash(evidence, block.Index, maxEvidenceAge); err != nil {
+			return nil, err
+		}
+	}
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if tx.Type != types.StakeTransaction && tx.Type != types.UnstakeTransaction {
+			continue
+		}
+		if err := next.stake(tx); err != nil {
+			return nil, &TransactionError{Position: i, Err: err}
+		}
+	}
+	return next, nil
+}
+
+// slash burns the stake of the offender of evidence included in the block at
+// height.
+func (s *stakeSnapshot) slash(evidence types.DoubleSignEvidence, height, maxEvidenceAge uint64) error {
+	if err := verifyEvidence(evidence); err != nil {
+		return err
+	}
+	offender := string(evidence.Offender())
+	switch {
+	case evidence.First.Index >= height:
+		return fmt.Errorf("%w: evidence is not older than the block", ErrInvalidEvidence)
+	case evidence.First.Index+maxEvidenceAge < height:
+		return fmt.Errorf("%w: evidence is older than %d blocks", ErrInvalidEvidence, maxEvidenceAge)
+	case s.slashed[offender]:
+		return fmt.Errorf("%w: offender is already slashed", ErrInvalidEvidence)
+	case s.stakes[offender] == 0:
+		return fmt.Errorf("%w: offender has no stake", ErrInvalidEvidence)
+	}
+	delete(s.stakes, offender)
+	s.slashed[offender] = true
+	return nil
+}
+
+// stake applies a stake or unstake transaction of its signer, moving the amount
+// from its funds to its stake or back. The transaction must carry the nonce
+// after the last one of its signer. The signature of the transaction has been
+// verified by the SignatureRule.
+func (s *stakeSnapshot) stake(tx *types.Transaction) error {
+	validator := string(tx.PublicKey)
+	switch {
+	case len(tx.PublicKey) == 0:
+		return fmt.Errorf("%w: transaction is not signed", ErrInvalidStake)
+	case tx.Amount == 0:
+		return fmt.Errorf("%w: amount is zero", ErrInvalidStake)
+	case s.slashed[validator]:
+		return fmt.Errorf("%w: validator was slashed", ErrInvalidStake)
+	case tx.Nonce != s.nonces[validator]+1:
+		return fmt.Errorf("%w: nonce %d, expected %d", ErrInvalidStake, tx.Nonce, s.nonces[validator]+1)
+	}
+
+	from, to := s.funds, s.stakes
+	if tx.Type == types.UnstakeTransaction {
+		from, to = s.stakes, s.funds
+	}
+	if tx.Amount > from[validator] {
+		if tx.Type == types.StakeTransaction {
+			return fmt.Errorf("%w: amount exceeds the funds of %d", ErrInvalidStake, from[validator])
+		}
+		return fmt.Errorf("%w: amount exceeds the stake of %d", ErrInvalidStake, from[validator])
+	}
+	from[validator] -= tx.Amount
+	if from[validator] == 0 {
+		delete(from, validator)
+	}
+	to[validator] += tx.Amount
+	s.nonces[validator] = tx.Nonce
+	return nil
+}
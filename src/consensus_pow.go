@@ -0,0 +1,81 @@
+package src
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// ErrSealAborted is returned by a consensus engine that stopped sealing a block.
+var ErrSealAborted = errors.New("sealing was aborted")
+
+// ProofOfWork is the consensus engine that requires the hash of a block to start
+// with a number of "0" characters. The nonce is kept in the block's Data field.
+type ProofOfWork struct {
+	prefix []byte
+}
+
+var _ interfaces.ConsensusEngine = (*ProofOfWork)(nil)
+
+// NewProofOfWork creates a proof-of-work engine whose block hashes start with
+// difficulty "0" characters.
+func NewProofOfWork(difficulty int) *ProofOfWork {
+	return &ProofOfWork{prefix: bytes.Repeat([]byte("0"), difficulty)}
+}
+
+// WithConsensusEngine makes the blockchain produce and validate blocks with engine.
+func WithConsensusEngine(engine interfaces.ConsensusEngine) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.engine = engine
+	}
+}
+
+// WithDifficulty makes the blockchain use proof of work with block hashes
+// starting with difficulty "0" characters.
+func WithDifficulty(difficulty int) BlockchainOption {
+	return WithConsensusEngine(NewProofOfWork(difficulty))
+}
+
+// VerifyHeader checks that the hash of block carries enough work.
+func (pow *ProofOfWork) VerifyHeader(parent *types.BlockNode, block *types.Block) error {
+	if !bytes.HasPrefix(block.CalculateHash(), pow.prefix) {
+		return ErrInsufficientWork
+	}
+	return nil
+}
+
+// Prepare resets the nonce of block.
+func (pow *ProofOfWork) Prepare(parent *types.BlockNode, block *types.Block) error {
+	block.Data = 0
+	return nil
+}
+
+// Finalize does nothing; proof of work has no block rewards yet.
+func (pow *ProofOfWork) Finalize(parent *types.BlockNode, block *types.Block) error {
+	return nil
+}
+
+// Seal grinds nonces until the hash of block carries enough work.
+func (pow *ProofOfWork) Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	sealed := *block
+	for {
+		select {
+		case <-stop:
+			return nil, ErrSealAborted
+		default:
+		}
+		if bytes.HasPrefix(sealed.CalculateHash(), pow.prefix) {
+			return &sealed, nil
+		}
+		sealed.Data++
+	}
+}
+
+// CalculateWork returns the expected number of hashes needed to seal a block,
+// which is 256 to the power of the difficulty.
+func (pow *ProofOfWork) CalculateWork(block *types.Block) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(8*len(pow.prefix)))
+}
@@ -0,0 +1,188 @@
+package src
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// SealMode selects how a node produces blocks.
+type SealMode string
+
+// Supported seal modes. All modes but SealProofOfWork skip proof of work and
+// are only allowed on networks that permit instant sealing.
+const (
+	// SealProofOfWork mines blocks by grinding nonces.
+	SealProofOfWork SealMode = "pow"
+	// SealInstant seals a block as soon as a transaction is submitted.
+	SealInstant SealMode = "instant"
+	// SealInterval seals a block every mining interval.
+	SealInterval SealMode = "interval"
+	// SealManual only seals blocks when asked to, for example over the dev RPC.
+	SealManual SealMode = "manual"
+)
+
+// ErrInstantSealNotAllowed is returned when a seal mode without proof of work is
+// selected on a network that does not permit it.
+var ErrInstantSealNotAllowed = errors.New("sealing without proof of work is only allowed on dev networks")
+
+// instantSeal reports whether the mode produces blocks without proof of work.
+func (m SealMode) instantSeal() bool {
+	return m != SealProofOfWork && m != ""
+}
+
+// Validate checks that the mode is supported.
+func (m SealMode) Validate() error {
+	switch m {
+	case SealProofOfWork, SealInstant, SealInterval, SealManual, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown seal mode %q", m)
+	}
+}
+
+// CheckSealMode returns ErrInstantSealNotAllowed if mode skips proof of work and
+// the network does not permit it.
+func (p NetworkProfile) CheckSealMode(mode SealMode) error {
+	if mode.instantSeal() && !p.AllowInstantSeal {
+		return fmt.Errorf("%w, not on %s", ErrInstantSealNotAllowed, p.Name)
+	}
+	return nil
+}
+
+// checkInstantSeal returns ErrInstantSealNotAllowed unless the chain with the
+// given ID permits sealing without proof of work. Chains without an ID are
+// local and permit it.
+func checkInstantSeal(chainID string) error {
+	if chainID == "" {
+		return nil
+	}
+	for _, profile := range networkProfiles {
+		if profile.ChainID == chainID {
+			return profile.CheckSealMode(SealInstant)
+		}
+	}
+	return fmt.Errorf("%w, not on chain %s", ErrInstantSealNotAllowed, chainID)
+}
+
+// InstantSeal is the consensus engine of dev networks: every block is valid and
+// sealing it takes no work. On networks that do not permit instant sealing, err
+// is set and every block is refused with it.
+type InstantSeal struct {
+	err error
+}
+
+var _ interfaces.ConsensusEngine = InstantSeal{}
+
+// VerifyHeader accepts every block unless err is set.
+func (e InstantSeal) VerifyHeader(parent *types.BlockNode, block *types.Block) error {
+	return e.err
+}
+
+// Prepare returns err.
+func (e InstantSeal) Prepare(parent *types.BlockNode, block *types.Block) error {
+	return e.err
+}
+
+// Finalize returns err.
+func (e InstantSeal) Finalize(parent *types.BlockNode, block *types.Block) error {
+	return e.err
+}
+
+// Seal returns a copy of block, or err if it is set.
+func (e InstantSeal) Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	sealed := *block
+	return &sealed, nil
+}
+
+// CalculateWork counts every block as one unit of work, so the longest chain wins.
+func (InstantSeal) CalculateWork(block *types.Block) *big.Int {
+	return big.NewInt(1)
+}
+
+// WithoutProofOfWork makes the blockchain accept blocks regardless of their hash
+// by switching to the InstantSeal engine. It is meant for dev networks, together
+// with a node in an instant seal mode; on other networks the blockchain refuses
+// every block with ErrInstantSealNotAllowed.
+func WithoutProofOfWork() BlockchainOption {
+	return WithConsensusEngine(InstantSeal{})
+}
+
+// WithSealMode selects how the node produces blocks once started. Modes other
+// than SealProofOfWork need a blockchain created with WithoutProofOfWork.
+func WithSealMode(mode SealMode) NodeOption {
+	return func(n *Node) {
+		n.sealMode = mode
+	}
+}
+
+// Seal immediately produces a block with the pending transactions and announces
+// it. It is meant for engines that seal without work, such as InstantSeal.
+func (n *Node) Seal() (*types.Block, error) {
+	n.sealMux.Lock()
+	defer n.sealMux.Unlock()
+
+	pending := n.mempool.Take(DefaultMaxBlockTransactions - 1)
+	transactions, offset := pending, 0
+	if coinbase, ok := n.coinbase(); ok {
+		transactions, offset = append([]types.Transaction{coinbase}, pending...), 1
+	}
+	block, err := n.blockchain.GetConsensusEngine().Seal(n.blockchain.GenerateNewBlock(transactions), nil)
+	if err == nil {
+		err = n.PublishBlock(block)
+	}
+	if err != nil {
+		n.requeue(pending, offset, err)
+		return nil, err
+	}
+	if n.metrics != nil {
+		n.metrics.BlocksMined.Add(1)
+	}
+	n.logger.Debug("Sealed block", blockAttr(block))
+	return block, nil
+}
+
+// sealEvery seals a block every interval, forever.
+func (n *Node) sealEvery(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		if _, err := n.Seal(); err != nil {
+			n.logger.Warn("Failed to seal block", errorAttr(err))
+		}
+	}
+}
+
+// NewDevRPCServer creates an HTTP server for driving a dev node from tests at
+// address. POST /mine seals a block and responds with its height and hash.
+func NewDevRPCServer(address string, node *Node) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mine", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		block, err := node.Seal()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Height       uint64 `json:"height"`
+			Hash         string `json:"hash"`
+			Transactions int    `json:"transactions"`
+		}{block.Index, hex.EncodeToString(block.CalculateHash()), len(block.Transactions)})
+	})
+	return &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+}
@@ -0,0 +1,56 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Errors returned when a block does not fit into the blockchain.
+var (
+	ErrInvalidIndex       = errors.New("block index is not valid")
+	ErrPrevHashMismatch   = errors.New("previous hash does not match the parent block")
+	ErrInsufficientWork   = errors.New("block hash does not meet the difficulty")
+	ErrUnknownParent      = errors.New("parent block is unknown")
+	ErrBlockExists        = errors.New("block already exists")
+	ErrNoCommonAncestor   = errors.New("chain has no common ancestor with the blockchain")
+	ErrGenesisMismatch    = errors.New("genesis block does not match")
+	ErrCheckpointConflict = errors.New("block conflicts with checkpoint")
+	ErrBelowCheckpoint    = errors.New("block reorganizes below checkpoint")
+)
+
+// Errors returned when exchanging messages with peers.
+var (
+	ErrPeerTimeout      = errors.New("peer timed out")
+	ErrPeerUnreachable  = errors.New("peer is unreachable")
+	ErrMessageTooLarge  = errors.New("message exceeds the size limit")
+	ErrMalformedMessage = errors.New("message is malformed")
+)
+
+// PeerError reports a failure to exchange messages with a peer. It matches both
+// its Kind (ErrPeerTimeout or ErrPeerUnreachable) and the underlying error.
+type PeerError struct {
+	Address string
+	Kind    error
+	Err     error
+}
+
+// newPeerError classifies err, returned while talking to the peer at address.
+func newPeerError(address string, err error) *PeerError {
+	kind := ErrPeerUnreachable
+	var netErr net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		kind = ErrPeerTimeout
+	}
+	return &PeerError{Address: address, Kind: kind, Err: err}
+}
+
+func (e *PeerError) Error() string {
+	return fmt.Sprintf("%v: node %s: %v", e.Kind, e.Address, e.Err)
+}
+
+// Unwrap returns the kind of failure and the underlying error.
+func (e *PeerError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
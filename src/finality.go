@@ -0,0 +1,591 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// Errors returned by the finality gadget.
+var (
+	ErrInvalidFinalityVote = errors.New("finality vote is invalid")
+	ErrConflictingVote     = errors.New("validator voted for two blocks at the same height")
+	ErrVoteKnown           = errors.New("finality vote is already known")
+	ErrInvalidCertificate  = errors.New("finality certificate is invalid")
+)
+
+// FinalityGadget makes checkpoint blocks final with the agreement of a set of
+// validators. Once a block at a checkpoint height is canonical, every validator
+// prevotes for it; once more than two thirds prevoted for the same block in a
+// round, they precommit to it, and once more than two thirds precommitted in
+// the round, their precommits form a certificate and the block is final. Final
+// checkpoints are never reverted by fork choice.
+//
+// A validator votes at most once per step, height and round. It starts the
+// next round when the prevotes of its round are split, or when a reorganization
+// makes another block canonical after it prevoted. A validator that precommitted
+// to a block is locked on it and keeps prevoting for it, until more than two
+// thirds prevote for another block in a later round.
+type FinalityGadget struct {
+	blockchain *Blockchain
+	validators [][]byte
+	chainID    string
+	interval   uint64
+	path       string
+
+	mux sync.Mutex
+	key *wallet.Key
+	// votes maps a step, height and round to the vote of every validator.
+	votes map[voteSlot]map[string]types.Vote
+	// voted holds the slots the authorized validator voted in.
+	voted map[voteSlot]bool
+	// rounds maps a checkpoint height to the round the authorized validator is in.
+	rounds map[uint64]uint32
+	// locks maps a checkpoint height to the block the authorized validator
+	// precommitted to last.
+	locks        map[uint64]voteLock
+	certificates map[uint64]*types.FinalityCertificate
+	latest       *types.FinalityCertificate
+	// outbox holds the votes cast by the authorized validator not yet sent.
+	outbox []types.Vote
+}
+
+// FinalityOption configures a FinalityGadget.
+type FinalityOption func(*FinalityGadget)
+
+// voteSlot is a step of the finality protocol at a height and round.
+type voteSlot struct {
+	kind   types.VoteType
+	height uint64
+	round  uint32
+}
+
+// voteLock is a block the authorized validator precommitted to in a round.
+type voteLock struct {
+	round     uint32
+	blockHash []byte
+}
+
+// certificateRecord is the on-disk representation of a FinalityCertificate.
+type certificateRecord struct {
+	Height     uint64            `json:"height"`
+	Round      uint32            `json:"round"`
+	Hash       string            `json:"hash"`
+	Precommits []precommitRecord `json:"precommits"`
+}
+
+// precommitRecord is the on-disk representation of a precommit of a certificate.
+type precommitRecord struct {
+	Validator string `json:"validator"`
+	Signature string `json:"signature"`
+}
+
+// WithCertificateFile persists finality certificates in the given file. The
+// certificates stored in the file are verified and the latest one is finalized
+// again, so that final checkpoints survive restarts.
+func WithCertificateFile(path string) FinalityOption {
+	return func(g *FinalityGadget) {
+		g.path = path
+	}
+}
+
+// NewFinalityGadget creates a finality gadget for blockchain with the given
+// validators. The checkpoint interval of blockchain selects the blocks to
+// finalize, and blockchain no longer pins automatic checkpoints on its own.
+func NewFinalityGadget(blockchain *Blockchain, validators []ed25519.PublicKey, opts ...FinalityOption) *FinalityGadget {
+	g := &FinalityGadget{
+		blockchain:   blockchain,
+		votes:        make(map[voteSlot]map[string]types.Vote),
+		voted:        make(map[voteSlot]bool),
+		rounds:       make(map[uint64]uint32),
+		locks:        make(map[uint64]voteLock),
+		certificates: make(map[uint64]*types.FinalityCertificate),
+	}
+	for _, validator := range validators {
+		g.validators = append(g.validators, []byte(validator))
+	}
+	sortKeys(g.validators)
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	blockchain.mux.Lock()
+	blockchain.finality = true
+	g.chainID = blockchain.chainID
+	g.interval = blockchain.checkpoints.interval
+	blockchain.mux.Unlock()
+
+	if g.path != "" {
+		if err := g.load(); err != nil {
+			blockchain.logger.Warn("Failed to load finality certificates", errorAttr(err))
+		}
+	}
+	return g
+}
+
+// Authorize makes the gadget vote with key, which must be a validator's.
+func (g *FinalityGadget) Authorize(key *wallet.Key) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	g.key = key
+}
+
+// Validators returns the public keys of the validators.
+func (g *FinalityGadget) Validators() []ed25519.PublicKey {
+	validators := make([]ed25519.PublicKey, len(g.validators))
+	for i, validator := range g.validators {
+		validators[i] = ed25519.PublicKey(validator)
+	}
+	return validators
+}
+
+// Certificate returns the certificate of the final checkpoint at height, or of
+// the latest final checkpoint if height is 0. It returns nil if there is none.
+func (g *FinalityGadget) Certificate(height uint64) *types.FinalityCertificate {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	if height == 0 {
+		return g.latest
+	}
+	return g.certificates[height]
+}
+
+// AddVote verifies and counts a vote. A quorum of prevotes makes the authorized
+// validator precommit, and a quorum of precommits finalizes the block. Votes
+// more than a checkpoint interval above the head are refused.
+func (g *FinalityGadget) AddVote(vote types.Vote) error {
+	if head := g.blockchain.GetLatestBlock(); vote.Height > head.Index+g.interval {
+		return fmt.Errorf("%w: height %d is too far above the head at %d", ErrInvalidFinalityVote, vote.Height, head.Index)
+	}
+	if err := g.verifyVote(vote); err != nil {
+		return err
+	}
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	return g.count(vote)
+}
+
+// AddCertificate verifies a certificate received from a peer and finalizes its block.
+func (g *FinalityGadget) AddCertificate(certificate *types.FinalityCertificate) error {
+	if err := g.verifyCertificate(certificate); err != nil {
+		return err
+	}
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	return g.finalize(certificate)
+}
+
+// step prevotes for the canonical block at the latest checkpoint height if it
+// is not final yet, and returns the votes cast since the last step.
+func (g *FinalityGadget) step() ([]types.Vote, error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	var err error
+	if head := g.blockchain.GetLatestBlock(); g.interval > 0 {
+		height := head.Index - head.Index%g.interval
+		if height > 0 && height > g.finalizedHeight() {
+			if node := g.blockchain.GetBlockByHeight(height); node != nil {
+				err = g.prevote(height, node.Hash)
+			}
+		}
+	}
+	votes := g.outbox
+	g.outbox = nil
+	return votes, err
+}
+
+// prevote prevotes at height for the block the authorized validator is locked
+// on, or else for canonical. If the authorized validator already prevoted in
+// its round and the round failed, it moves on to the next one. The caller must
+// hold mux.
+func (g *FinalityGadget) prevote(height uint64, canonical []byte) error {
+	if g.key == nil {
+		return nil
+	}
+	blockHash := canonical
+	if lock, ok := g.locks[height]; ok {
+		blockHash = lock.blockHash
+	}
+	round := g.rounds[height]
+	slot := voteSlot{kind: types.Prevote, height: height, round: round}
+	if own, ok := g.votes[slot][string(g.key.PublicKey())]; ok {
+		if bytes.Equal(own.BlockHash, blockHash) && !g.split(slot) {
+			return nil
+		}
+		round++
+		g.rounds[height] = round
+	}
+	return g.vote(types.Prevote, height, round, blockHash)
+}
+
+// vote casts a vote of the authorized validator, unless it is not a validator
+// or already voted in the step at height and round. The caller must hold mux.
+func (g *FinalityGadget) vote(kind types.VoteType, height uint64, round uint32, blockHash []byte) error {
+	slot := voteSlot{kind: kind, height: height, round: round}
+	if g.key == nil || !g.isValidator(g.key.PublicKey()) || g.voted[slot] {
+		return nil
+	}
+	vote := types.Vote{Type: kind, Height: height, Round: round, BlockHash: blockHash}
+	g.key.SignVote(&vote, g.chainID)
+	g.voted[slot] = true
+	g.outbox = append(g.outbox, vote)
+	return g.count(vote)
+}
+
+// count records a verified vote and moves on to the next step once its block
+// has a quorum. The caller must hold mux.
+func (g *FinalityGadget) count(vote types.Vote) error {
+	if vote.Height <= g.finalizedHeight() {
+		return nil
+	}
+	slot := voteSlot{kind: vote.Type, height: vote.Height, round: vote.Round}
+	if existing, ok := g.votes[slot][string(vote.Validator)]; ok {
+		if bytes.Equal(existing.BlockHash, vote.BlockHash) {
+			return ErrVoteKnown
+		}
+		return fmt.Errorf("%w: %s at height %d in round %d", ErrConflictingVote, vote.Type, vote.Height, vote.Round)
+	}
+	if g.votes[slot] == nil {
+		g.votes[slot] = make(map[string]types.Vote)
+	}
+	g.votes[slot][string(vote.Validator)] = vote
+
+	// More than a third of the validators prevoting in a later round means the
+	// authorized validator fell behind.
+	if vote.Type == types.Prevote && vote.Round > g.rounds[vote.Height] && 3*len(g.votes[slot]) > len(g.validators) {
+		g.rounds[vote.Height] = vote.Round
+	}
+
+	var supporting []types.Vote
+	for _, validator := range g.validators {
+		if v, ok := g.votes[slot][string(validator)]; ok && bytes.Equal(v.BlockHash, vote.BlockHash) {
+			supporting = append(supporting, v)
+		}
+	}
+	if !g.quorum(len(supporting)) {
+		return nil
+	}
+	if vote.Type == types.Prevote {
+		return g.precommit(vote.Height, vote.Round, vote.BlockHash)
+	}
+	return g.finalize(&types.FinalityCertificate{Height: vote.Height, Round: vote.Round, BlockHash: vote.BlockHash, Precommits: supporting})
+}
+
+// precommit precommits to a block more than two thirds of the validators
+// prevoted for in round and locks the authorized validator on it. Quorums of
+// rounds the authorized validator already left, or older than its lock, are
+// ignored. The caller must hold mux.
+func (g *FinalityGadget) precommit(height uint64, round uint32, blockHash []byte) error {
+	if g.key == nil || !g.isValidator(g.key.PublicKey()) || round < g.rounds[height] {
+		return nil
+	}
+	if lock, ok := g.locks[height]; ok && round < lock.round {
+		return nil
+	}
+	g.rounds[height] = round
+	g.locks[height] = voteLock{round: round, blockHash: blockHash}
+	return g.vote(types.Precommit, height, round, blockHash)
+}
+
+// split reports whether the votes in slot are divided so that no block can get
+// a quorum any more. The caller must hold mux.
+func (g *FinalityGadget) split(slot voteSlot) bool {
+	support := make(map[string]int)
+	most := 0
+	for _, vote := range g.votes[slot] {
+		support[string(vote.BlockHash)]++
+		most = max(most, support[string(vote.BlockHash)])
+	}
+	return !g.quorum(most + len(g.validators) - len(g.votes[slot]))
+}
+
+// finalize stores a verified certificate and, if it is the latest, pins its
+// block as final in the blockchain. The caller must hold mux.
+func (g *FinalityGadget) finalize(certificate *types.FinalityCertificate) error {
+	if g.certificates[certificate.Height] == nil {
+		g.certificates[certificate.Height] = certificate
+		g.save()
+	}
+	if certificate.Height <= g.finalizedHeight() {
+		return nil
+	}
+	g.latest = certificate
+	for slot := range g.votes {
+		if slot.height <= certificate.Height {
+			delete(g.votes, slot)
+		}
+	}
+	for slot := range g.voted {
+		if slot.height <= certificate.Height {
+			delete(g.voted, slot)
+		}
+	}
+	for height := range g.rounds {
+		if height <= certificate.Height {
+			delete(g.rounds, height)
+		}
+	}
+	for height := range g.locks {
+		if height <= certificate.Height {
+			delete(g.locks, height)
+		}
+	}
+	return g.blockchain.FinalizeCheckpoint(Checkpoint{Height: certificate.Height, Hash: certificate.BlockHash})
+}
+
+// finalizedHeight returns the height of the latest final checkpoint. The caller
+// must hold mux.
+func (g *FinalityGadget) finalizedHeight() uint64 {
+	if g.latest == nil {
+		return 0
+	}
+	return g.latest.Height
+}
+
+// quorum reports whether votes are more than two thirds of the validators.
+func (g *FinalityGadget) quorum(votes int) bool {
+	return 3*votes > 2*len(g.validators)
+}
+
+// isValidator reports whether key is a validator's.
+func (g *FinalityGadget) isValidator(key []byte) bool {
+	for _, validator := range g.validators {
+		if bytes.Equal(validator, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyVote checks that vote is a validator's signed vote at a checkpoint height.
+func (g *FinalityGadget) verifyVote(vote types.Vote) error {
+	switch {
+	case vote.Type != types.Prevote && vote.Type != types.Precommit:
+		return fmt.Errorf("%w: unknown vote type %d", ErrInvalidFinalityVote, vote.Type)
+	case g.interval == 0 || vote.Height == 0 || vote.Height%g.interval != 0:
+		return fmt.Errorf("%w: height %d is not a checkpoint height", ErrInvalidFinalityVote, vote.Height)
+	case !g.isValidator(vote.Validator):
+		return fmt.Errorf("%w: voter is not a validator", ErrInvalidFinalityVote)
+	case !wallet.Verify(vote.Validator, vote.SigningHash(g.chainID), vote.Signature):
+		return fmt.Errorf("%w: signature is invalid", ErrInvalidFinalityVote)
+	}
+	return nil
+}
+
+// verifyCertificate checks that certificate holds the precommits of more than
+// two thirds of the validators for its block in its round.
+func (g *FinalityGadget) verifyCertificate(certificate *types.FinalityCertificate) error {
+	signed := make(map[string]bool)
+	for _, precommit := range certificate.Precommits {
+		if precommit.Type != types.Precommit || precommit.Height != certificate.Height || precommit.Round != certificate.Round || !bytes.Equal(precommit.BlockHash, certificate.BlockHash) {
+			return fmt.Errorf("%w: vote is not a precommit for the block", ErrInvalidCertificate)
+		}
+		if err := g.verifyVote(precommit); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+		signed[string(precommit.Validator)] = true
+	}
+	if !g.quorum(len(signed)) {
+		return fmt.Errorf("%w: %d of %d validators precommitted", ErrInvalidCertificate, len(signed), len(g.validators))
+	}
+	return nil
+}
+
+// load verifies the certificates persisted at g.path and finalizes the latest
+// one. A missing file is not an error.
+func (g *FinalityGadget) load() error {
+	data, err := os.ReadFile(g.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []certificateRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse certificate file %s: %w", g.path, err)
+	}
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	for _, record := range records {
+		certificate, err := record.certificate()
+		if err != nil {
+			return err
+		}
+		if err := g.verifyCertificate(certificate); err != nil {
+			return fmt.Errorf("certificate at height %d: %w", record.Height, err)
+		}
+		g.certificates[certificate.Height] = certificate
+		if certificate.Height > g.finalizedHeight() {
+			g.latest = certificate
+		}
+	}
+	if g.latest == nil {
+		return nil
+	}
+	return g.blockchain.FinalizeCheckpoint(Checkpoint{Height: g.latest.Height, Hash: g.latest.BlockHash})
+}
+
+// save writes all certificates to g.path, replacing the file atomically, if a
+// certificate file is configured. The caller must hold mux.
+func (g *FinalityGadget) save() {
+	if g.path == "" {
+		return
+	}
+	heights := make([]uint64, 0, len(g.certificates))
+	for height := range g.certificates {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	records := make([]certificateRecord, len(heights))
+	for i, height := range heights {
+		records[i] = newCertificateRecord(g.certificates[height])
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err == nil {
+		tmpPath := g.path + ".tmp"
+		if err = os.WriteFile(tmpPath, data, 0o644); err == nil {
+			err = os.Rename(tmpPath, g.path)
+		}
+	}
+	if err != nil {
+		g.blockchain.logger.Error("Failed to save finality certificates", errorAttr(err))
+	}
+}
+
+// newCertificateRecord returns the on-disk representation of certificate.
+func newCertificateRecord(certificate *types.FinalityCertificate) certificateRecord {
+	record := certificateRecord{Height: certificate.Height, Round: certificate.Round, Hash: hex.EncodeToString(certificate.BlockHash)}
+	for _, precommit := range certificate.Precommits {
+		record.Precommits = append(record.Precommits, precommitRecord{
+			Validator: hex.EncodeToString(precommit.Validator),
+			Signature: hex.EncodeToString(precommit.Signature),
+		})
+	}
+	return record
+}
+
+// certificate decodes the certificate of the record.
+func (r certificateRecord) certificate() (*types.FinalityCertificate, error) {
+	hash, err := hex.DecodeString(r.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate hash at height %d: %w", r.Height, err)
+	}
+	certificate := &types.FinalityCertificate{Height: r.Height, Round: r.Round, BlockHash: hash}
+	for _, precommit := range r.Precommits {
+		validator, err := hex.DecodeString(precommit.Validator)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator in certificate at height %d: %w", r.Height, err)
+		}
+		signature, err := hex.DecodeString(precommit.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature in certificate at height %d: %w", r.Height, err)
+		}
+		certificate.Precommits = append(certificate.Precommits, types.Vote{
+			Type:      types.Precommit,
+			Height:    r.Height,
+			Round:     r.Round,
+			BlockHash: hash,
+			Validator: validator,
+			Signature: signature,
+		})
+	}
+	return certificate, nil
+}
+
+// FinalizeCheckpoint pins checkpoint as final. Checkpoints below the latest
+// final one are ignored. If the canonical chain conflicts with the checkpoint,
+// the chain with the most work through it becomes canonical.
+func (bc *Blockchain) FinalizeCheckpoint(checkpoint Checkpoint) error {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	if bc.finalized != nil && checkpoint.Height <= bc.finalized.Height {
+		if checkpoint.Height == bc.finalized.Height && !bytes.Equal(checkpoint.Hash, bc.finalized.Hash) {
+			return fmt.Errorf("%w at height %d", ErrCheckpointConflict, checkpoint.Height)
+		}
+		return nil
+	}
+	if err := bc.checkpoints.add(checkpoint); err != nil {
+		return fmt.Errorf("%w at height %d", ErrCheckpointConflict, checkpoint.Height)
+	}
+	bc.saveCheckpoints()
+	bc.finalized = &checkpoint
+	bc.logger.Info("Checkpoint finalized", blockHashAttr(checkpoint.Hash, checkpoint.Height))
+
+	node := bc.nodes[string(checkpoint.Hash)]
+	switch {
+	case node == nil:
+		// The chain through the checkpoint wins fork choice once it arrives.
+	case bytes.Equal(bc.index.hashAt(checkpoint.Height), checkpoint.Hash):
+		bc.ApproveBlock(node)
+	default:
+		bc.setHead(heaviestDescendant(node))
+	}
+	return nil
+}
+
+// GetFinalized returns the latest final checkpoint, or nil if there is none.
+func (bc *Blockchain) GetFinalized() *Checkpoint {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.finalized == nil {
+		return nil
+	}
+	finalized := *bc.finalized
+	return &finalized
+}
+
+// includesFinalized reports whether the chain ending at node goes through the
+// latest final checkpoint. The caller must hold bc.mux.
+func (bc *Blockchain) includesFinalized(node *types.BlockNode) bool {
+	ancestor := ancestorAt(node, bc.finalized.Height)
+	return ancestor != nil && bytes.Equal(ancestor.Hash, bc.finalized.Hash)
+}
+
+// conflictsWithFinalized reports whether the chain ending at node has another
+// block at the height of the latest final checkpoint. The caller must hold bc.mux.
+func (bc *Blockchain) conflictsWithFinalized(node *types.BlockNode) bool {
+	ancestor := ancestorAt(node, bc.finalized.Height)
+	return ancestor != nil && !bytes.Equal(ancestor.Hash, bc.finalized.Hash)
+}
+
+// ancestorAt returns the ancestor of node at height, or nil if node is below it.
+func ancestorAt(node *types.BlockNode, height uint64) *types.BlockNode {
+	if node.Block.Index < height {
+		return nil
+	}
+	for node.Block.Index > height {
+		node = node.Parent
+	}
+	return node
+}
+
+// heaviestDescendant returns the descendant of node, or node itself, with the most work.
+func heaviestDescendant(node *types.BlockNode) *types.BlockNode {
+	best := node
+	for _, child := range node.Childs {
+		if candidate := heaviestDescendant(child); candidate.TotalWork.Cmp(best.TotalWork) > 0 {
+			best = candidate
+		}
+	}
+	return best
+}
@@ -0,0 +1,94 @@
+package src
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// nodeIDSize is the number of public key hash bytes that make up a node ID.
+const nodeIDSize = 20
+
+// Identity is the ed25519 key pair a node authenticates itself with.
+type Identity struct {
+	privateKey ed25519.PrivateKey
+}
+
+// GenerateIdentity creates a new random Identity.
+func GenerateIdentity() (*Identity, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{privateKey: privateKey}, nil
+}
+
+// LoadOrCreateIdentity reads the identity stored at path, or generates one and
+// stores it there if the file does not exist.
+func LoadOrCreateIdentity(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		identity, err := GenerateIdentity()
+		if err != nil {
+			return nil, err
+		}
+		return identity, identity.save(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("identity file %s does not contain a private key", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity file %s: %w", path, err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("identity file %s does not contain an ed25519 key", path)
+	}
+	return &Identity{privateKey: privateKey}, nil
+}
+
+// save writes the private key to path in PEM encoded PKCS #8 form.
+func (id *Identity) save(path string) error {
+	der, err := x509.MarshalPKCS8PrivateKey(id.privateKey)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return os.WriteFile(path, data, 0o600)
+}
+
+// PublicKey returns the public half of the identity.
+func (id *Identity) PublicKey() ed25519.PublicKey {
+	return id.privateKey.Public().(ed25519.PublicKey)
+}
+
+// ID returns the node ID derived from the identity's public key.
+func (id *Identity) ID() string {
+	return NodeID(id.PublicKey())
+}
+
+// ValidateNodeID checks that id is a node ID: nodeIDSize hex encoded bytes.
+func ValidateNodeID(id string) error {
+	if b, err := hex.DecodeString(id); err != nil || len(b) != nodeIDSize {
+		return fmt.Errorf("invalid node ID %q, expected %d hex characters", id, 2*nodeIDSize)
+	}
+	return nil
+}
+
+// NodeID derives a node ID from a public key: the hex encoded prefix of its SHA-256 hash.
+func NodeID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:nodeIDSize])
+}
@@ -0,0 +1,70 @@
+package src
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// Keys of the fields attached to log records.
+const (
+	LogKeyNode        = "node"
+	LogKeyPeer        = "peer"
+	LogKeyBlockHash   = "block_hash"
+	LogKeyHeight      = "height"
+	LogKeyMessageType = "message_type"
+	LogKeyError       = "error"
+)
+
+// LogFormat selects how log records are written.
+type LogFormat string
+
+// Supported log formats.
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+// NewLogger creates a logger that writes records at level or above to w.
+func NewLogger(w io.Writer, level slog.Leveler, format LogFormat) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case LogFormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// blockAttr returns the fields identifying a block.
+func blockAttr(block *types.Block) slog.Attr {
+	if block == nil {
+		return slog.Attr{}
+	}
+	return blockHashAttr(block.CalculateHash(), block.Index)
+}
+
+// blockHashAttr returns the fields identifying the block with the given hash and height.
+func blockHashAttr(hash []byte, height uint64) slog.Attr {
+	return slog.Group("", slog.String(LogKeyBlockHash, hex.EncodeToString(hash)), slog.Uint64(LogKeyHeight, height))
+}
+
+// peerAttr returns the field identifying the peer behind sender, or an empty
+// field if the sender does not identify its peer.
+func peerAttr(sender interfaces.MessageSender) slog.Attr {
+	if address := peerAddress(sender); address != "" {
+		return slog.String(LogKeyPeer, address)
+	}
+	return slog.Attr{}
+}
+
+// errorAttr returns the field describing err.
+func errorAttr(err error) slog.Attr {
+	return slog.Any(LogKeyError, err)
+}
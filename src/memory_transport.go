@@ -0,0 +1,196 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MemoryTransport connects nodes of the same process without opening ports.
+// Nodes that should reach each other must share one MemoryTransport.
+type MemoryTransport struct {
+	mux       sync.Mutex
+	listeners map[string]*memoryListener
+}
+
+// NewMemoryTransport creates an empty MemoryTransport.
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{listeners: make(map[string]*memoryListener)}
+}
+
+// Listen accepts connections dialed to address on the same transport.
+func (t *MemoryTransport) Listen(address string) (net.Listener, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if _, ok := t.listeners[address]; ok {
+		return nil, fmt.Errorf("address %s is already in use", address)
+	}
+	ln := &memoryListener{
+		transport: t,
+		address:   memoryAddr(address),
+		conns:     make(chan net.Conn),
+		done:      make(chan struct{}),
+	}
+	t.listeners[address] = ln
+	return ln, nil
+}
+
+// Dial connects to the listener registered for address.
+func (t *MemoryTransport) Dial(address string) (net.Conn, error) {
+	t.mux.Lock()
+	ln, ok := t.listeners[address]
+	t.mux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no node is listening on %s", address)
+	}
+
+	client, server := newMemoryConnPair(memoryAddr("dialer"), ln.address)
+	select {
+	case ln.conns <- server:
+		return client, nil
+	case <-ln.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes all listeners of the transport.
+func (t *MemoryTransport) Close() error {
+	t.mux.Lock()
+	listeners := make([]*memoryListener, 0, len(t.listeners))
+	for _, ln := range t.listeners {
+		listeners = append(listeners, ln)
+	}
+	t.mux.Unlock()
+
+	for _, ln := range listeners {
+		ln.Close()
+	}
+	return nil
+}
+
+// memoryAddr is the address of an in-memory endpoint.
+type memoryAddr string
+
+func (a memoryAddr) Network() string { return "memory" }
+
+func (a memoryAddr) String() string { return string(a) }
+
+// memoryListener hands out the server side of connections dialed to its address.
+type memoryListener struct {
+	transport *MemoryTransport
+	address   memoryAddr
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *memoryListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		l.transport.mux.Lock()
+		delete(l.transport.listeners, string(l.address))
+		l.transport.mux.Unlock()
+	})
+	return nil
+}
+
+func (l *memoryListener) Addr() net.Addr {
+	return l.address
+}
+
+// memoryPipe is a buffered one-way byte stream. Unlike net.Pipe, writes never
+// wait for the reader, so two nodes replying to each other cannot deadlock.
+type memoryPipe struct {
+	mux    sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+// newMemoryPipe creates an open memoryPipe.
+func newMemoryPipe() *memoryPipe {
+	p := &memoryPipe{}
+	p.cond = sync.NewCond(&p.mux)
+	return p
+}
+
+func (p *memoryPipe) read(b []byte) (int, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for p.buf.Len() == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return p.buf.Read(b)
+}
+
+func (p *memoryPipe) write(b []byte) (int, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := p.buf.Write(b)
+	p.cond.Broadcast()
+	return n, err
+}
+
+func (p *memoryPipe) close() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+// memoryConn is one end of an in-memory connection. Deadlines are ignored.
+type memoryConn struct {
+	in     *memoryPipe
+	out    *memoryPipe
+	local  net.Addr
+	remote net.Addr
+}
+
+// newMemoryConnPair creates the two ends of an in-memory connection.
+func newMemoryConnPair(dialer, listener net.Addr) (*memoryConn, *memoryConn) {
+	up, down := newMemoryPipe(), newMemoryPipe()
+	return &memoryConn{in: down, out: up, local: dialer, remote: listener},
+		&memoryConn{in: up, out: down, local: listener, remote: dialer}
+}
+
+func (c *memoryConn) Read(b []byte) (int, error) { return c.in.read(b) }
+
+func (c *memoryConn) Write(b []byte) (int, error) { return c.out.write(b) }
+
+func (c *memoryConn) Close() error {
+	c.in.close()
+	c.out.close()
+	return nil
+}
+
+func (c *memoryConn) LocalAddr() net.Addr { return c.local }
+
+func (c *memoryConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *memoryConn) SetDeadline(t time.Time) error { return nil }
+
+func (c *memoryConn) SetReadDeadline(t time.Time) error { return nil }
+
+func (c *memoryConn) SetWriteDeadline(t time.Time) error { return nil }
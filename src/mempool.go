@@ -0,0 +1,102 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// DefaultMempoolSize is the number of transactions a mempool holds by default.
+const DefaultMempoolSize = 10000
+
+// Errors returned when a transaction is not accepted into the mempool.
+var (
+	ErrMempoolFull      = errors.New("mempool is full")
+	ErrTransactionKnown = errors.New("transaction is already in the mempool")
+)
+
+// Mempool holds transactions waiting to be included in a block, in the order
+// they were submitted. Two queued UTXO transactions never spend the same output.
+type Mempool struct {
+	mux          sync.Mutex
+	transactions []types.Transaction
+	hashes       map[string]struct{}
+	spent        map[string]struct{}
+	limit        int
+	rules        []TransactionRule
+	ctx          *ValidationContext
+}
+
+// NewMempool creates a mempool holding up to limit transactions that pass rules
+// on the chain with the given ID. The rules get a validation context holding
+// only the chain ID.
+func NewMempool(limit int, rules []TransactionRule, chainID string) *Mempool {
+	return &Mempool{
+		hashes: make(map[string]struct{}),
+		spent:  make(map[string]struct{}),
+		limit:  limit,
+		rules:  rules,
+		ctx:    &ValidationContext{ChainID: chainID},
+	}
+}
+
+// Add queues a transaction.
+func (m *Mempool) Add(tx types.Transaction) error {
+	for _, rule := range m.rules {
+		if err := rule(m.ctx, &tx); err != nil {
+			return err
+		}
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	hash := string(tx.CalculateHash())
+	if _, known := m.hashes[hash]; known {
+		return ErrTransactionKnown
+	}
+	for _, input := range tx.Inputs {
+		if _, spent := m.spent[input.PreviousOutput.String()]; spent {
+			return fmt.Errorf("%w: %s is spent by a queued transaction", ErrDoubleSpend, input.PreviousOutput)
+		}
+	}
+	if len(m.transactions) >= m.limit {
+		return ErrMempoolFull
+	}
+	m.transactions = append(m.transactions, tx)
+	m.hashes[hash] = struct{}{}
+	for _, input := range tx.Inputs {
+		m.spent[input.PreviousOutput.String()] = struct{}{}
+	}
+	return nil
+}
+
+// Take removes and returns up to n of the oldest transactions.
+func (m *Mempool) Take(n int) []types.Transaction {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if n > len(m.transactions) {
+		n = len(m.transactions)
+	}
+	taken := make([]types.Transaction, n)
+	copy(taken, m.transactions)
+	m.transactions = m.transactions[n:]
+	for _, tx := range taken {
+		delete(m.hashes, string(tx.CalculateHash()))
+		for _, input := range tx.Inputs {
+			delete(m.spent, input.PreviousOutput.String())
+		}
+	}
+	return taken
+}
+
+// Size returns the number of queued transactions.
+func (m *Mempool) Size() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return len(m.transactions)
+}
@@ -0,0 +1,390 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// metric is a metric family that can be written in the Prometheus text format.
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry collects metrics and serves them in the Prometheus text format.
+type Registry struct {
+	mux     sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m to the registry, replacing a metric with the same name.
+func (r *Registry) register(m metric) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.metrics[m.name()] = m
+}
+
+// WriteTo writes all metrics ordered by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mux.Lock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mux.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, m := range metrics {
+		m.write(cw)
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family.
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatValue formats a sample value.
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatLabels formats label pairs as {name="value",...}.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.Quote(values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	metricName string
+	help       string
+	value      atomic.Uint64
+}
+
+// Add increases the counter by n.
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+func (c *Counter) name() string { return c.metricName }
+
+func (c *Counter) write(w io.Writer) {
+	writeHeader(w, c.metricName, c.help, "counter")
+	fmt.Fprintf(w, "%s %d\n", c.metricName, c.Value())
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	metricName string
+	help       string
+	labels     []string
+	mux        sync.Mutex
+	values     map[string]uint64
+}
+
+// Add increases the counter with the given label values by n.
+func (c *CounterVec) Add(n uint64, labelValues ...string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.values[strings.Join(labelValues, "\x00")] += n
+}
+
+// Value returns the counter with the given label values.
+func (c *CounterVec) Value(labelValues ...string) uint64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.values[strings.Join(labelValues, "\x00")]
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mux.Lock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]uint64, len(keys))
+	for i, key := range keys {
+		values[i] = c.values[key]
+	}
+	c.mux.Unlock()
+
+	writeHeader(w, c.metricName, c.help, "counter")
+	for i, key := range keys {
+		fmt.Fprintf(w, "%s%s %d\n", c.metricName, formatLabels(c.labels, strings.Split(key, "\x00")), values[i])
+	}
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	metricName string
+	help       string
+	bits       atomic.Uint64
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+func (g *Gauge) name() string { return g.metricName }
+
+func (g *Gauge) write(w io.Writer) {
+	writeHeader(w, g.metricName, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatValue(g.Value()))
+}
+
+// gaugeFunc is a gauge whose value is computed when the metrics are collected.
+type gaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+func (g *gaugeFunc) name() string { return g.metricName }
+
+func (g *gaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.metricName, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatValue(g.fn()))
+}
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	metricName string
+	help       string
+	buckets    []float64
+	mux        sync.Mutex
+	counts     []uint64
+	sum        float64
+	count      uint64
+}
+
+// Observe adds an observation.
+func (h *Histogram) Observe(v float64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	return h.count
+}
+
+func (h *Histogram) name() string { return h.metricName }
+
+func (h *Histogram) write(w io.Writer) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", h.metricName, formatValue(bound), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.metricName, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.metricName, formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.metricName, h.count)
+}
+
+// Metrics are the metrics of a node, its blockchain and its network. Pass them to
+// the blockchain with WithMetrics and to the node with WithNodeMetrics, and serve
+// them with ServeHTTP or NewMetricsServer.
+type Metrics struct {
+	*Registry
+
+	BlockValidationSeconds *Histogram
+	ReorgDepth             *Histogram
+	BlocksRejected         *Counter
+	BlocksMined            *Counter
+	MiningHashrate         *Gauge
+	MessagesTotal          *CounterVec
+	BytesTotal             *CounterVec
+}
+
+// NewMetrics creates the metrics of a node.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: NewRegistry(),
+		BlockValidationSeconds: &Histogram{
+			metricName: "blockchain_block_validation_seconds",
+			help:       "Time spent validating blocks added to the blockchain.",
+			buckets:    []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+		ReorgDepth: &Histogram{
+			metricName: "blockchain_reorg_depth",
+			help:       "Number of canonical blocks disconnected by a reorganization.",
+			buckets:    []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		},
+		BlocksRejected: &Counter{
+			metricName: "node_blocks_rejected_total",
+			help:       "Blocks received from peers that were rejected.",
+		},
+		BlocksMined: &Counter{
+			metricName: "miner_blocks_mined_total",
+			help:       "Blocks found by the miner.",
+		},
+		MiningHashrate: &Gauge{
+			metricName: "miner_hashrate",
+			help:       "Hashes per second computed while mining the last block.",
+		},
+		MessagesTotal: &CounterVec{
+			metricName: "network_messages_total",
+			help:       "Messages exchanged with peers.",
+			labels:     []string{"direction", "type"},
+			values:     make(map[string]uint64),
+		},
+		BytesTotal: &CounterVec{
+			metricName: "network_bytes_total",
+			help:       "Bytes of messages exchanged with peers.",
+			labels:     []string{"direction", "type"},
+			values:     make(map[string]uint64),
+		},
+	}
+	for _, h := range []*Histogram{m.BlockValidationSeconds, m.ReorgDepth} {
+		h.counts = make([]uint64, len(h.buckets))
+	}
+	for _, metric := range []metric{m.BlockValidationSeconds, m.ReorgDepth, m.BlocksRejected, m.BlocksMined, m.MiningHashrate, m.MessagesTotal, m.BytesTotal} {
+		m.register(metric)
+	}
+	return m
+}
+
+// GaugeFunc registers a gauge whose value is computed by fn when the metrics are collected.
+func (m *Metrics) GaugeFunc(name, help string, fn func() float64) {
+	m.register(&gaugeFunc{metricName: name, help: help, fn: fn})
+}
+
+// observeMessage counts a message exchanged with a peer.
+func (m *Metrics) observeMessage(outbound bool, data []byte) {
+	direction := "in"
+	if outbound {
+		direction = "out"
+	}
+	messageType := "malformed"
+	var message block_chain.MainMessage
+	if err := proto.Unmarshal(data, &message); err == nil {
+		messageType = MessageType(&message)
+	}
+	m.MessagesTotal.Add(1, direction, messageType)
+	m.BytesTotal.Add(uint64(len(data)), direction, messageType)
+}
+
+// MessageType returns the name of the block or node message carried by message,
+// such as "block_response" or "welcome_request".
+func MessageType(message *block_chain.MainMessage) string {
+	outer := message.ProtoReflect()
+	field := outer.WhichOneof(outer.Descriptor().Oneofs().Get(0))
+	if field == nil {
+		return "unknown"
+	}
+	return innerMessageType(outer.Get(field).Message())
+}
+
+// innerMessageType returns the name of the message set in a block or node message.
+func innerMessageType(inner protoreflect.Message) string {
+	if inner.Descriptor().Oneofs().Len() == 0 {
+		return string(inner.Descriptor().Name())
+	}
+	field := inner.WhichOneof(inner.Descriptor().Oneofs().Get(0))
+	if field == nil {
+		return "unknown"
+	}
+	return string(field.Name())
+}
+
+// NewMetricsServer creates an HTTP server that serves m on /metrics at address.
+func NewMetricsServer(address string, m *Metrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	return &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+}
+
+// WithMetrics instruments the blockchain with m: chain height, head age, fork
+// count, block validation latency and reorganization depth.
+func WithMetrics(m *Metrics) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.metrics = m
+		m.GaugeFunc("blockchain_height", "Height of the canonical chain.", func() float64 {
+			return float64(bc.GetLatestBlock().Index)
+		})
+		m.GaugeFunc("blockchain_head_age_seconds", "Time since the timestamp of the head block.", func() float64 {
+			return bc.clock.Now().Sub(time.Unix(int64(bc.GetLatestBlock().Timestamp), 0)).Seconds()
+		})
+		m.GaugeFunc("blockchain_forks", "Tips of side branches kept besides the canonical chain.", func() float64 {
+			return float64(bc.forkCount())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// Names of the predefined networks.
+const (
+	NetworkDev     = "dev"
+	NetworkTestnet = "testnet"
+	NetworkPrivate = "private"
+)
+
+// DefaultNetwork is the network a node joins when none is selected.
+const DefaultNetwork = NetworkTestnet
+
+// ErrUnknownNetwork is returned when a network name has no profile.
+var ErrUnknownNetwork = errors.New("unknown network")
+
+// NetworkProfile bundles the settings every node of a network must agree on,
+// along with the defaults nodes use to find each other.
+type NetworkProfile struct {
+	Name string
+	// ChainID is part of every signed transaction, so that transactions cannot
+	// be replayed on another network. It also makes the genesis block unique.
+	ChainID string
+	// GenesisTimestamp is the timestamp of the genesis block.
+	GenesisTimestamp uint64
+	// Port is the port nodes listen on by default.
+	Port int
+	// SeedPeers are the addresses of nodes a new node introduces itself to.
+	SeedPeers []string
+	// Difficulty is the number of "0" characters a block hash starts with.
+	Difficulty int
+	// CheckpointInterval is the distance between automatic checkpoints, 0 disables them.
+	CheckpointInterval uint64
+	// Checkpoints are blocks every node of the network must have.
+	Checkpoints []Checkpoint
+	// AllowInstantSeal permits seal modes that skip proof of work.
+	AllowInstantSeal bool
+}
+
+// networkProfiles are the predefined networks by name.
+var networkProfiles = map[string]NetworkProfile{
+	// dev is a local chain with cheap blocks for development.
+	NetworkDev: {
+		Name:             NetworkDev,
+		ChainID:          "gob-dev",
+		GenesisTimestamp: 1735689600,
+		Port:             18080,
+		Difficulty:       1,
+		AllowInstantSeal: true,
+	},
+	// testnet is the shared test network.
+	NetworkTestnet: {
+		Name:               NetworkTestnet,
+		ChainID:            "gob-testnet-1",
+		GenesisTimestamp:   1735689600,
+		Port:               8080,
+		Difficulty:         DefaultDifficulty,
+		CheckpointInterval: DefaultCheckpointInterval,
+	},
+	// private is a template for isolated chains, which must pick their own chain ID.
+	NetworkPrivate: {
+		Name:               NetworkPrivate,
+		GenesisTimestamp:   1735689600,
+		Port:               28080,
+		Difficulty:         DefaultDifficulty,
+		CheckpointInterval: DefaultCheckpointInterval,
+	},
+}
+
+// LookupNetwork returns the profile of the named network.
+func LookupNetwork(name string) (NetworkProfile, error) {
+	profile, ok := networkProfiles[name]
+	if !ok {
+		return NetworkProfile{}, fmt.Errorf("%w %q, expected one of %v", ErrUnknownNetwork, name, Networks())
+	}
+	profile.SeedPeers = append([]string(nil), profile.SeedPeers...)
+	profile.Checkpoints = append([]Checkpoint(nil), profile.Checkpoints...)
+	return profile, nil
+}
+
+// Networks returns the names of the predefined networks, sorted.
+func Networks() []string {
+	names := make([]string, 0, len(networkProfiles))
+	for name := range networkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the profile describes a usable network.
+func (p NetworkProfile) Validate() error {
+	var errs []error
+	if p.ChainID == "" {
+		errs = append(errs, fmt.Errorf("network %s needs a chain ID", p.Name))
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", p.Port))
+	}
+	if p.Difficulty < 1 || p.Difficulty > 32 {
+		errs = append(errs, fmt.Errorf("difficulty must be between 1 and 32, got %d", p.Difficulty))
+	}
+	for _, peer := range p.SeedPeers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			errs = append(errs, fmt.Errorf("seed peer: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Genesis returns the genesis block of the network. It commits to the chain ID,
+// so that networks never share blocks.
+func (p NetworkProfile) Genesis() *types.Block {
+	return &types.Block{
+		Index:        0,
+		Timestamp:    p.GenesisTimestamp,
+		Transactions: make([]types.Transaction, 0),
+		PreviousHash: []byte(p.ChainID),
+		Data:         0,
+	}
+}
+
+// DefaultAddress returns the address a node of the network listens on by default.
+func (p NetworkProfile) DefaultAddress() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(p.Port))
+}
+
+// BlockchainOptions returns the options that apply the network's consensus
+// settings to a blockchain.
+func (p NetworkProfile) BlockchainOptions() []BlockchainOption {
+	return []BlockchainOption{
+		WithChainID(p.ChainID),
+		WithDifficulty(p.Difficulty),
+		WithCheckpointInterval(p.CheckpointInterval),
+		WithCheckpoints(p.Checkpoints...),
+	}
+}
+
+// NewBlockchain creates a blockchain of the network rooted at its genesis
+// block. opts are applied after the network's settings.
+func (p NetworkProfile) NewBlockchain(opts ...BlockchainOption) *Blockchain {
+	return NewBlockchainFromGenesis(p.Genesis(), append(p.BlockchainOptions(), opts...)...)
+}
+
+// NewNode creates a node of the network that knows the seed peers. An empty
+// address selects the default address. opts are applied after the network's settings.
+func (p NetworkProfile) NewNode(blockchain interfaces.BlockchainInterface, address string, opts ...NodeOption) *Node {
+	if address == "" {
+		address = p.DefaultAddress()
+	}
+	networkOpts := []NodeOption{WithNodeChainID(p.ChainID), WithPeers(p.SeedPeers...)}
+	return NewNode(blockchain, address, append(networkOpts, opts...)...)
+}
@@ -1,9 +1,13 @@
 package src
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"math/rand"
-	"net"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
@@ -18,24 +22,166 @@ type Message struct {
 }
 
 type Node struct {
-	blockchain       interfaces.BlockchainInterface
-	nodes            [][]byte
-	blockHandler     interfaces.BlockMessageHandlerInterface
-	nodeHandler      interfaces.NodeMessageHandlerInterface
-	tcpMessageSender interfaces.MessageSender
-	address          string
+	blockchain     interfaces.BlockchainInterface
+	nodes          [][]byte
+	nodesMux       sync.RWMutex
+	blockHandler   interfaces.BlockMessageHandlerInterface
+	nodeHandler    interfaces.NodeMessageHandlerInterface
+	messageSender  interfaces.MessageSender
+	network        interfaces.Network
+	address        string
+	peers          *PeerScores
+	clock          *NetworkClock
+	recorder       *Recorder
+	metrics        *Metrics
+	mempool        *Mempool
+	logger         *slog.Logger
+	miningInterval time.Duration
+	chainID        string
+	sealMode       SealMode
+	sealMux        sync.Mutex
+	finality       *FinalityGadget
+	pruneInterval  time.Duration
+	pruneOptions   PruneOptions
+	// coinbaseKey is the public key the coinbases of mined UTXO blocks pay to.
+	coinbaseKey []byte
+	// done is closed when the node is closed, which stops mining and pruning.
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewNode(blockchain interfaces.BlockchainInterface, address string) *Node {
-	messageSender, _ := NewTCPSender(address)
+// DefaultMiningInterval is the pause between mining two blocks.
+const DefaultMiningInterval = 10 * time.Second
+
+// NodeOption configures optional Node settings.
+type NodeOption func(*Node)
+
+// WithNetwork makes the node exchange messages over network instead of TCP.
+func WithNetwork(network interfaces.Network) NodeOption {
+	return func(n *Node) {
+		n.network = network
+	}
+}
+
+// WithNetworkClock makes the node feed the times reported by its peers into clock.
+// Pass the same clock to the blockchain with WithClock to validate and produce
+// blocks in network-adjusted time.
+func WithNetworkClock(clock *NetworkClock) NodeOption {
+	return func(n *Node) {
+		n.clock = clock
+	}
+}
+
+// WithRecorder makes the node record every message it receives and sends.
+func WithRecorder(recorder *Recorder) NodeOption {
+	return func(n *Node) {
+		n.recorder = recorder
+	}
+}
+
+// WithNodeMetrics instruments the node with m: peers, mempool size, orphan blocks,
+// rejected blocks, messages and bytes exchanged, and the miner's hashrate.
+func WithNodeMetrics(m *Metrics) NodeOption {
+	return func(n *Node) {
+		n.metrics = m
+	}
+}
+
+// WithNodeLogger makes the node and its message handlers write their log records
+// to logger. Every record carries the node's address.
+func WithNodeLogger(logger *slog.Logger) NodeOption {
+	return func(n *Node) {
+		n.logger = logger
+	}
+}
+
+// WithCoinbaseKey makes the node pay the subsidy of the UTXO blocks it mines to
+// the ed25519 public key lockingKey. Without one, the coinbase pays nothing.
+func WithCoinbaseKey(lockingKey []byte) NodeOption {
+	return func(n *Node) {
+		n.coinbaseKey = lockingKey
+	}
+}
+
+// WithMiningInterval sets the pause between mining two blocks.
+func WithMiningInterval(interval time.Duration) NodeOption {
+	return func(n *Node) {
+		n.miningInterval = interval
+	}
+}
+
+// WithNodeChainID sets the ID of the network whose signed transactions the node
+// accepts into its mempool. It should match the blockchain's WithChainID.
+func WithNodeChainID(chainID string) NodeOption {
+	return func(n *Node) {
+		n.chainID = chainID
+	}
+}
+
+// WithPeers adds the addresses of nodes to introduce the node to when it joins.
+func WithPeers(addresses ...string) NodeOption {
+	return func(n *Node) {
+		for _, address := range addresses {
+			n.AddNodes([]byte(address))
+		}
+	}
+}
+
+// WithFinality makes the node take part in finalizing checkpoints with gadget:
+// it exchanges votes and certificates with its peers, and votes itself if gadget
+// is authorized with a validator's key.
+func WithFinality(gadget *FinalityGadget) NodeOption {
+	return func(n *Node) {
+		n.finality = gadget
+	}
+}
+
+// WithTransport makes the node exchange messages over the connections of transport
+// instead of TCP.
+func WithTransport(transport interfaces.Transport) NodeOption {
+	return WithNetwork(NewStreamNetwork(transport))
+}
+
+func NewNode(blockchain interfaces.BlockchainInterface, address string, opts ...NodeOption) *Node {
 	node := &Node{
-		blockchain:       blockchain,
-		nodes:            make([][]byte, 0),
-		tcpMessageSender: messageSender,
-		address:          address,
+		blockchain:     blockchain,
+		nodes:          make([][]byte, 0),
+		network:        NewTCPNetwork(),
+		address:        address,
+		peers:          NewPeerScores(),
+		clock:          NewNetworkClock(SystemClock{}),
+		logger:         slog.Default(),
+		miningInterval: DefaultMiningInterval,
+		sealMode:       SealProofOfWork,
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(node)
+	}
+	node.mempool = NewMempool(DefaultMempoolSize, DefaultRules().TransactionRules, node.chainID)
+	node.logger = node.logger.With(LogKeyNode, address)
+	node.peers.logger = node.logger
+	node.clock.SetLogger(node.logger)
+	if network, ok := node.network.(interface{ SetLogger(*slog.Logger) }); ok {
+		network.SetLogger(node.logger)
+	}
+	node.peers.clock = localClock{node.clock}
+	node.messageSender = &broadcastSender{node: node}
+	blockHandler := NewBlockMessageHandler(blockchain, node.messageSender)
+	blockHandler.OnInvalidMessage(node.penalize)
+	blockHandler.SetLogger(node.logger)
+	blockHandler.SetClock(localClock{node.clock})
+	node.blockHandler = blockHandler
+	if node.metrics != nil {
+		node.registerMetrics(blockHandler)
 	}
-	node.blockHandler = NewBlockMessageHandler(blockchain, node.tcpMessageSender)
 	node.nodeHandler = NewNodeMessageHandler(node)
+	if pool, ok := blockchain.GetConsensusEngine().(interfaces.EvidencePool); ok {
+		// Evidence is detected while blocks are added, so it is relayed in the background.
+		pool.OnEvidence(func(evidence types.DoubleSignEvidence) {
+			go node.BroadcastEvidence(evidence)
+		})
+	}
 	return node
 }
 
@@ -43,96 +189,372 @@ func (n *Node) GetBlockchain() interfaces.BlockchainInterface {
 	return n.blockchain
 }
 
+// GetNodes returns a copy of the list of known node addresses.
 func (n *Node) GetNodes() [][]byte {
-	return n.nodes
+	n.nodesMux.RLock()
+	defer n.nodesMux.RUnlock()
+
+	nodes := make([][]byte, len(n.nodes))
+	copy(nodes, n.nodes)
+	return nodes
 }
 
 func (n *Node) GetAddress() string {
 	return n.address
 }
 
+// GetMessageSender returns a sender that delivers messages to all known nodes.
 func (n *Node) GetMessageSender() interfaces.MessageSender {
-	return n.tcpMessageSender
+	return n.messageSender
 }
 
-func (n *Node) Start() {
-	ln, err := net.Listen("tcp", n.address)
-	if err != nil {
-		log.Fatalf("Failed to listen on address %s: %v", n.address, err)
+// GetMempool returns the transactions waiting to be mined.
+func (n *Node) GetMempool() *Mempool {
+	return n.mempool
+}
+
+// SubmitTransaction queues a transaction for the next block the node mines. In
+// SealInstant mode the transaction is sealed into a block right away.
+func (n *Node) SubmitTransaction(tx types.Transaction) error {
+	if err := n.mempool.Add(tx); err != nil {
+		return err
+	}
+	if n.sealMode == SealInstant {
+		_, err := n.Seal()
+		return err
+	}
+	return nil
+}
+
+// registerMetrics registers the gauges computed from the node's state.
+func (n *Node) registerMetrics(blockHandler *BlockMessageHandlerImpl) {
+	blockHandler.SetMetrics(n.metrics)
+	n.metrics.GaugeFunc("node_peers", "Peers the node knows.", func() float64 {
+		return float64(len(n.GetNodes()))
+	})
+	n.metrics.GaugeFunc("node_mempool_transactions", "Transactions waiting to be mined.", func() float64 {
+		return float64(n.mempool.Size())
+	})
+}
+
+// GetClock returns the node's network-adjusted clock.
+func (n *Node) GetClock() *NetworkClock {
+	return n.clock
+}
+
+// GetPeerScores returns the misbehaviour scores of the node's peers.
+func (n *Node) GetPeerScores() *PeerScores {
+	return n.peers
+}
+
+// penalize records that the peer behind reply sent a message that failed with err.
+func (n *Node) penalize(reply interfaces.MessageSender, err error) {
+	if peer := peerIdentity(reply); peer != "" {
+		n.peers.Penalize(peer, err)
 	}
-	defer ln.Close()
+}
 
+// Join starts receiving messages and introduces the node to all known nodes.
+func (n *Node) Join() error {
+	if err := n.network.Listen(n.address, n); err != nil {
+		return err
+	}
 	n.BroadcastAddress([]byte(n.address))
-	go n.TryToFindNewBlock()
+	return nil
+}
 
-	go n.blockHandler.BroadcastLatestBlock(n.nodes) // Implement this method
+// Close stops receiving messages, mining and pruning, and closes the node's
+// connections.
+func (n *Node) Close() error {
+	n.closeOnce.Do(func() { close(n.done) })
+	return n.network.Close()
+}
 
-	for {
-		conn, err := ln.Accept()
-		if err != nil {
-			log.Fatalf("Failed to accept connection: %v", err)
-		}
-		go n.handleConnection(conn)
+// sleep pauses for d and reports whether the node is still open.
+func (n *Node) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-n.done:
+		return false
+	case <-timer.C:
+		return true
 	}
 }
 
-func (n *Node) handleConnection(conn net.Conn) {
-	defer conn.Close()
+func (n *Node) Start() {
+	if err := n.Join(); err != nil {
+		n.logger.Error("Failed to listen", errorAttr(err))
+		os.Exit(1)
+	}
 
-	buf := make([]byte, 4096)
-	nRead, err := conn.Read(buf)
-	if err != nil {
-		log.Println(err)
+	go n.blockHandler.BroadcastLatestBlock(n.GetNodes()) // Implement this method
+	if n.pruneInterval > 0 {
+		go n.pruneEvery(n.pruneInterval)
+	}
+
+	switch n.sealMode {
+	case SealInterval:
+		n.sealEvery(n.miningInterval)
+	case SealInstant, SealManual:
+		select {}
+	default:
+		n.TryToFindNewBlock()
+	}
+}
+
+// HandleMessage decodes a message received from a peer and dispatches it to the
+// block or node message handler. Replies go to reply. Messages from banned peers
+// are ignored.
+func (n *Node) HandleMessage(data []byte, reply interfaces.MessageSender) {
+	if peer := peerIdentity(reply); peer != "" && n.peers.Banned(peer) {
 		return
 	}
+	address := peerAddress(reply)
+	if n.recorder != nil || n.metrics != nil {
+		n.observe(address, false, data)
+		reply = &observedSender{sender: reply, node: n, peer: address}
+	}
 
-	var blockMessage block_chain.BlockMessage
-	err = proto.Unmarshal(buf[:nRead], &blockMessage)
-	if err == nil {
-		n.blockHandler.HandleBlockMessage(&blockMessage)
+	var message block_chain.MainMessage
+	if err := proto.Unmarshal(data, &message); err != nil {
+		n.logger.Warn("Failed to unmarshal message", peerAttr(reply), errorAttr(err))
+		n.penalize(reply, fmt.Errorf("%w: %v", ErrMalformedMessage, err))
 		return
 	}
 
-	var nodeMessage block_chain.NodeMessage
-	err = proto.Unmarshal(buf[:nRead], &nodeMessage)
-	if err == nil {
-		n.nodeHandler.HandleNodeMessage(&nodeMessage)
+	n.logger.Debug("Received message", peerAttr(reply), slog.String(LogKeyMessageType, MessageType(&message)))
+	switch msg := message.MessageType.(type) {
+	case *block_chain.MainMessage_BlockMessage:
+		n.blockHandler.HandleBlockMessageFrom(msg.BlockMessage, reply)
+	case *block_chain.MainMessage_NodeMessage:
+		n.nodeHandler.HandleNodeMessageFrom(msg.NodeMessage, reply)
+	default:
+		n.logger.Warn("Received message of unknown type", peerAttr(reply))
+	}
+	n.advanceFinality()
+}
+
+// PublishBlock adds a locally produced block to the blockchain and announces it to all known nodes.
+func (n *Node) PublishBlock(block *types.Block) error {
+	parent := n.blockchain.GetBlock(block.PreviousHash)
+	if parent == nil {
+		return ErrUnknownParent
+	}
+	if err := n.blockchain.AddBlock(parent, block); err != nil {
+		return err
+	}
+	n.blockHandler.AnnounceBlock(block)
+	n.advanceFinality()
+	return nil
+}
+
+// advanceFinality lets the finality gadget vote for the latest checkpoint and
+// sends the votes it cast to all known nodes. Votes are sent synchronously, so
+// they never race the blocks they are about.
+func (n *Node) advanceFinality() {
+	if n.finality == nil {
 		return
 	}
+	votes, err := n.finality.step()
+	if err != nil {
+		n.logger.Error("Failed to finalize checkpoint", errorAttr(err))
+	}
+	for _, vote := range votes {
+		n.BroadcastVote(vote)
+	}
+}
+
+// sendTo sends a message to the node at address. Failures are returned as a
+// *PeerError and count against the peer's score.
+func (n *Node) sendTo(address string, data []byte) error {
+	n.observe(address, true, data)
+	sender, err := n.network.Dial(n.address, address)
+	if err == nil {
+		err = sender.SendMsg(data)
+	}
+	if err != nil {
+		peerErr := newPeerError(address, err)
+		n.peers.Penalize(addressIdentity(address), peerErr)
+		return peerErr
+	}
+	return nil
+}
+
+// observe records and counts a message exchanged with peer.
+func (n *Node) observe(peer string, outbound bool, data []byte) {
+	if n.recorder != nil {
+		msg := CapturedMessage{Time: n.clock.Now(), Peer: peer, Outbound: outbound, Data: data}
+		if err := n.recorder.Record(msg); err != nil {
+			n.logger.Error("Failed to record message", errorAttr(err))
+		}
+	}
+	if n.metrics != nil {
+		n.metrics.observeMessage(outbound, data)
+	}
+}
+
+// observedSender records and counts the messages sent to a peer before passing them on.
+type observedSender struct {
+	sender interfaces.MessageSender
+	node   *Node
+	peer   string
+}
+
+func (s *observedSender) SendMsg(data []byte) error {
+	s.node.observe(s.peer, true, data)
+	return s.sender.SendMsg(data)
+}
+
+// PeerAddress returns the address of the peer messages are sent to.
+func (s *observedSender) PeerAddress() string {
+	return s.peer
+}
+
+// PeerID returns the node ID the peer authenticated with, if any.
+func (s *observedSender) PeerID() string {
+	if peer, ok := s.sender.(interface{ PeerID() string }); ok {
+		return peer.PeerID()
+	}
+	return ""
+}
+
+// broadcastSender sends every message to all known nodes that are not banned.
+type broadcastSender struct {
+	node *Node
+}
 
-	log.Println("Failed to unmarshal message")
+func (s *broadcastSender) SendMsg(data []byte) error {
+	var errs []error
+	for _, address := range s.node.GetNodes() {
+		if s.node.peers.Banned(addressIdentity(string(address))) {
+			continue
+		}
+		if err := s.node.sendTo(string(address), data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (n *Node) getRandomNodes(count int) [][]byte {
-	if count > len(n.nodes) {
-		count = len(n.nodes)
+	nodes := n.GetNodes()
+	if count > len(nodes) {
+		count = len(nodes)
 	}
 
-	rand.Shuffle(len(n.nodes), func(i, j int) {
-		n.nodes[i], n.nodes[j] = n.nodes[j], n.nodes[i]
+	rand.Shuffle(len(nodes), func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
 	})
 
-	return n.nodes[:count]
+	return nodes[:count]
 }
 
+// headPollInterval is how often the miner checks whether the head moved.
+const headPollInterval = 100 * time.Millisecond
+
+// TryToFindNewBlock mines blocks until the node is closed. Every block carries
+// the transactions waiting in the mempool after a coinbase on the UTXO ledger,
+// or else a fixed placeholder transaction, and is sealed by the blockchain's
+// consensus engine.
 func (n *Node) TryToFindNewBlock() {
+	engine := n.blockchain.GetConsensusEngine()
 	for {
-		transaction := []types.Transaction{
-			{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 10},
+		pending := n.mempool.Take(DefaultMaxBlockTransactions - 1)
+		first, ok := n.coinbase()
+		if !ok {
+			first = types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 10}
 		}
-		newBlock := n.blockchain.GenerateNewBlock(transaction)
-		nonce := uint64(0)
+		newBlock := n.blockchain.GenerateNewBlock(append([]types.Transaction{first}, pending...))
+		start := time.Now()
 
-		for {
-			newBlock.Data = nonce
-			parentBlock := n.blockchain.GetLatestBlock()
-			if err := n.blockchain.ValidateBlock(newBlock, parentBlock); err == nil {
-				break
+		// Sealing stops early if a block from a peer moves the head, as the
+		// block could no longer extend the canonical chain.
+		stop, release := n.watchHead(newBlock.PreviousHash)
+		sealed, err := engine.Seal(newBlock, stop)
+		release()
+		if _, ok := engine.(*ProofOfWork); ok && n.metrics != nil && err == nil {
+			n.metrics.MiningHashrate.Set(float64(sealed.Data+1) / time.Since(start).Seconds())
+		}
+
+		if err == nil {
+			err = n.PublishBlock(sealed)
+		}
+		var txErr *TransactionError
+		switch {
+		case err == nil:
+			if n.metrics != nil {
+				n.metrics.BlocksMined.Add(1)
+			}
+		case errors.As(err, &txErr):
+			n.logger.Warn("Dropping invalid pending transaction of mined block", blockAttr(newBlock), errorAttr(err))
+			n.requeue(pending, 1, err)
+		case errors.Is(err, ErrNoSignerKey):
+			n.logger.Warn("Not producing blocks without a signer key")
+			select {}
+		case errors.Is(err, ErrSealAborted), errors.Is(err, ErrRecentlySigned), errors.Is(err, ErrNotProposer):
+			// Another node produced the next block first, or it is another signer's turn.
+			n.logger.Debug("Skipped block", blockAttr(newBlock), errorAttr(err))
+			for _, tx := range pending {
+				n.mempool.Add(tx)
 			}
-			nonce++
+		default:
+			n.logger.Warn("Failed to mine block", blockAttr(newBlock), errorAttr(err))
+			for _, tx := range pending {
+				n.mempool.Add(tx)
+			}
+		}
+		if !n.sleep(n.miningInterval) {
+			return
 		}
+	}
+}
+
+// coinbase returns the coinbase of the next block if the blockchain is on the
+// UTXO ledger.
+func (n *Node) coinbase() (types.Transaction, bool) {
+	ledger, ok := n.blockchain.(interface{ LedgerModel() LedgerModel })
+	if !ok || ledger.LedgerModel() != LedgerUTXO {
+		return types.Transaction{}, false
+	}
+	return newCoinbase(n.blockchain.GetLatestBlock().Index+1, n.coinbaseKey), true
+}
 
-		n.blockchain.AddBlock(n.blockchain.GetRoot(), newBlock)
-		time.Sleep(10 * time.Second)
+// requeue puts the pending transactions of a block that failed with err back
+// into the mempool, except the one at fault. offset is the position of the first
+// pending transaction in the block.
+func (n *Node) requeue(pending []types.Transaction, offset int, err error) {
+	var txErr *TransactionError
+	faulty := -1
+	if errors.As(err, &txErr) {
+		faulty = txErr.Position - offset
+	}
+	for i, tx := range pending {
+		if i != faulty {
+			n.mempool.Add(tx)
+		}
 	}
 }
+
+// watchHead returns a channel that is closed once the head of the blockchain is
+// no longer the block with parentHash, and a function that stops watching.
+func (n *Node) watchHead(parentHash []byte) (<-chan struct{}, func()) {
+	moved := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(headPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !bytes.Equal(n.blockchain.GetLatestBlock().CalculateHash(), parentHash) {
+					close(moved)
+					return
+				}
+			}
+		}
+	}()
+	return moved, func() { close(done) }
+}
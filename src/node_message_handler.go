@@ -2,10 +2,14 @@ package src
 
 import (
 	"bytes"
-	"log"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
 	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
-	"google.golang.org/protobuf/proto"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
 )
 
 // NodeMessageHandlerImpl handles node-related messages.
@@ -18,95 +22,276 @@ func NewNodeMessageHandler(node *Node) *NodeMessageHandlerImpl {
 	return &NodeMessageHandlerImpl{node: node}
 }
 
-// HandleNodeMessage processes incoming node messages.
+// HandleNodeMessage processes incoming node messages, replying to all known nodes.
 func (h *NodeMessageHandlerImpl) HandleNodeMessage(msg *block_chain.NodeMessage) {
+	h.HandleNodeMessageFrom(msg, h.node.GetMessageSender())
+}
+
+// HandleNodeMessageFrom processes a node message received from a peer. Replies go to reply.
+func (h *NodeMessageHandlerImpl) HandleNodeMessageFrom(msg *block_chain.NodeMessage, reply interfaces.MessageSender) {
 	switch nodeMsg := msg.NodeMessageType.(type) {
 	case *block_chain.NodeMessage_WelcomeRequest:
-		h.node.handleWelcomeRequest(nodeMsg.WelcomeRequest.Message)
+		h.node.handleWelcomeRequest(nodeMsg.WelcomeRequest.GetMessage(), nodeMsg.WelcomeRequest.GetTimestamp(), reply)
 	case *block_chain.NodeMessage_WelcomeResponse:
-		h.node.handleWelcomeResponse(nodeMsg.WelcomeResponse.Message)
+		h.node.handleWelcomeResponse(nodeMsg.WelcomeResponse.GetMessage(), nodeMsg.WelcomeResponse.GetTimestamp(), reply)
+	case *block_chain.NodeMessage_DoubleSignEvidence:
+		h.node.handleDoubleSignEvidence(types.DoubleSignEvidenceFromProto(nodeMsg.DoubleSignEvidence), reply)
+	case *block_chain.NodeMessage_FinalityVote:
+		h.node.handleFinalityVote(types.VoteFromProto(nodeMsg.FinalityVote), reply)
+	case *block_chain.NodeMessage_FinalityCertificate:
+		h.node.handleFinalityCertificate(types.FinalityCertificateFromProto(nodeMsg.FinalityCertificate), reply)
+	case *block_chain.NodeMessage_FinalityCertificateRequest:
+		h.node.handleFinalityCertificateRequest(nodeMsg.FinalityCertificateRequest.GetHeight(), reply)
+	}
+}
+
+// handleWelcomeRequest registers the node that sent its address and time, replies
+// with the known nodes and asks for its latest block and finality certificate.
+func (n *Node) handleWelcomeRequest(address []byte, timestamp uint64, reply interfaces.MessageSender) {
+	if len(address) == 0 {
+		return
+	}
+	if n.AddNodes(address) {
+		n.logger.Info("New peer", slog.String(LogKeyPeer, string(address)))
+	}
+	n.addTimeSample(reply, timestamp)
+	n.SendAddressWelcomeResponse(reply)
+	n.requestLatestBlock(reply)
+	n.requestFinalityCertificate(reply)
+}
+
+// handleWelcomeResponse registers the nodes known to a peer, introduces the node to
+// the ones it did not know yet and asks the peer for its latest block and
+// finality certificate.
+func (n *Node) handleWelcomeResponse(nodes []byte, timestamp uint64, reply interfaces.MessageSender) {
+	n.addTimeSample(reply, timestamp)
+	for _, address := range bytes.Split(nodes, []byte(", ")) {
+		if len(address) > 0 && n.AddNodes(address) {
+			n.logger.Info("New peer", slog.String(LogKeyPeer, string(address)))
+			n.sendWelcomeRequest(string(address), []byte(n.address))
+		}
+	}
+	n.requestLatestBlock(reply)
+	n.requestFinalityCertificate(reply)
+}
+
+// handleDoubleSignEvidence adds evidence received from a peer to the evidence
+// pool of the consensus engine, which relays it if it is new. Peers sending
+// invalid evidence are penalized.
+func (n *Node) handleDoubleSignEvidence(evidence types.DoubleSignEvidence, reply interfaces.MessageSender) {
+	pool, ok := n.blockchain.GetConsensusEngine().(interfaces.EvidencePool)
+	if !ok {
+		return
+	}
+	if err := pool.ReportEvidence(evidence); err != nil && !errors.Is(err, ErrEvidenceKnown) {
+		n.logger.Warn("Rejected double-sign evidence", peerAttr(reply), errorAttr(err))
+		n.penalize(reply, err)
 	}
 }
 
-// handleWelcomeRequest processes a welcome request message.
-func (n *Node) handleWelcomeRequest(data []byte) {
-	welcomeRequest := &block_chain.WelcomeRequest{}
-	err := proto.Unmarshal(data, welcomeRequest)
+// BroadcastEvidence sends evidence of double signing to all known nodes.
+func (n *Node) BroadcastEvidence(evidence types.DoubleSignEvidence) {
+	n.logger.Warn("Validator signed conflicting blocks", slog.String("offender", hex.EncodeToString(evidence.Offender())), blockAttr(evidence.First))
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
+		NodeMessageType: &block_chain.NodeMessage_DoubleSignEvidence{DoubleSignEvidence: evidence.ToProto()},
+	})
 	if err != nil {
-		log.Println(err)
+		n.logger.Error("Failed to encode double-sign evidence", errorAttr(err))
 		return
 	}
-	n.nodes = append(n.nodes, welcomeRequest.Message)
-	n.SendAddressWelcomeResponse()
+	for _, node := range n.GetNodes() {
+		if err := n.sendTo(string(node), data); err != nil {
+			n.logger.Warn("Failed to send double-sign evidence", slog.String(LogKeyPeer, string(node)), errorAttr(err))
+		}
+	}
 }
 
-// handleWelcomeResponse processes a welcome response message.
-func (n *Node) handleWelcomeResponse(data []byte) {
-	welcomeResponse := &block_chain.WelcomeResponse{}
-	err := proto.Unmarshal(data, welcomeResponse)
+// handleFinalityVote counts a finality vote received from a peer. Peers sending
+// invalid votes are penalized.
+func (n *Node) handleFinalityVote(vote types.Vote, reply interfaces.MessageSender) {
+	if n.finality == nil {
+		return
+	}
+	err := n.finality.AddVote(vote)
+	switch {
+	case err == nil, errors.Is(err, ErrVoteKnown):
+	case errors.Is(err, ErrConflictingVote):
+		n.logger.Warn("Validator voted for conflicting checkpoints", slog.String("validator", hex.EncodeToString(vote.Validator)), errorAttr(err))
+	case errors.Is(err, ErrInvalidFinalityVote):
+		n.logger.Warn("Rejected finality vote", peerAttr(reply), errorAttr(err))
+		n.penalize(reply, err)
+	default:
+		n.logger.Error("Failed to finalize checkpoint", errorAttr(err))
+	}
+}
+
+// handleFinalityCertificate finalizes the checkpoint of a certificate received
+// from a peer. Peers sending invalid certificates are penalized.
+func (n *Node) handleFinalityCertificate(certificate *types.FinalityCertificate, reply interfaces.MessageSender) {
+	if n.finality == nil {
+		return
+	}
+	err := n.finality.AddCertificate(certificate)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidCertificate):
+		n.logger.Warn("Rejected finality certificate", peerAttr(reply), errorAttr(err))
+		n.penalize(reply, err)
+	default:
+		n.logger.Error("Failed to finalize checkpoint", errorAttr(err))
+	}
+}
+
+// handleFinalityCertificateRequest replies with the finality certificate at the
+// requested height, or the latest one for height 0, if the node has it.
+func (n *Node) handleFinalityCertificateRequest(height uint64, reply interfaces.MessageSender) {
+	if n.finality == nil {
+		return
+	}
+	certificate := n.finality.Certificate(height)
+	if certificate == nil {
+		return
+	}
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
+		NodeMessageType: &block_chain.NodeMessage_FinalityCertificate{FinalityCertificate: certificate.ToProto()},
+	})
 	if err != nil {
-		log.Println(err)
+		n.logger.Error("Failed to encode finality certificate", errorAttr(err))
 		return
 	}
-	n.AddNodes(welcomeResponse.Message)
+
+	if err := reply.SendMsg(data); err != nil {
+		n.logger.Warn("Failed to send finality certificate", peerAttr(reply), errorAttr(err))
+	}
 }
 
-// BroadcastAddress sends the node's address to all known nodes.
-func (n *Node) BroadcastAddress(address []byte) {
-	for _, node := range n.nodes {
-		welcomeRequest := &block_chain.WelcomeRequest{
-			Message: address,
-		}
+// requestFinalityCertificate asks reply for its latest finality certificate.
+func (n *Node) requestFinalityCertificate(reply interfaces.MessageSender) {
+	if n.finality == nil {
+		return
+	}
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
+		NodeMessageType: &block_chain.NodeMessage_FinalityCertificateRequest{
+			FinalityCertificateRequest: &block_chain.FinalityCertificateRequest{},
+		},
+	})
+	if err != nil {
+		n.logger.Error("Failed to encode finality certificate request", errorAttr(err))
+		return
+	}
 
-		nodeMessage := &block_chain.NodeMessage{
-			NodeMessageType: &block_chain.NodeMessage_WelcomeRequest{
-				WelcomeRequest: welcomeRequest,
-			},
-		}
+	if err := reply.SendMsg(data); err != nil {
+		n.logger.Warn("Failed to request finality certificate", peerAttr(reply), errorAttr(err))
+	}
+}
 
-		data, err := EncodeMessage(nodeMessage)
-		if err != nil {
-			log.Fatal(err)
+// BroadcastVote sends a finality vote to all known nodes.
+func (n *Node) BroadcastVote(vote types.Vote) {
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
+		NodeMessageType: &block_chain.NodeMessage_FinalityVote{FinalityVote: vote.ToProto()},
+	})
+	if err != nil {
+		n.logger.Error("Failed to encode finality vote", errorAttr(err))
+		return
+	}
+	for _, node := range n.GetNodes() {
+		if err := n.sendTo(string(node), data); err != nil {
+			n.logger.Warn("Failed to send finality vote", slog.String(LogKeyPeer, string(node)), errorAttr(err))
 		}
+	}
+}
 
-		err = n.GetMessageSender().SendMsg(data)
-		if err != nil {
-			log.Printf("Failed to send message to node at address %s: %v", node, err)
-		}
+// addTimeSample feeds the time reported by the peer behind reply into the
+// node's clock. Samples are kept by the host at the remote end of the
+// connection instead of the address the peer claims, so that a peer counts once
+// however many connections it opens or addresses it claims.
+func (n *Node) addTimeSample(reply interfaces.MessageSender, timestamp uint64) {
+	peer := remoteHost(reply)
+	if peer == "" || timestamp == 0 {
+		return
+	}
+	n.clock.AddSample(peer, time.Unix(int64(timestamp), 0))
+}
+
+// BroadcastAddress sends the node's address to all known nodes.
+func (n *Node) BroadcastAddress(address []byte) {
+	for _, node := range n.GetNodes() {
+		n.sendWelcomeRequest(string(node), address)
 	}
 }
 
-// SendAddressWelcomeResponse sends a welcome response with the node's address.
-func (n *Node) SendAddressWelcomeResponse() {
-	nodes := bytes.Join(n.nodes, []byte(", "))
+// sendWelcomeRequest sends address to the node at the given address.
+func (n *Node) sendWelcomeRequest(node string, address []byte) {
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
+		NodeMessageType: &block_chain.NodeMessage_WelcomeRequest{
+			WelcomeRequest: &block_chain.WelcomeRequest{Message: address, Timestamp: n.localTimestamp()},
+		},
+	})
+	if err != nil {
+		n.logger.Error("Failed to encode welcome request", errorAttr(err))
+		return
+	}
 
-	welcomeResponse := &block_chain.WelcomeResponse{
-		Message: nodes,
+	if err := n.sendTo(node, data); err != nil {
+		n.logger.Warn("Failed to send welcome request", slog.String(LogKeyPeer, node), errorAttr(err))
 	}
+}
+
+// SendAddressWelcomeResponse sends the known node addresses to reply.
+func (n *Node) SendAddressWelcomeResponse(reply interfaces.MessageSender) {
+	nodes := bytes.Join(n.GetNodes(), []byte(", "))
 
-	nodeMessage := &block_chain.NodeMessage{
+	data, err := EncodeNodeMessage(&block_chain.NodeMessage{
 		NodeMessageType: &block_chain.NodeMessage_WelcomeResponse{
-			WelcomeResponse: welcomeResponse,
+			WelcomeResponse: &block_chain.WelcomeResponse{Message: nodes, Timestamp: n.localTimestamp()},
 		},
+	})
+	if err != nil {
+		n.logger.Error("Failed to encode welcome response", errorAttr(err))
+		return
 	}
 
-	data, err := EncodeMessage(nodeMessage)
-	if err != nil {
-		log.Fatal(err)
+	if err := reply.SendMsg(data); err != nil {
+		n.logger.Warn("Failed to send welcome response", peerAttr(reply), errorAttr(err))
 	}
+}
+
+// localTimestamp returns the time of the node's local clock as reported to peers.
+func (n *Node) localTimestamp() uint64 {
+	return uint64(n.clock.LocalNow().Unix())
+}
 
-	err = n.GetMessageSender().SendMsg(data)
+// requestLatestBlock asks reply for its latest block.
+func (n *Node) requestLatestBlock(reply interfaces.MessageSender) {
+	data, err := EncodeBlockMessage(&block_chain.BlockMessage{
+		BlockMessageType: &block_chain.BlockMessage_GetLatestBlockRequest{
+			GetLatestBlockRequest: &block_chain.GetLatestBlockRequest{},
+		},
+	})
 	if err != nil {
-		log.Fatal(err)
+		n.logger.Error("Failed to encode latest block request", errorAttr(err))
+		return
+	}
+
+	if err := reply.SendMsg(data); err != nil {
+		n.logger.Warn("Failed to request latest block", peerAttr(reply), errorAttr(err))
 	}
 }
 
-// AddNodes adds a new node address to the list of known nodes.
-func (n *Node) AddNodes(address []byte) {
+// AddNodes adds a new node address to the list of known nodes. It reports whether
+// the address was new; the node's own address is never added.
+func (n *Node) AddNodes(address []byte) bool {
+	n.nodesMux.Lock()
+	defer n.nodesMux.Unlock()
+
+	if string(address) == n.address {
+		return false
+	}
 	for _, node := range n.nodes {
 		if bytes.Equal(node, address) {
-			return
+			return false
 		}
 	}
 	n.nodes = append(n.nodes, address)
+	return true
 }
@@ -0,0 +1,220 @@
+package src
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// BanScore is the misbehaviour score at which messages from a peer are ignored.
+const BanScore = 100
+
+// ScoreDecayInterval is the time it takes the misbehaviour score of a peer to
+// drop by one, so that occasional timeouts of an honest peer never add up to a ban.
+const ScoreDecayInterval = time.Minute
+
+// BanDuration is how long messages from a peer are ignored once its score
+// reaches BanScore. Its score starts over when the ban ends.
+const BanDuration = 24 * time.Hour
+
+// Penalty returns the misbehaviour score a peer earns for a message or exchange
+// that failed with err. Blocks that cannot be valid on any chain are punished hard,
+// blocks that may come from an honest peer on another fork or with a skewed clock
+// lightly, and the normal outcomes of syncing not at all.
+func Penalty(err error) int {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrBlockExists),
+		errors.Is(err, ErrUnknownParent),
+		errors.Is(err, ErrPeerUnreachable):
+		return 0
+	case errors.Is(err, ErrPeerTimeout):
+		return 5
+	case errors.Is(err, ErrTimestampTooFarInFuture),
+		errors.Is(err, ErrTimestampTooOld),
+		errors.Is(err, ErrBlockTooEarly):
+		return 10
+	case errors.Is(err, ErrBelowCheckpoint):
+		return 20
+	case errors.Is(err, ErrInvalidIndex),
+		errors.Is(err, ErrPrevHashMismatch),
+		errors.Is(err, ErrInsufficientWork),
+		errors.Is(err, ErrCheckpointConflict),
+		errors.Is(err, ErrMalformedMessage),
+		errors.Is(err, ErrMessageTooLarge),
+		errors.Is(err, ErrTooManyTransactions),
+		errors.Is(err, ErrBlockTooLarge),
+		errors.Is(err, types.ErrAmountOverflow),
+		errors.Is(err, ErrInvalidBlockSignature),
+		errors.Is(err, ErrUnauthorizedSigner),
+		errors.Is(err, ErrRecentlySigned),
+		errors.Is(err, ErrWrongTurn),
+		errors.Is(err, ErrWrongProposer),
+		errors.Is(err, ErrInvalidEvidence),
+		errors.Is(err, ErrInvalidFinalityVote),
+		errors.Is(err, ErrInvalidCertificate):
+		return BanScore
+	}
+	// Every transaction rule describes transactions no honest node relays.
+	var txErr *TransactionError
+	if errors.As(err, &txErr) {
+		return BanScore
+	}
+	return 0
+}
+
+// PeerScores keeps the misbehaviour score of every peer. Peers are identified
+// by the node ID they authenticated with over TLS, or else by the host at the
+// remote end of their connection. The address a peer announces is not used, so
+// that a banned peer stays banned when it reconnects under another address and
+// cannot get the peer whose address it announces banned.
+type PeerScores struct {
+	mux    sync.Mutex
+	scores map[string]*peerScore
+	clock  interfaces.Clock
+	logger *slog.Logger
+}
+
+// peerScore is the misbehaviour score of a peer as of updated.
+type peerScore struct {
+	score       int
+	updated     time.Time
+	bannedUntil time.Time
+}
+
+// NewPeerScores creates an empty PeerScores.
+func NewPeerScores() *PeerScores {
+	return &PeerScores{scores: make(map[string]*peerScore), clock: SystemClock{}, logger: slog.Default()}
+}
+
+// Penalize adds the penalty for err to the score of peer and reports whether the
+// peer is banned. A peer is banned for BanDuration once its score reaches BanScore.
+func (p *PeerScores) Penalize(peer string, err error) bool {
+	penalty := Penalty(err)
+	if penalty == 0 {
+		return p.Banned(peer)
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	now := p.clock.Now()
+	score := p.current(peer, now)
+	if score == nil {
+		score = &peerScore{updated: now}
+		p.scores[peer] = score
+	}
+	if now.Before(score.bannedUntil) {
+		return true
+	}
+	score.score += penalty
+	if score.score >= BanScore {
+		score.bannedUntil = now.Add(BanDuration)
+		p.logger.Warn("Banning peer", slog.String(LogKeyPeer, peer), slog.Duration("duration", BanDuration), errorAttr(err))
+		return true
+	}
+	return false
+}
+
+// Score returns the misbehaviour score of peer.
+func (p *PeerScores) Score(peer string) int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if score := p.current(peer, p.clock.Now()); score != nil {
+		return score.score
+	}
+	return 0
+}
+
+// Banned reports whether messages from peer are ignored.
+func (p *PeerScores) Banned(peer string) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	now := p.clock.Now()
+	score := p.current(peer, now)
+	return score != nil && now.Before(score.bannedUntil)
+}
+
+// current returns the score of peer at now, after ending an expired ban and
+// letting the score decay since it was last updated. Scores that dropped to zero
+// are forgotten and nil is returned. The caller must hold mux.
+func (p *PeerScores) current(peer string, now time.Time) *peerScore {
+	score := p.scores[peer]
+	if score == nil {
+		return nil
+	}
+	if !score.bannedUntil.IsZero() {
+		if now.Before(score.bannedUntil) {
+			return score
+		}
+		score.score, score.updated, score.bannedUntil = 0, score.bannedUntil, time.Time{}
+	}
+	if decay := int(now.Sub(score.updated) / ScoreDecayInterval); decay > 0 {
+		score.score -= decay
+		score.updated = score.updated.Add(time.Duration(decay) * ScoreDecayInterval)
+	}
+	if score.score <= 0 {
+		delete(p.scores, peer)
+		return nil
+	}
+	return score
+}
+
+// peerAddress returns the address the peer behind a reply sender listens on, or
+// "" if the sender does not identify its peer.
+func peerAddress(sender interface{}) string {
+	if peer, ok := sender.(interface{ PeerAddress() string }); ok {
+		return peer.PeerAddress()
+	}
+	return ""
+}
+
+// peerIdentity returns the key the score of the peer behind a reply sender is
+// kept under: the node ID it authenticated with, or else its remote host.
+func peerIdentity(sender interface{}) string {
+	if peer, ok := sender.(interface{ PeerID() string }); ok {
+		if id := peer.PeerID(); id != "" {
+			return id
+		}
+	}
+	return remoteHost(sender)
+}
+
+// addressIdentity returns the key the score of the peer at address is kept
+// under: the node ID the address is pinned to, or else the host of the address.
+func addressIdentity(address string) string {
+	id, address := splitPeerID(address)
+	if id != "" {
+		return id
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// remoteHost returns the host at the remote end of the connection behind a
+// reply sender, the whole remote address if it has no port, or the peer
+// address if the sender has no network connection.
+func remoteHost(sender interface{}) string {
+	if observed, ok := sender.(*observedSender); ok {
+		sender = observed.sender
+	}
+	if conn, ok := sender.(interface{ RemoteAddr() net.Addr }); ok {
+		address := conn.RemoteAddr().String()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			return host
+		}
+		if address != "" {
+			return address
+		}
+	}
+	return peerAddress(sender)
+}
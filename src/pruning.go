@@ -0,0 +1,159 @@
+package src
+
+import (
+	"bytes"
+	"log/slog"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// Approximate in-memory sizes used to estimate the memory reclaimed by pruning.
+const (
+	blockNodeOverhead   = 160
+	transactionOverhead = 56
+	undoOverhead        = 64
+	utxoOverhead        = 48
+)
+
+// PruneOptions selects what Prune removes. The UTXO undo data of canonical
+// blocks up to the latest canonical checkpoint is always removed, since those
+// blocks are never disconnected.
+type PruneOptions struct {
+	// ForkDepth removes side branches that forked off the canonical chain more
+	// than ForkDepth blocks below the head. Zero keeps them.
+	ForkDepth uint64
+	// BelowCheckpoint removes side branches that forked off below the latest
+	// checkpoint; fork choice can never select them again.
+	BelowCheckpoint bool
+	// DropTransactions drops the transactions of canonical blocks below the
+	// latest checkpoint, keeping only their headers.
+	DropTransactions bool
+}
+
+// WithArchive makes the blockchain keep the transactions of every block, even
+// when pruned with DropTransactions, so that the whole chain can be exported.
+// Blockchains backed by a chain file need it, since the file is rewritten from
+// the genesis block.
+func WithArchive() BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.archive = true
+	}
+}
+
+// PruneReport describes what Prune removed.
+type PruneReport struct {
+	BranchesRemoved     int
+	BlocksRemoved       int
+	TransactionsDropped int
+	// UndoDropped counts the blocks whose UTXO undo data was dropped.
+	UndoDropped int
+	// SnapshotsDropped counts the consensus snapshots dropped below the latest
+	// canonical checkpoint.
+	SnapshotsDropped int
+	// BytesReclaimed is an estimate of the memory released.
+	BytesReclaimed uint64
+}
+
+// Prune removes stale side branches, drops the UTXO undo data and consensus
+// snapshots of canonical blocks that can no longer be disconnected and
+// optionally drops the transactions of finalized canonical blocks.
+func (bc *Blockchain) Prune(opts PruneOptions) PruneReport {
+	bc.mux.Lock()
+	defer bc.mux.Unlock()
+
+	var report PruneReport
+	headHeight := bc.head.Block.Index
+	latest := bc.checkpoints.latest(headHeight)
+	canonical := bc.canonicalCheckpoint()
+
+	// The snapshot of the checkpoint is taken before transactions are dropped.
+	if engine, ok := bc.engine.(interfaces.PrunableEngine); ok && canonical != nil {
+		report.SnapshotsDropped = engine.PruneSnapshots(bc.nodes[string(canonical.Hash)])
+	}
+
+	for node := bc.head; node != nil; node = node.Parent {
+		forkHeight := node.Block.Index
+		staleFork := opts.ForkDepth > 0 && headHeight-forkHeight > opts.ForkDepth
+		finalizedFork := opts.BelowCheckpoint && latest != nil && forkHeight < latest.Height
+
+		if staleFork || finalizedFork {
+			kept := make([]*types.BlockNode, 0, len(node.Childs))
+			for _, child := range node.Childs {
+				if bytes.Equal(bc.index.hashAt(child.Block.Index), child.Hash) {
+					kept = append(kept, child)
+					continue
+				}
+				report.BranchesRemoved++
+				bc.removeBranch(child, &report)
+			}
+			node.Childs = kept
+		}
+
+		if opts.DropTransactions && !bc.archive && latest != nil && forkHeight < latest.Height && !node.Pruned {
+			report.TransactionsDropped += len(node.Block.Transactions)
+			report.BytesReclaimed += transactionsSize(node.Block.Transactions)
+			header := *node.Block
+			header.Transactions = nil
+			node.Block = &header
+			node.Pruned = true
+		}
+
+		if bc.utxos != nil && canonical != nil && forkHeight <= canonical.Height {
+			if size := bc.utxos.prune(node); size > 0 {
+				report.UndoDropped++
+				report.BytesReclaimed += size
+			}
+		}
+	}
+
+	return report
+}
+
+// removeBranch deletes the subtree rooted at node from the block lookup.
+func (bc *Blockchain) removeBranch(node *types.BlockNode, report *PruneReport) {
+	for _, child := range node.Childs {
+		bc.removeBranch(child, report)
+	}
+	delete(bc.nodes, string(node.Hash))
+	report.BlocksRemoved++
+	report.BytesReclaimed += blockNodeOverhead + uint64(len(node.Hash)+len(node.Block.PreviousHash)) + transactionsSize(node.Block.Transactions)
+}
+
+// transactionsSize estimates the memory used by a slice of transactions.
+func transactionsSize(transactions []types.Transaction) uint64 {
+	var size uint64
+	for _, tx := range transactions {
+		size += transactionOverhead + uint64(len(tx.Sender)+len(tx.Receiver))
+	}
+	return size
+}
+
+// WithPruning makes the node prune its blockchain with opts every interval once
+// it starts.
+func WithPruning(interval time.Duration, opts PruneOptions) NodeOption {
+	return func(n *Node) {
+		n.pruneInterval = interval
+		n.pruneOptions = opts
+	}
+}
+
+// Prune prunes the node's blockchain with the pruning options of the node. It
+// does nothing if the blockchain is not a *Blockchain.
+func (n *Node) Prune() PruneReport {
+	bc, ok := n.blockchain.(*Blockchain)
+	if !ok {
+		return PruneReport{}
+	}
+	report := bc.Prune(n.pruneOptions)
+	n.logger.Debug("Pruned blockchain", slog.Int("blocks", report.BlocksRemoved), slog.Int("transactions", report.TransactionsDropped), slog.Uint64("bytes", report.BytesReclaimed))
+	return report
+}
+
+// pruneEvery prunes the node's blockchain every interval until the node is closed.
+func (n *Node) pruneEvery(interval time.Duration) {
+	for n.sleep(interval) {
+		n.Prune()
+	}
+}
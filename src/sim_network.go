@@ -0,0 +1,282 @@
+package src
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+)
+
+// DefaultSimLatency is the delivery delay of a SimNetwork without jitter.
+const DefaultSimLatency = 10 * time.Millisecond
+
+// SimStats counts the messages handled by a SimNetwork.
+type SimStats struct {
+	Sent      int
+	Delivered int
+	Dropped   int
+}
+
+// SimNetwork is an in-process network for running many nodes in one test.
+// Messages are queued with a simulated delivery time and delivered one at a time
+// by Step, Run or RunFor on the caller's goroutine, so a run with the same seed
+// and the same inputs always delivers the same messages in the same order.
+type SimNetwork struct {
+	mux      sync.Mutex
+	rand     *rand.Rand
+	now      time.Duration
+	seq      uint64
+	queue    simQueue
+	handlers map[string]interfaces.MessageHandler
+	latency  time.Duration
+	jitter   time.Duration
+	lossRate float64
+	groups   map[string]int
+	stats    SimStats
+}
+
+// NewSimNetwork creates a SimNetwork whose randomness is derived from seed.
+func NewSimNetwork(seed int64) *SimNetwork {
+	return &SimNetwork{
+		rand:     rand.New(rand.NewSource(seed)),
+		handlers: make(map[string]interfaces.MessageHandler),
+		latency:  DefaultSimLatency,
+		groups:   make(map[string]int),
+	}
+}
+
+// SetLatency sets the delivery delay of messages. Each message is delayed by an
+// additional random duration up to jitter, which reorders messages sent close together.
+func (s *SimNetwork) SetLatency(latency, jitter time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.latency = latency
+	s.jitter = jitter
+}
+
+// SetLossRate sets the probability, between 0 and 1, that a message is silently dropped.
+func (s *SimNetwork) SetLossRate(rate float64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.lossRate = rate
+}
+
+// Partition splits the network: nodes in different groups cannot exchange messages.
+// Nodes not listed in any group form one more group together. Messages already in
+// flight between separated nodes are dropped.
+func (s *SimNetwork) Partition(groups ...[]string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, address := range group {
+			s.groups[address] = i + 1
+		}
+	}
+}
+
+// Heal removes all partitions.
+func (s *SimNetwork) Heal() {
+	s.Partition()
+}
+
+// Now returns the simulated time elapsed since the network was created.
+func (s *SimNetwork) Now() time.Duration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.now
+}
+
+// Stats returns the message counters of the network.
+func (s *SimNetwork) Stats() SimStats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.stats
+}
+
+// Pending returns the number of messages in flight.
+func (s *SimNetwork) Pending() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return len(s.queue)
+}
+
+// Listen registers handler for the messages sent to address.
+func (s *SimNetwork) Listen(address string, handler interfaces.MessageHandler) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, ok := s.handlers[address]; ok {
+		return fmt.Errorf("address %s is already in use", address)
+	}
+	s.handlers[address] = handler
+	return nil
+}
+
+// Dial returns a sender for messages from one node to another.
+func (s *SimNetwork) Dial(from, to string) (interfaces.MessageSender, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, ok := s.handlers[to]; !ok {
+		return nil, fmt.Errorf("no node is listening on %s", to)
+	}
+	return &simSender{network: s, from: from, to: to}, nil
+}
+
+// Close unregisters all nodes and drops the messages in flight.
+func (s *SimNetwork) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.stats.Dropped += len(s.queue)
+	s.queue = nil
+	s.handlers = make(map[string]interfaces.MessageHandler)
+	return nil
+}
+
+// Step delivers the next message. It reports whether a message was in flight.
+func (s *SimNetwork) Step() bool {
+	s.mux.Lock()
+	if len(s.queue) == 0 {
+		s.mux.Unlock()
+		return false
+	}
+	msg := heap.Pop(&s.queue).(*simMessage)
+	s.now = msg.deliverAt
+
+	handler, ok := s.handlers[msg.to]
+	if !ok || !s.reachable(msg.from, msg.to) {
+		s.stats.Dropped++
+		s.mux.Unlock()
+		return true
+	}
+	s.stats.Delivered++
+	s.mux.Unlock()
+
+	handler.HandleMessage(msg.data, &simSender{network: s, from: msg.to, to: msg.from})
+	return true
+}
+
+// Run delivers messages until none are in flight and returns how many were processed.
+func (s *SimNetwork) Run() int {
+	steps := 0
+	for s.Step() {
+		steps++
+	}
+	return steps
+}
+
+// RunFor delivers the messages due within d of simulated time, then advances the
+// clock by d. It returns how many messages were processed.
+func (s *SimNetwork) RunFor(d time.Duration) int {
+	s.mux.Lock()
+	deadline := s.now + d
+	s.mux.Unlock()
+
+	steps := 0
+	for {
+		s.mux.Lock()
+		due := len(s.queue) > 0 && s.queue[0].deliverAt <= deadline
+		s.mux.Unlock()
+		if !due {
+			break
+		}
+		s.Step()
+		steps++
+	}
+
+	s.mux.Lock()
+	s.now = deadline
+	s.mux.Unlock()
+	return steps
+}
+
+// send queues a message, unless it is lost or crosses a partition.
+func (s *SimNetwork) send(from, to string, data []byte) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.stats.Sent++
+	if !s.reachable(from, to) || s.rand.Float64() < s.lossRate {
+		s.stats.Dropped++
+		return
+	}
+
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(s.jitter) + 1))
+	}
+	s.seq++
+	heap.Push(&s.queue, &simMessage{
+		deliverAt: s.now + delay,
+		seq:       s.seq,
+		from:      from,
+		to:        to,
+		data:      append([]byte(nil), data...),
+	})
+}
+
+// reachable reports whether two nodes are on the same side of every partition.
+// The caller must hold s.mux.
+func (s *SimNetwork) reachable(from, to string) bool {
+	return s.groups[from] == s.groups[to]
+}
+
+// simSender sends messages from one node of a SimNetwork to another.
+type simSender struct {
+	network *SimNetwork
+	from    string
+	to      string
+}
+
+func (s *simSender) SendMsg(data []byte) error {
+	s.network.send(s.from, s.to, data)
+	return nil
+}
+
+// PeerAddress returns the address of the node messages are sent to.
+func (s *simSender) PeerAddress() string {
+	return s.to
+}
+
+// simMessage is a message in flight on a SimNetwork.
+type simMessage struct {
+	deliverAt time.Duration
+	seq       uint64
+	from      string
+	to        string
+	data      []byte
+}
+
+// simQueue orders messages by delivery time, then by the order they were sent.
+type simQueue []*simMessage
+
+func (q simQueue) Len() int { return len(q) }
+
+func (q simQueue) Less(i, j int) bool {
+	if q[i].deliverAt != q[j].deliverAt {
+		return q[i].deliverAt < q[j].deliverAt
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q simQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *simQueue) Push(x any) { *q = append(*q, x.(*simMessage)) }
+
+func (q *simQueue) Pop() any {
+	old := *q
+	msg := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return msg
+}
@@ -0,0 +1,205 @@
+package src
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+)
+
+// handshakeTimeout bounds the time an accepted connection may take to announce
+// the address of the dialer.
+const handshakeTimeout = 10 * time.Second
+
+// streamNetwork exchanges length-prefixed messages over the connections of a
+// Transport. A connection is used in both directions, so replies travel back over
+// the connection the request arrived on, and one outgoing connection per peer is
+// kept open and reused. The first frame on a connection is the address the
+// dialer listens on, which tells the accepting node where to reach it. The
+// address is not authenticated, so peers are not scored by it.
+type streamNetwork struct {
+	transport interfaces.Transport
+	mux       sync.Mutex
+	listener  net.Listener
+	handler   interfaces.MessageHandler
+	peers     map[string]*TcpMessageSender
+	conns     map[net.Conn]struct{}
+	closed    bool
+	logger    *slog.Logger
+}
+
+// NewTCPNetwork creates a network that exchanges messages over TCP.
+func NewTCPNetwork() interfaces.Network {
+	return NewStreamNetwork(NewTCPTransport())
+}
+
+// NewStreamNetwork creates a network that exchanges messages over the connections
+// of transport. Closing the network does not close the transport, which may be
+// shared with other nodes.
+func NewStreamNetwork(transport interfaces.Transport) interfaces.Network {
+	return &streamNetwork{
+		transport: transport,
+		peers:     make(map[string]*TcpMessageSender),
+		conns:     make(map[net.Conn]struct{}),
+		logger:    slog.Default(),
+	}
+}
+
+// SetLogger makes the network write its log records to logger. It has to be
+// called before Listen.
+func (s *streamNetwork) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// Listen accepts connections on address and delivers their messages to handler.
+func (s *streamNetwork) Listen(address string, handler interfaces.MessageHandler) error {
+	ln, err := s.transport.Listen(address)
+	if err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	s.listener = ln
+	s.handler = handler
+	s.mux.Unlock()
+
+	go s.accept(ln)
+	return nil
+}
+
+// Dial returns a sender for the connection to the node at to, connecting and
+// announcing from if needed. Connecting happens without holding the lock, so a
+// slow peer does not hold up messages to the others. If two calls connect to the
+// same node at once, the connection of the first one to finish is kept.
+func (s *streamNetwork) Dial(from, to string) (interfaces.MessageSender, error) {
+	s.mux.Lock()
+	closed, sender := s.closed, s.peers[to]
+	s.mux.Unlock()
+	if closed {
+		return nil, net.ErrClosed
+	}
+	if sender != nil {
+		return sender, nil
+	}
+
+	conn, err := s.transport.Dial(to)
+	if err != nil {
+		return nil, err
+	}
+	sender = newStreamSender(conn, to)
+	if err := sender.SendMsg([]byte(from)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.closed {
+		conn.Close()
+		return nil, net.ErrClosed
+	}
+	if existing, ok := s.peers[to]; ok {
+		conn.Close()
+		return existing, nil
+	}
+	s.peers[to] = sender
+	s.conns[conn] = struct{}{}
+	go s.serve(conn, sender)
+	return sender, nil
+}
+
+// Close stops listening and closes all connections.
+func (s *streamNetwork) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+// accept serves every incoming connection until the listener is closed.
+func (s *streamNetwork) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Warn("Failed to accept connection", errorAttr(err))
+			}
+			return
+		}
+
+		s.mux.Lock()
+		s.conns[conn] = struct{}{}
+		s.mux.Unlock()
+		go s.serve(conn, nil)
+	}
+}
+
+// serve reads messages from conn until it is closed. Replies are sent back over
+// conn. Accepted connections, whose reply is nil, start with the address of the
+// dialer, which has to arrive within handshakeTimeout.
+func (s *streamNetwork) serve(conn net.Conn, reply *TcpMessageSender) {
+	defer s.drop(conn)
+
+	reader := bufio.NewReader(conn)
+	if reply == nil {
+		conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+		hello, err := readFrame(reader)
+		if err != nil {
+			s.logger.Warn("Failed to read the address of the peer", slog.String(LogKeyPeer, conn.RemoteAddr().String()), errorAttr(err))
+			return
+		}
+		conn.SetReadDeadline(time.Time{})
+		reply = newStreamSender(conn, string(hello))
+	}
+	for {
+		data, err := readFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Warn("Failed to read message", slog.String(LogKeyPeer, conn.RemoteAddr().String()), errorAttr(err))
+			}
+			return
+		}
+
+		s.mux.Lock()
+		handler := s.handler
+		s.mux.Unlock()
+		if handler != nil {
+			handler.HandleMessage(data, reply)
+		}
+	}
+}
+
+// newStreamSender creates a sender for conn to the node listening on peer,
+// identified by its node ID if the connection is authenticated.
+func newStreamSender(conn net.Conn, peer string) *TcpMessageSender {
+	id, _ := PeerID(conn)
+	return &TcpMessageSender{conn: conn, peer: peer, id: id}
+}
+
+// drop closes conn and forgets it, so that the next Dial reconnects.
+func (s *streamNetwork) drop(conn net.Conn) {
+	conn.Close()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.conns, conn)
+	for address, sender := range s.peers {
+		if sender.conn == conn {
+			delete(s.peers, address)
+		}
+	}
+}
@@ -2,10 +2,21 @@ package src
 
 import (
 	"net"
+	"sync"
+	"time"
 )
 
+// sendTimeout limits how long writing a message to a peer may block.
+const sendTimeout = 10 * time.Second
+
+// TcpMessageSender sends length-prefixed messages over a connection.
 type TcpMessageSender struct {
 	conn net.Conn
+	mux  sync.Mutex
+	// peer identifies the node at the remote end, if known.
+	peer string
+	// id is the node ID the remote end authenticated with, if any.
+	id string
 }
 
 func NewTCPSender(address string) (*TcpMessageSender, error) {
@@ -13,12 +24,37 @@ func NewTCPSender(address string) (*TcpMessageSender, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &TcpMessageSender{conn: conn}, nil
+	return &TcpMessageSender{conn: conn, peer: address}, nil
 }
 
 func (s *TcpMessageSender) SendMsg(data []byte) error {
-	_, err := s.conn.Write(data)
-	return err
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(sendTimeout)); err != nil {
+		return err
+	}
+	return writeFrame(s.conn, data)
+}
+
+// PeerAddress returns the address the node at the remote end listens on, or
+// the address of the remote end of the connection if the node did not tell it.
+func (s *TcpMessageSender) PeerAddress() string {
+	if s.peer != "" {
+		return s.peer
+	}
+	return s.conn.RemoteAddr().String()
+}
+
+// PeerID returns the node ID the node at the remote end authenticated with, or
+// "" if the connection is not authenticated.
+func (s *TcpMessageSender) PeerID() string {
+	return s.id
+}
+
+// RemoteAddr returns the address of the remote end of the connection.
+func (s *TcpMessageSender) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
 }
 
 func (s *TcpMessageSender) Close() {
@@ -0,0 +1,238 @@
+package src
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+)
+
+// ErrPeerNotAllowed is returned when a peer's node ID is not on the allow-list.
+var ErrPeerNotAllowed = errors.New("peer is not allowed")
+
+// ErrUnexpectedPeer is returned when a dialed peer presents another node ID than
+// the one its address is pinned to.
+var ErrUnexpectedPeer = errors.New("peer has an unexpected node ID")
+
+// tlsHandshakeTimeout bounds the time spent authenticating a peer.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// tlsTransport encrypts and mutually authenticates the connections of another
+// transport. Both sides present a self-signed certificate for their ed25519
+// identity, so a peer is identified by the node ID of its key rather than by a
+// certificate authority. An address of the form <node ID>@<address> pins the
+// node ID the dialed peer must present.
+type tlsTransport struct {
+	inner       interfaces.Transport
+	certificate tls.Certificate
+	allowed     map[string]bool
+}
+
+// NewTLSTransport wraps inner with mutual TLS using identity. If allowedPeers is
+// not empty, only peers with one of the listed node IDs can connect or be connected to.
+func NewTLSTransport(inner interfaces.Transport, identity *Identity, allowedPeers []string) (interfaces.Transport, error) {
+	certificate, err := selfSignedCertificate(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(allowedPeers))
+	for _, id := range allowedPeers {
+		allowed[id] = true
+	}
+	return &tlsTransport{inner: inner, certificate: certificate, allowed: allowed}, nil
+}
+
+// Listen accepts connections on address. The handshake happens on the first read
+// or write, and has to complete within tlsHandshakeTimeout.
+func (t *tlsTransport) Listen(address string) (net.Listener, error) {
+	ln, err := t.inner.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	config := t.config("")
+	config.ClientAuth = tls.RequireAnyClientCert
+	return &tlsListener{Listener: ln, config: config}, nil
+}
+
+// Dial connects to address and completes the handshake, so an unauthorized or
+// unexpected peer is reported here.
+func (t *tlsTransport) Dial(address string) (net.Conn, error) {
+	id, address := splitPeerID(address)
+	conn, err := t.inner.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	config := t.config(id)
+	// The peer certificate is verified against its node ID in verifyPeer instead.
+	config.InsecureSkipVerify = true
+	tlsConn := tls.Client(conn, config)
+
+	tlsConn.SetDeadline(time.Now().Add(tlsHandshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// Close closes the underlying transport.
+func (t *tlsTransport) Close() error {
+	return t.inner.Close()
+}
+
+// config returns the TLS settings shared by both sides of a connection. A
+// non-empty expected is the node ID the peer must present.
+func (t *tlsTransport) config(expected string) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{t.certificate},
+		MinVersion:   tls.VersionTLS13,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return t.verifyPeer(rawCerts, expected)
+		},
+	}
+}
+
+// verifyPeer checks that the peer presented a valid self-signed ed25519
+// certificate and that its node ID is the expected one, if any, and allowed.
+func (t *tlsTransport) verifyPeer(rawCerts [][]byte, expected string) error {
+	if len(rawCerts) != 1 {
+		return errors.New("peer must present exactly one certificate")
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return err
+	}
+	publicKey, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return errors.New("peer certificate does not use an ed25519 key")
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return fmt.Errorf("peer certificate is not self-signed: %w", err)
+	}
+
+	id := NodeID(publicKey)
+	if expected != "" && id != expected {
+		return fmt.Errorf("%w: expected %s, got %s", ErrUnexpectedPeer, expected, id)
+	}
+	if len(t.allowed) > 0 && !t.allowed[id] {
+		return fmt.Errorf("%w: %s", ErrPeerNotAllowed, id)
+	}
+	return nil
+}
+
+// tlsListener accepts the server side of TLS connections.
+type tlsListener struct {
+	net.Listener
+	config *tls.Config
+}
+
+// Accept waits for the next connection. Its handshake happens on the first read
+// or write.
+func (l *tlsListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &serverConn{Conn: tls.Server(conn, l.config)}, nil
+}
+
+// serverConn is an accepted TLS connection whose handshake is aborted after
+// tlsHandshakeTimeout, so that a peer that never completes it does not hold the
+// connection open. The deadlines of the connection are left to its user.
+type serverConn struct {
+	*tls.Conn
+	once sync.Once
+	err  error
+}
+
+// Handshake runs the handshake unless it already ran.
+func (c *serverConn) Handshake() error {
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+		defer cancel()
+		c.err = c.Conn.HandshakeContext(ctx)
+	})
+	return c.err
+}
+
+func (c *serverConn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *serverConn) Write(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+// splitPeerID splits an address of the form <node ID>@<address> into the node ID
+// and the address. Addresses without a node ID are returned as they are.
+func splitPeerID(address string) (id, rest string) {
+	if id, rest, ok := strings.Cut(address, "@"); ok {
+		return id, rest
+	}
+	return "", address
+}
+
+// PeerID returns the node ID of the peer on the other end of a TLS connection.
+func PeerID(conn net.Conn) (string, error) {
+	tlsConn, ok := conn.(interface {
+		Handshake() error
+		ConnectionState() tls.ConnectionState
+	})
+	if !ok {
+		return "", errors.New("connection is not authenticated")
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", errors.New("peer did not present a certificate")
+	}
+	publicKey, ok := certs[0].PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return "", errors.New("peer certificate does not use an ed25519 key")
+	}
+	return NodeID(publicKey), nil
+}
+
+// selfSignedCertificate creates a certificate for identity signed by its own key.
+func selfSignedCertificate(identity *Identity) (tls.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: identity.ID()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, identity.PublicKey(), identity.privateKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: identity.privateKey}, nil
+}
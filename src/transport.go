@@ -0,0 +1,60 @@
+package src
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+)
+
+// dialTimeout bounds the time spent connecting to a peer.
+const dialTimeout = 5 * time.Second
+
+// netTransport opens connections with the standard library for one network type.
+type netTransport struct {
+	network   string
+	mux       sync.Mutex
+	listeners []net.Listener
+}
+
+// NewTCPTransport creates a transport over TCP. Addresses are host:port pairs.
+func NewTCPTransport() interfaces.Transport {
+	return &netTransport{network: "tcp"}
+}
+
+// NewUnixTransport creates a transport over Unix domain sockets. Addresses are socket file paths.
+func NewUnixTransport() interfaces.Transport {
+	return &netTransport{network: "unix"}
+}
+
+func (t *netTransport) Listen(address string) (net.Listener, error) {
+	ln, err := net.Listen(t.network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mux.Lock()
+	t.listeners = append(t.listeners, ln)
+	t.mux.Unlock()
+	return ln, nil
+}
+
+func (t *netTransport) Dial(address string) (net.Conn, error) {
+	return net.DialTimeout(t.network, address, dialTimeout)
+}
+
+func (t *netTransport) Close() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	var errs []error
+	for _, ln := range t.listeners {
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	t.listeners = nil
+	return errors.Join(errs...)
+}
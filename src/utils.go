@@ -1,11 +1,18 @@
 package src
 
 import (
+	"bufio"
+	"encoding/binary"
 	"fmt"
+	"io"
 
+	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
 	"google.golang.org/protobuf/proto"
 )
 
+// MaxMessageSize limits the size of a single message on a peer connection.
+const MaxMessageSize = 4 << 20
+
 // EncodeMessage encodes a protobuf message into a byte slice.
 func EncodeMessage(message interface{}) ([]byte, error) {
 	protoMessage, ok := message.(proto.Message)
@@ -18,3 +25,42 @@ func EncodeMessage(message interface{}) ([]byte, error) {
 	}
 	return data, nil
 }
+
+// EncodeBlockMessage wraps a block message in a MainMessage and encodes it.
+func EncodeBlockMessage(message *block_chain.BlockMessage) ([]byte, error) {
+	return EncodeMessage(&block_chain.MainMessage{
+		MessageType: &block_chain.MainMessage_BlockMessage{BlockMessage: message},
+	})
+}
+
+// EncodeNodeMessage wraps a node message in a MainMessage and encodes it.
+func EncodeNodeMessage(message *block_chain.NodeMessage) ([]byte, error) {
+	return EncodeMessage(&block_chain.MainMessage{
+		MessageType: &block_chain.MainMessage_NodeMessage{NodeMessage: message},
+	})
+}
+
+// writeFrame writes a length-prefixed message to w.
+func writeFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(frame, uint64(len(data)))
+	n += copy(frame[n:], data)
+	_, err := w.Write(frame[:n])
+	return err
+}
+
+// readFrame reads a length-prefixed message from r.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > MaxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
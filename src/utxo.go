@@ -0,0 +1,463 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// LedgerModel selects how the blockchain accounts for funds.
+type LedgerModel string
+
+// Supported ledger models.
+const (
+	// LedgerAccount moves funds with transfers between addresses.
+	LedgerAccount LedgerModel = "account"
+	// LedgerUTXO moves funds with UTXO transactions that spend the unspent
+	// outputs of earlier ones, and keeps the set of unspent outputs.
+	LedgerUTXO LedgerModel = "utxo"
+)
+
+// BlockSubsidy is the amount of new coins the coinbase of a UTXO block may
+// create on top of the fees of the block.
+const BlockSubsidy = 50 * types.UnitsPerCoin
+
+// Errors returned when a block breaks the rules of its ledger model.
+var (
+	ErrWrongLedgerModel    = errors.New("transaction does not belong to the ledger model")
+	ErrDoubleSpend         = errors.New("transaction spends a missing or already spent output")
+	ErrInvalidOutput       = errors.New("transaction output is invalid")
+	ErrOutputsExceedInputs = errors.New("transaction outputs exceed its inputs")
+	ErrInvalidCoinbase     = errors.New("coinbase is invalid")
+)
+
+// Validate checks that the model is supported.
+func (m LedgerModel) Validate() error {
+	switch m {
+	case LedgerAccount, LedgerUTXO, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown ledger model %q", m)
+	}
+}
+
+// WithLedgerModel selects the ledger model of the blockchain. The account model
+// is the default.
+func WithLedgerModel(model LedgerModel) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.ledger = model
+	}
+}
+
+// LedgerModel returns the ledger model of the blockchain.
+func (bc *Blockchain) LedgerModel() LedgerModel {
+	if bc.ledger == "" {
+		return LedgerAccount
+	}
+	return bc.ledger
+}
+
+// GetUnspentOutputs returns the unspent outputs of the canonical chain locked to
+// the key of address, oldest first. It returns nothing on the account ledger.
+func (bc *Blockchain) GetUnspentOutputs(address []byte) []types.UTXO {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.utxos == nil {
+		return nil
+	}
+	var utxos []types.UTXO
+	for key := range bc.utxos.byAddress[string(address)] {
+		utxos = append(utxos, bc.utxos.outputs[key])
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].Height != utxos[j].Height {
+			return utxos[i].Height < utxos[j].Height
+		}
+		return utxos[i].OutPoint.String() < utxos[j].OutPoint.String()
+	})
+	return utxos
+}
+
+// GetUnspentOutput returns the unspent output of the canonical chain at
+// outPoint, or nil if it does not exist or is spent.
+func (bc *Blockchain) GetUnspentOutput(outPoint types.OutPoint) *types.UTXO {
+	bc.mux.RLock()
+	defer bc.mux.RUnlock()
+
+	if bc.utxos == nil {
+		return nil
+	}
+	utxo, ok := bc.utxos.outputs[outPoint.String()]
+	if !ok {
+		return nil
+	}
+	return &utxo
+}
+
+// utxoStore holds unspent outputs by the string form of their outpoint.
+type utxoStore interface {
+	get(key string) (types.UTXO, bool)
+	put(utxo types.UTXO)
+	remove(key string)
+}
+
+// blockUndo holds what disconnecting a block needs: the outputs it spent and
+// the outputs it created. It does not depend on the transactions of the block,
+// which pruning may drop.
+type blockUndo struct {
+	spent   []types.UTXO
+	created []string
+}
+
+// utxoSet is the set of unspent outputs of the canonical chain. Like the chain
+// index it is updated only through connect and disconnect.
+type utxoSet struct {
+	outputs   map[string]types.UTXO
+	byAddress map[string]map[string]struct{}
+	undo      map[string]blockUndo
+}
+
+// newUTXOSet creates an empty utxoSet.
+func newUTXOSet() *utxoSet {
+	return &utxoSet{
+		outputs:   make(map[string]types.UTXO),
+		byAddress: make(map[string]map[string]struct{}),
+		undo:      make(map[string]blockUndo),
+	}
+}
+
+// get returns the unspent output at key.
+func (s *utxoSet) get(key string) (types.UTXO, bool) {
+	utxo, ok := s.outputs[key]
+	return utxo, ok
+}
+
+// put adds an unspent output.
+func (s *utxoSet) put(utxo types.UTXO) {
+	key := utxo.OutPoint.String()
+	s.outputs[key] = utxo
+	address := wallet.AddressFromPublicKey(utxo.Output.LockingKey)
+	if s.byAddress[address] == nil {
+		s.byAddress[address] = make(map[string]struct{})
+	}
+	s.byAddress[address][key] = struct{}{}
+}
+
+// remove deletes the unspent output at key.
+func (s *utxoSet) remove(key string) {
+	utxo, ok := s.outputs[key]
+	if !ok {
+		return
+	}
+	delete(s.outputs, key)
+	address := wallet.AddressFromPublicKey(utxo.Output.LockingKey)
+	delete(s.byAddress[address], key)
+	if len(s.byAddress[address]) == 0 {
+		delete(s.byAddress, address)
+	}
+}
+
+// connect applies a block that became part of the canonical chain and keeps
+// its undo data.
+func (s *utxoSet) connect(node *types.BlockNode) {
+	s.undo[string(node.Hash)] = connectOutputs(s, node.Block)
+}
+
+// disconnect reverts a block that is no longer part of the canonical chain.
+// Blocks must be disconnected from the tip downwards.
+func (s *utxoSet) disconnect(node *types.BlockNode) {
+	disconnectOutputs(s, s.undo[string(node.Hash)])
+	delete(s.undo, string(node.Hash))
+}
+
+// connectOutputs spends the inputs and creates the outputs of the UTXO
+// transactions of an already validated block in store.
+func connectOutputs(store utxoStore, block *types.Block) blockUndo {
+	var undo blockUndo
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if tx.Type != types.UTXOTransaction {
+			continue
+		}
+		for _, input := range tx.Inputs {
+			key := input.PreviousOutput.String()
+			if utxo, ok := store.get(key); ok {
+				undo.spent = append(undo.spent, utxo)
+				store.remove(key)
+			}
+		}
+		for _, utxo := range createdOutputs(tx, block.Index) {
+			store.put(utxo)
+			undo.created = append(undo.created, utxo.OutPoint.String())
+		}
+	}
+	return undo
+}
+
+// disconnectOutputs reverts connectOutputs in store.
+func disconnectOutputs(store utxoStore, undo blockUndo) {
+	for _, key := range undo.created {
+		store.remove(key)
+	}
+	for _, utxo := range undo.spent {
+		store.put(utxo)
+	}
+}
+
+// prune drops the undo data of node, which can no longer be disconnected, and
+// returns an estimate of the memory released.
+func (s *utxoSet) prune(node *types.BlockNode) uint64 {
+	undo, ok := s.undo[string(node.Hash)]
+	if !ok {
+		return 0
+	}
+	delete(s.undo, string(node.Hash))
+	size := uint64(undoOverhead)
+	for _, utxo := range undo.spent {
+		size += utxoOverhead + uint64(len(utxo.OutPoint.TxHash)+len(utxo.Output.LockingKey))
+	}
+	for _, key := range undo.created {
+		size += uint64(len(key))
+	}
+	return size
+}
+
+// newCoinbase returns the coinbase of the block at height paying the
+// BlockSubsidy to lockingKey, or nothing if lockingKey is empty. The height in
+// Amount makes the coinbase of every block unique.
+func newCoinbase(height uint64, lockingKey []byte) types.Transaction {
+	tx := types.Transaction{Type: types.UTXOTransaction, Amount: height}
+	if len(lockingKey) > 0 {
+		tx.Outputs = []types.TxOutput{{Amount: BlockSubsidy, LockingKey: lockingKey}}
+	}
+	return tx
+}
+
+// createdOutputs returns the outputs tx creates in the block at height.
+func createdOutputs(tx *types.Transaction, height uint64) []types.UTXO {
+	utxos := make([]types.UTXO, len(tx.Outputs))
+	hash := tx.CalculateHash()
+	for i, output := range tx.Outputs {
+		utxos[i] = types.UTXO{OutPoint: types.OutPoint{TxHash: hash, Index: uint32(i)}, Output: output, Height: height}
+	}
+	return utxos
+}
+
+// utxoView is the UTXO set of a chain that ends at a block other than the head:
+// the canonical set with the outputs the chain adds and removes on top of it.
+// A view of a chain that forks off below blocks whose undo data was pruned is
+// incomplete and spends nothing.
+type utxoView struct {
+	base       *utxoSet
+	added      map[string]types.UTXO
+	removed    map[string]bool
+	incomplete bool
+}
+
+// get returns the unspent output at key.
+func (v *utxoView) get(key string) (types.UTXO, bool) {
+	if utxo, ok := v.added[key]; ok {
+		return utxo, true
+	}
+	if v.removed[key] {
+		return types.UTXO{}, false
+	}
+	return v.base.get(key)
+}
+
+// put adds an unspent output.
+func (v *utxoView) put(utxo types.UTXO) {
+	key := utxo.OutPoint.String()
+	delete(v.removed, key)
+	v.added[key] = utxo
+}
+
+// remove deletes the unspent output at key.
+func (v *utxoView) remove(key string) {
+	delete(v.added, key)
+	v.removed[key] = true
+}
+
+// spend validates the UTXO transactions of block against the view and applies
+// them. Inputs must spend unspent outputs with a signature of the key they are
+// locked to, and outputs may not exceed inputs. Only the first transaction may
+// be a coinbase, which may create the BlockSubsidy and the fees of the block.
+func (v *utxoView) spend(block *types.Block, chainID string) error {
+	if v.incomplete {
+		return fmt.Errorf("%w: the undo data of the fork point was pruned", ErrBelowCheckpoint)
+	}
+	var fees uint64
+	var coinbase *types.Transaction
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if tx.Type != types.UTXOTransaction {
+			continue
+		}
+		if tx.IsCoinbase() {
+			if i != 0 {
+				return &TransactionError{Position: i, Err: fmt.Errorf("%w: only the first transaction may be a coinbase", ErrInvalidCoinbase)}
+			}
+			if tx.Amount != block.Index {
+				return &TransactionError{Position: i, Err: fmt.Errorf("%w: height %d instead of %d", ErrInvalidCoinbase, tx.Amount, block.Index)}
+			}
+			coinbase = tx
+			continue
+		}
+		fee, err := v.spendInputs(tx, block.Index, chainID)
+		if err != nil {
+			return &TransactionError{Position: i, Err: err}
+		}
+		if fees, err = types.AddAmounts(fees, fee); err != nil {
+			return &TransactionError{Position: i, Err: err}
+		}
+	}
+	if coinbase == nil {
+		return nil
+	}
+	// The outputs of the coinbase can only be spent in later blocks.
+	created, err := outputsTotal(coinbase)
+	if err != nil {
+		return &TransactionError{Position: 0, Err: err}
+	}
+	if limit, err := types.AddAmounts(BlockSubsidy, fees); err == nil && created > limit {
+		return &TransactionError{Position: 0, Err: fmt.Errorf("%w: creates %d > %d", ErrInvalidCoinbase, created, limit)}
+	}
+	for _, utxo := range createdOutputs(coinbase, block.Index) {
+		v.put(utxo)
+	}
+	return nil
+}
+
+// spendInputs spends the inputs of tx, creates its outputs and returns its fee.
+func (v *utxoView) spendInputs(tx *types.Transaction, height uint64, chainID string) (uint64, error) {
+	signingHash := tx.SigningHash(chainID)
+	var spent uint64
+	for j, input := range tx.Inputs {
+		key := input.PreviousOutput.String()
+		utxo, ok := v.get(key)
+		if !ok {
+			return 0, fmt.Errorf("%w: input %d spends %s", ErrDoubleSpend, j, key)
+		}
+		if !wallet.Verify(utxo.Output.LockingKey, signingHash, input.Signature) {
+			return 0, fmt.Errorf("%w: input %d is not signed by the key of %s", ErrInvalidSignature, j, key)
+		}
+		var err error
+		if spent, err = types.AddAmounts(spent, utxo.Output.Amount); err != nil {
+			return 0, err
+		}
+		v.remove(key)
+	}
+	created, err := outputsTotal(tx)
+	if err != nil {
+		return 0, err
+	}
+	if created > spent {
+		return 0, fmt.Errorf("%w: %d > %d", ErrOutputsExceedInputs, created, spent)
+	}
+	for _, utxo := range createdOutputs(tx, height) {
+		v.put(utxo)
+	}
+	return spent - created, nil
+}
+
+// outputsTotal returns the sum of the outputs of tx.
+func outputsTotal(tx *types.Transaction) (uint64, error) {
+	var total uint64
+	for _, output := range tx.Outputs {
+		var err error
+		if total, err = types.AddAmounts(total, output.Amount); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// UTXOTransactionRule rejects UTXO transactions without outputs, with outputs
+// that are empty, not locked to an ed25519 public key or sum past 64 bits, and
+// with inputs that spend the same output twice.
+func UTXOTransactionRule(ctx *ValidationContext, tx *types.Transaction) error {
+	if tx.Type != types.UTXOTransaction {
+		return nil
+	}
+	if len(tx.Outputs) == 0 {
+		return fmt.Errorf("%w: transaction has no outputs", ErrInvalidOutput)
+	}
+	for i, output := range tx.Outputs {
+		if output.Amount == 0 {
+			return fmt.Errorf("%w: output %d", ErrZeroAmount, i)
+		}
+		if len(output.LockingKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("%w: output %d is not locked to a %d-byte public key", ErrInvalidOutput, i, ed25519.PublicKeySize)
+		}
+	}
+	if _, err := outputsTotal(tx); err != nil {
+		return err
+	}
+	spent := make(map[string]bool, len(tx.Inputs))
+	for i, input := range tx.Inputs {
+		key := input.PreviousOutput.String()
+		if spent[key] {
+			return fmt.Errorf("%w: input %d spends %s again", ErrDoubleSpend, i, key)
+		}
+		spent[key] = true
+	}
+	return nil
+}
+
+// checkLedger checks that the transactions of block belong to the ledger model
+// and, on the UTXO ledger, spends them in ctx's view of the unspent outputs.
+// The caller must hold bc.mux.
+func (bc *Blockchain) checkLedger(ctx *ValidationContext, block *types.Block) error {
+	model := bc.LedgerModel()
+	for i := range block.Transactions {
+		switch kind := block.Transactions[i].Type; {
+		case kind == types.UTXOTransaction && model != LedgerUTXO:
+			return &TransactionError{Position: i, Err: fmt.Errorf("%w: UTXO transaction on the %s ledger", ErrWrongLedgerModel, model)}
+		case kind == types.TransferTransaction && model != LedgerAccount:
+			return &TransactionError{Position: i, Err: fmt.Errorf("%w: transfer on the %s ledger", ErrWrongLedgerModel, model)}
+		}
+	}
+	if ctx.utxos == nil {
+		return nil
+	}
+	return ctx.utxos.spend(block, ctx.ChainID)
+}
+
+// utxoView returns the view of the unspent outputs of the chain ending at
+// parent, or nil if the blockchain keeps no UTXO set or parent is not in the
+// tree. The caller must hold bc.mux.
+func (bc *Blockchain) utxoView(parent *types.BlockNode) *utxoView {
+	if bc.utxos == nil || parent.Hash == nil {
+		return nil
+	}
+	view := &utxoView{base: bc.utxos, added: make(map[string]types.UTXO), removed: make(map[string]bool)}
+
+	// Walk back from parent to the canonical chain, then rewind the canonical
+	// chain to the fork point and replay the branch.
+	var branch []*types.BlockNode
+	fork := parent
+	for !bytes.Equal(bc.index.hashAt(fork.Block.Index), fork.Hash) {
+		branch = append(branch, fork)
+		if fork = fork.Parent; fork == nil {
+			return nil
+		}
+	}
+	for node := bc.head; node.Block.Index > fork.Block.Index; node = node.Parent {
+		undo, ok := bc.utxos.undo[string(node.Hash)]
+		if !ok {
+			view.incomplete = true
+			return view
+		}
+		disconnectOutputs(view, undo)
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		connectOutputs(view, branch[i].Block)
+	}
+	return view
+}
@@ -0,0 +1,254 @@
+package src
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultMaxBlockTransactions is the default limit on transactions per block.
+	DefaultMaxBlockTransactions = 1000
+	// DefaultMaxBlockSize is the default limit on the encoded size of a block, in bytes.
+	DefaultMaxBlockSize = 1 << 20
+	// MaxFutureBlockTime is how far ahead of the local clock a block timestamp may be.
+	MaxFutureBlockTime = 2 * time.Hour
+	// MedianTimeSpan is the number of past blocks whose median timestamp a new block must exceed.
+	MedianTimeSpan = 11
+)
+
+// Errors returned by the validation rules.
+var (
+	ErrZeroAmount              = errors.New("transaction amount is zero")
+	ErrSelfTransfer            = errors.New("transaction sender and receiver are the same")
+	ErrTooManyTransactions     = errors.New("block has too many transactions")
+	ErrBlockTooLarge           = errors.New("block is too large")
+	ErrDuplicateTransaction    = errors.New("block contains a duplicate transaction")
+	ErrTimestampTooFarInFuture = errors.New("block timestamp is too far in the future")
+	ErrTimestampTooOld         = errors.New("block timestamp is not after the median of past blocks")
+	ErrInvalidSignature        = errors.New("transaction signature is invalid")
+	ErrInvalidReceiver         = errors.New("transaction receiver is not a valid address")
+)
+
+// TransactionError reports which transaction of a block broke a rule.
+type TransactionError struct {
+	Position int
+	Err      error
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("transaction %d: %v", e.Position, e.Err)
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationContext describes the chain a block is validated against.
+type ValidationContext struct {
+	// Parent is the block the validated block extends.
+	Parent *types.Block
+	// PastTimestamps holds the timestamps of the parent and its ancestors, newest
+	// first, up to MedianTimeSpan blocks.
+	PastTimestamps []uint64
+	// Now is the local time the block is validated at.
+	Now time.Time
+	// ChainID identifies the network transactions must be signed for.
+	ChainID string
+
+	// parentNode is the node of Parent in the block tree, or a detached node if
+	// Parent is not in the tree yet.
+	parentNode *types.BlockNode
+	// utxos holds the unspent outputs of the chain ending at Parent on the UTXO
+	// ledger. Validating a block spends its transactions in it, so that it holds
+	// the unspent outputs of the chain ending at the block afterwards.
+	utxos *utxoView
+}
+
+// MedianTimePast returns the median of PastTimestamps.
+func (ctx *ValidationContext) MedianTimePast() uint64 {
+	if len(ctx.PastTimestamps) == 0 {
+		return 0
+	}
+	sorted := append([]uint64(nil), ctx.PastTimestamps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// extend returns the context for validating a child of block, where block is a
+// child of ctx.Parent that was validated with ctx.
+func (ctx *ValidationContext) extend(block *types.Block) *ValidationContext {
+	timestamps := append([]uint64{block.Timestamp}, ctx.PastTimestamps...)
+	if len(timestamps) > MedianTimeSpan {
+		timestamps = timestamps[:MedianTimeSpan]
+	}
+	node := &types.BlockNode{Block: block, Parent: ctx.parentNode, Hash: block.CalculateHash()}
+	return &ValidationContext{Parent: block, PastTimestamps: timestamps, Now: ctx.Now, ChainID: ctx.ChainID, parentNode: node, utxos: ctx.utxos}
+}
+
+// BlockRule checks a block as a whole.
+type BlockRule func(ctx *ValidationContext, block *types.Block) error
+
+// TransactionRule checks a single transaction of a block.
+type TransactionRule func(ctx *ValidationContext, tx *types.Transaction) error
+
+// RuleSet is the validation pipeline a block goes through in addition to the
+// consensus checks of ValidateBlock. Block rules run first, in order, then every
+// transaction rule runs on every transaction; the first failure is returned.
+type RuleSet struct {
+	BlockRules       []BlockRule
+	TransactionRules []TransactionRule
+}
+
+// DefaultRules returns the rules applied when no other RuleSet is configured.
+func DefaultRules() *RuleSet {
+	return &RuleSet{
+		BlockRules: []BlockRule{
+			MaxTransactionsRule(DefaultMaxBlockTransactions),
+			MaxBlockSizeRule(DefaultMaxBlockSize),
+			AmountsDoNotOverflowRule,
+			UniqueTransactionsRule,
+			TimestampNotInFutureRule(MaxFutureBlockTime),
+			TimestampAfterMedianRule,
+		},
+		TransactionRules: []TransactionRule{
+			NonZeroAmountRule,
+			DistinctPartiesRule,
+			SignatureRule,
+			UTXOTransactionRule,
+		},
+	}
+}
+
+// Validate runs all rules on block.
+func (rs *RuleSet) Validate(ctx *ValidationContext, block *types.Block) error {
+	for _, rule := range rs.BlockRules {
+		if err := rule(ctx, block); err != nil {
+			return err
+		}
+	}
+	for i := range block.Transactions {
+		for _, rule := range rs.TransactionRules {
+			if err := rule(ctx, &block.Transactions[i]); err != nil {
+				return &TransactionError{Position: i, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// WithRules replaces the default validation rules.
+func WithRules(rules *RuleSet) BlockchainOption {
+	return func(bc *Blockchain) {
+		bc.rules = rules
+	}
+}
+
+// MaxTransactionsRule rejects blocks with more than limit transactions.
+func MaxTransactionsRule(limit int) BlockRule {
+	return func(ctx *ValidationContext, block *types.Block) error {
+		if len(block.Transactions) > limit {
+			return fmt.Errorf("%w: %d > %d", ErrTooManyTransactions, len(block.Transactions), limit)
+		}
+		return nil
+	}
+}
+
+// MaxBlockSizeRule rejects blocks whose encoded size exceeds limit bytes.
+func MaxBlockSizeRule(limit int) BlockRule {
+	return func(ctx *ValidationContext, block *types.Block) error {
+		if size := proto.Size(block.ToProto()); size > limit {
+			return fmt.Errorf("%w: %d > %d bytes", ErrBlockTooLarge, size, limit)
+		}
+		return nil
+	}
+}
+
+// AmountsDoNotOverflowRule rejects blocks whose transaction amounts sum past 64 bits.
+func AmountsDoNotOverflowRule(ctx *ValidationContext, block *types.Block) error {
+	_, err := types.TotalAmount(block.Transactions)
+	return err
+}
+
+// UniqueTransactionsRule rejects blocks that contain the same transaction twice.
+// Transactions carry no nonce yet, so identical transfers in different blocks are
+// legitimate and only duplicates within a block are detected.
+func UniqueTransactionsRule(ctx *ValidationContext, block *types.Block) error {
+	seen := make(map[string]int, len(block.Transactions))
+	for i := range block.Transactions {
+		hash := string(block.Transactions[i].CalculateHash())
+		if first, ok := seen[hash]; ok {
+			return &TransactionError{Position: i, Err: fmt.Errorf("%w of transaction %d", ErrDuplicateTransaction, first)}
+		}
+		seen[hash] = i
+	}
+	return nil
+}
+
+// TimestampNotInFutureRule rejects blocks timestamped more than maxDrift after ctx.Now.
+func TimestampNotInFutureRule(maxDrift time.Duration) BlockRule {
+	return func(ctx *ValidationContext, block *types.Block) error {
+		if limit := ctx.Now.Add(maxDrift).Unix(); int64(block.Timestamp) > limit {
+			return fmt.Errorf("%w: %d > %d", ErrTimestampTooFarInFuture, block.Timestamp, limit)
+		}
+		return nil
+	}
+}
+
+// TimestampAfterMedianRule rejects blocks not timestamped after the median of
+// the past MedianTimeSpan blocks, so timestamps keep moving forward even if a
+// few blocks lie about the time.
+func TimestampAfterMedianRule(ctx *ValidationContext, block *types.Block) error {
+	if median := ctx.MedianTimePast(); block.Timestamp <= median {
+		return fmt.Errorf("%w: %d <= %d", ErrTimestampTooOld, block.Timestamp, median)
+	}
+	return nil
+}
+
+// NonZeroAmountRule rejects transfers that transfer nothing.
+func NonZeroAmountRule(ctx *ValidationContext, tx *types.Transaction) error {
+	if tx.Type == types.TransferTransaction && tx.Amount == 0 {
+		return ErrZeroAmount
+	}
+	return nil
+}
+
+// DistinctPartiesRule rejects transactions whose sender is also the receiver.
+// UTXO transactions have neither.
+func DistinctPartiesRule(ctx *ValidationContext, tx *types.Transaction) error {
+	if tx.Type != types.UTXOTransaction && bytes.Equal(tx.Sender, tx.Receiver) {
+		return ErrSelfTransfer
+	}
+	return nil
+}
+
+// SignatureRule rejects signed transactions whose signature was not made by the
+// sender's key for ctx.ChainID, and signed transfers to a receiver that is not
+// a valid address. Unsigned transactions are accepted.
+func SignatureRule(ctx *ValidationContext, tx *types.Transaction) error {
+	if len(tx.PublicKey) == 0 && len(tx.Signature) == 0 {
+		return nil
+	}
+	if len(tx.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: public key must be %d bytes", ErrInvalidSignature, ed25519.PublicKeySize)
+	}
+	if string(tx.Sender) != wallet.AddressFromPublicKey(tx.PublicKey) {
+		return fmt.Errorf("%w: sender is not the address of the public key", ErrInvalidSignature)
+	}
+	if !wallet.Verify(tx.PublicKey, tx.SigningHash(ctx.ChainID), tx.Signature) {
+		return fmt.Errorf("%w for chain %q", ErrInvalidSignature, ctx.ChainID)
+	}
+	if tx.Type == types.TransferTransaction {
+		if err := wallet.ValidateAddress(string(tx.Receiver)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReceiver, err)
+		}
+	}
+	return nil
+}
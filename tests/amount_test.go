@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func TestFormatAndParseAmount(t *testing.T) {
+	tests := []struct {
+		units uint64
+		text  string
+	}{
+		{0, "0"},
+		{1, "0.00000001"},
+		{150000000, "1.5"},
+		{100000000, "1"},
+		{1234567890123, "12345.67890123"},
+		{math.MaxUint64, "184467440737.09551615"},
+	}
+
+	for _, tt := range tests {
+		if got := types.FormatAmount(tt.units); got != tt.text {
+			t.Errorf("Expected FormatAmount(%d) = %s, got %s", tt.units, tt.text, got)
+		}
+		if got, err := types.ParseAmount(tt.text); err != nil || got != tt.units {
+			t.Errorf("Expected ParseAmount(%s) = %d, got %d (%v)", tt.text, tt.units, got, err)
+		}
+	}
+
+	for _, text := range []string{"", "1.", ".5", "-1", "1e5", "0.123456789", "1,5"} {
+		if _, err := types.ParseAmount(text); !errors.Is(err, types.ErrInvalidAmount) {
+			t.Errorf("Expected ParseAmount(%q) to fail with ErrInvalidAmount, got %v", text, err)
+		}
+	}
+	for _, text := range []string{"184467440737.09551616", "18446744073709551616"} {
+		if _, err := types.ParseAmount(text); !errors.Is(err, types.ErrAmountOverflow) {
+			t.Errorf("Expected ParseAmount(%q) to fail with ErrAmountOverflow, got %v", text, err)
+		}
+	}
+}
+
+func TestAmountsHaveNoPrecisionLoss(t *testing.T) {
+	tenth, _ := types.ParseAmount("0.1")
+	fifth, _ := types.ParseAmount("0.2")
+	sum, err := types.AddAmounts(tenth, fifth)
+	if err != nil {
+		t.Fatalf("Failed to add amounts: %v", err)
+	}
+	if want, _ := types.ParseAmount("0.3"); sum != want {
+		t.Errorf("Expected 0.1 + 0.2 to be exactly 0.3, got %s", types.FormatAmount(sum))
+	}
+
+	// 2^24 and 2^24+1 used to be formatted identically with 32-bit precision.
+	a := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1 << 24}
+	b := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1<<24 + 1}
+	if bytes.Equal(a.CalculateHash(), b.CalculateHash()) {
+		t.Errorf("Expected different amounts to hash differently")
+	}
+
+	block := &types.Block{Transactions: []types.Transaction{{Amount: math.MaxUint64}}}
+	decoded := types.BlockFromProto(block.ToProto())
+	if decoded.Transactions[0].Amount != math.MaxUint64 {
+		t.Errorf("Expected the amount to survive encoding, got %d", decoded.Transactions[0].Amount)
+	}
+}
+
+func TestValidateBlockRejectsAmountOverflow(t *testing.T) {
+	if _, err := types.AddAmounts(math.MaxUint64, 1); !errors.Is(err, types.ErrAmountOverflow) {
+		t.Errorf("Expected ErrAmountOverflow, got %v", err)
+	}
+
+	bc := newFixtureBlockchain()
+	genesis := bc.GetRoot().Block
+	block := &types.Block{
+		Index:     1,
+		Timestamp: genesis.Timestamp + 10,
+		Transactions: []types.Transaction{
+			{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: math.MaxUint64},
+			{Sender: []byte("Alice"), Receiver: []byte("Carol"), Amount: 1},
+		},
+		PreviousHash: genesis.CalculateHash(),
+	}
+
+	if err := bc.ValidateBlock(block, genesis); !errors.Is(err, types.ErrAmountOverflow) {
+		t.Errorf("Expected overflowing amounts to be rejected, got %v", err)
+	}
+}
@@ -26,10 +26,20 @@ func TestNewBlockchain(t *testing.T) {
 	}
 }
 
+// nextTimestamp returns the current time, or one second after parent if the
+// parent is not older, so that the block is after the median of past blocks.
+func nextTimestamp(parent *types.Block) uint64 {
+	timestamp := uint64(time.Now().Unix())
+	if timestamp <= parent.Timestamp {
+		timestamp = parent.Timestamp + 1
+	}
+	return timestamp
+}
+
 func generateHardcodedValidBlock(parent *types.Block) *types.Block {
 	newBlock := &types.Block{
 		Index:        parent.Index + 1,
-		Timestamp:    uint64(time.Now().Unix()),
+		Timestamp:    nextTimestamp(parent),
 		Transactions: make([]types.Transaction, 0),
 		PreviousHash: parent.CalculateHash(),
 		Data:         0,
@@ -136,3 +146,65 @@ func TestGetLatestBlock(t *testing.T) {
 		t.Errorf("Expected latest block index to be 1, but got %d", latestBlock.Index)
 	}
 }
+
+func TestAdoptChainExtendsAndReorganizes(t *testing.T) {
+	// The "late" branch forks below the automatic checkpoint at height 10.
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 3))
+
+	// Known blocks are skipped and the rest extends the head.
+	if err := bc.AdoptChain(fixtureBlocks("main", 12)); err != nil {
+		t.Fatalf("Failed to adopt chain: %v", err)
+	}
+	if bc.GetLatestBlock().Index != 12 {
+		t.Fatalf("Expected head at height 12, got %d", bc.GetLatestBlock().Index)
+	}
+
+	// A shorter fork is attached but does not become canonical.
+	fork := fixtureBlocks("fork", 3)
+	if err := bc.AdoptChain(fork); err != nil {
+		t.Fatalf("Failed to adopt fork: %v", err)
+	}
+	if !bc.BlockExists(fork[2].CalculateHash()) {
+		t.Errorf("Expected fork blocks to be attached to the tree")
+	}
+	if bc.GetLatestBlock().Index != 12 {
+		t.Errorf("Expected shorter fork to leave the head at height 12")
+	}
+
+	// A longer branch from a common ancestor becomes canonical.
+	late := fixtureBlocks("late", 13)
+	if err := bc.AdoptChain(late[4:]); err == nil {
+		t.Errorf("Expected branch without a common ancestor to be rejected")
+	}
+	if err := bc.AdoptChain(late); err != nil {
+		t.Fatalf("Failed to adopt longer branch: %v", err)
+	}
+	if !bytes.Equal(bc.GetLatestBlock().CalculateHash(), late[len(late)-1].CalculateHash()) {
+		t.Errorf("Expected the longer branch to become canonical")
+	}
+	if !bytes.Equal(bc.GetBlockByHeight(9).Block.CalculateHash(), late[0].CalculateHash()) {
+		t.Errorf("Expected height 9 to point to the adopted branch")
+	}
+}
+
+func TestAdoptChainIsAtomic(t *testing.T) {
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+	head := bc.GetLatestBlock()
+
+	late := fixtureBlocks("late", 13)
+	late[3].Data++
+
+	if err := bc.AdoptChain(late); err == nil {
+		t.Fatalf("Expected branch with an invalid block to be rejected")
+	}
+	for _, block := range late[:3] {
+		if bc.BlockExists(block.CalculateHash()) {
+			t.Errorf("Expected valid blocks before the invalid one to be rolled back, found block %d", block.Index)
+		}
+	}
+	if bc.GetLatestBlock() != head {
+		t.Errorf("Expected the head to be unchanged")
+	}
+}
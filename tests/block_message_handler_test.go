@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pabloaaa/GO_BLOCKCHAIN/mocks"
 	pb "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
@@ -61,20 +63,20 @@ func TestHandleBlockMessage_BlockResponse(t *testing.T) {
 	block := &types.Block{
 		Transactions: []types.Transaction{},
 	}
-	data, _ := proto.Marshal(block.ToProto())
 	blockHash := block.CalculateHash()
 	parentBlock := &types.BlockNode{Block: &types.Block{
 		Transactions: []types.Transaction{},
 	}}
 
-	mockBlockchain.On("BlockExists", blockHash).Return(true).Once()
+	mockBlockchain.On("CheckCheckpoint", block).Return(nil).Once()
+	mockBlockchain.On("BlockExists", blockHash).Return(false).Once()
 	mockBlockchain.On("GetBlock", block.PreviousHash).Return(parentBlock).Once()
 	mockBlockchain.On("ValidateBlock", block, parentBlock.Block).Return(nil).Once()
 	mockBlockchain.On("AddBlock", parentBlock, block).Return(nil).Once()
 
 	msg := &pb.BlockMessage{
 		BlockMessageType: &pb.BlockMessage_BlockResponse{
-			BlockResponse: &pb.BlockResponse{Message: data},
+			BlockResponse: &pb.BlockResponse{Block: block.ToProto()},
 		},
 	}
 
@@ -85,3 +87,111 @@ func TestHandleBlockMessage_BlockResponse(t *testing.T) {
 		t.Errorf("Expected data to be sent, but queue is empty")
 	}
 }
+
+func TestHandleBlockMessage_BlockResponseWithUnknownParent(t *testing.T) {
+	mockBlockchain := new(mocks.MockBlockchain)
+	testSender := NewTestSender()
+	handler := NewBlockMessageHandler(mockBlockchain, testSender)
+
+	block := &types.Block{
+		Index:        2,
+		Transactions: []types.Transaction{},
+		PreviousHash: []byte("parenthash"),
+	}
+
+	mockBlockchain.On("CheckCheckpoint", block).Return(nil).Once()
+	mockBlockchain.On("BlockExists", block.CalculateHash()).Return(false).Once()
+	mockBlockchain.On("GetBlock", block.PreviousHash).Return((*types.BlockNode)(nil)).Once()
+
+	msg := &pb.BlockMessage{
+		BlockMessageType: &pb.BlockMessage_BlockResponse{
+			BlockResponse: &pb.BlockResponse{Block: block.ToProto()},
+		},
+	}
+
+	handler.HandleBlockMessage(msg)
+
+	mockBlockchain.AssertExpectations(t)
+	if len(testSender.GetQueue()) != 1 {
+		t.Fatalf("Expected a request for the parent block, got %d messages", len(testSender.GetQueue()))
+	}
+	var sent pb.MainMessage
+	if err := proto.Unmarshal(testSender.GetQueue()[0], &sent); err != nil {
+		t.Fatalf("Failed to decode sent message: %v", err)
+	}
+	request := sent.GetBlockMessage().GetGetBlockRequest_()
+	if request == nil || string(request.GetHash()) != "parenthash" {
+		t.Errorf("Expected a request for the parent block, got %v", &sent)
+	}
+}
+
+// sendBlockResponse hands block to handler as if a peer had sent it.
+func sendBlockResponse(handler *BlockMessageHandlerImpl, block *types.Block) {
+	handler.HandleBlockMessageFrom(&pb.BlockMessage{
+		BlockMessageType: &pb.BlockMessage_BlockResponse{
+			BlockResponse: &pb.BlockResponse{Block: block.ToProto()},
+		},
+	}, NewTestSender())
+}
+
+// junkOrphan returns a block whose parent no node will ever find.
+func junkOrphan(i int) *types.Block {
+	return &types.Block{
+		Index:        5,
+		Transactions: []types.Transaction{},
+		PreviousHash: []byte(fmt.Sprintf("junk-%d", i)),
+	}
+}
+
+func TestOrphanPoolEvictsOldestBlocks(t *testing.T) {
+	bc := newFixtureBlockchain()
+	handler := NewBlockMessageHandler(bc, NewTestSender())
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	handler.SetClock(clock)
+
+	for i := 0; i < 300; i++ {
+		sendBlockResponse(handler, junkOrphan(i))
+		clock.Advance(time.Second)
+	}
+	main := fixtureBlocks("main", 3)
+	sendBlockResponse(handler, main[2])
+	clock.Advance(time.Second)
+	sendBlockResponse(handler, main[1])
+	clock.Advance(time.Second)
+	for i := 300; i < 500; i++ {
+		sendBlockResponse(handler, junkOrphan(i))
+		clock.Advance(time.Second)
+	}
+	if count := handler.OrphanCount(); count != 256 {
+		t.Fatalf("Expected a full orphan pool of 256 blocks, got %d", count)
+	}
+
+	sendBlockResponse(handler, main[0])
+	if head := bc.GetLatestBlock(); head.Index != 3 {
+		t.Errorf("Expected the orphan chain to connect up to height 3, head is at %d", head.Index)
+	}
+	if count := handler.OrphanCount(); count != 254 {
+		t.Errorf("Expected 254 junk orphans left, got %d", count)
+	}
+}
+
+func TestOrphansExpire(t *testing.T) {
+	bc := newFixtureBlockchain()
+	handler := NewBlockMessageHandler(bc, NewTestSender())
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	handler.SetClock(clock)
+
+	main := fixtureBlocks("main", 2)
+	sendBlockResponse(handler, main[1])
+	sendBlockResponse(handler, junkOrphan(0))
+	clock.Advance(11 * time.Minute)
+	sendBlockResponse(handler, junkOrphan(1))
+	if count := handler.OrphanCount(); count != 1 {
+		t.Fatalf("Expected the expired orphans to be dropped, %d left", count)
+	}
+
+	sendBlockResponse(handler, main[0])
+	if head := bc.GetLatestBlock(); head.Index != 1 {
+		t.Errorf("Expected the expired orphan not to be added, head is at %d", head.Index)
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"reflect"
 	"testing"
 
@@ -14,7 +15,7 @@ func setup() *types.Block {
 		{
 			Sender:   []byte("Alice"),
 			Receiver: []byte("Bob"),
-			Amount:   10.0,
+			Amount:   10,
 		},
 	}
 	return &types.Block{
@@ -29,7 +30,8 @@ func setup() *types.Block {
 
 func TestCalculateHash(t *testing.T) {
 	block := setup()
-	expectedHash := sha256.Sum256([]byte("1123456789AliceBob10previousHash0"))
+	transactionHash := hex.EncodeToString(block.Transactions[0].CalculateHash())
+	expectedHash := sha256.Sum256([]byte("1123456789" + transactionHash + "previousHash0"))
 	calculatedHash := block.CalculateHash()
 
 	if !reflect.DeepEqual(calculatedHash, expectedHash[:]) {
@@ -47,7 +49,7 @@ func TestBlockFromProto(t *testing.T) {
 			{
 				Sender:   []byte("Alice"),
 				Receiver: []byte("Bob"),
-				Amount:   10.0,
+				Amount:   10,
 			},
 		},
 		Data:       0,
@@ -98,3 +100,45 @@ func TestToProto(t *testing.T) {
 		t.Errorf("Expected %v, got %v", block.Checkpoint, pbBlock.GetCheckpoint())
 	}
 }
+
+func TestUTXOTransactionProtoRoundTrip(t *testing.T) {
+	block := setup()
+	block.Transactions = []types.Transaction{{
+		Type:    types.UTXOTransaction,
+		Inputs:  []types.TxInput{{PreviousOutput: types.OutPoint{TxHash: []byte("previous"), Index: 1}, Signature: []byte("signature")}},
+		Outputs: []types.TxOutput{{Amount: 10, LockingKey: []byte("key")}},
+	}}
+	decoded := types.BlockFromProto(block.ToProto())
+
+	if !reflect.DeepEqual(decoded.Transactions, block.Transactions) {
+		t.Errorf("Expected %v, got %v", block.Transactions, decoded.Transactions)
+	}
+	if !reflect.DeepEqual(decoded.CalculateHash(), block.CalculateHash()) {
+		t.Errorf("Expected the decoded block to keep its hash")
+	}
+}
+
+func TestSigningHashSeparatesFields(t *testing.T) {
+	tx := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 123}
+	resplit := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob1"), Amount: 23}
+	if reflect.DeepEqual(tx.SigningHash(""), resplit.SigningHash("")) {
+		t.Errorf("Expected transactions splitting the same bytes differently to have different signing hashes")
+	}
+	moved := types.Transaction{Sender: []byte("AliceB"), Receiver: []byte("ob"), Amount: 123}
+	if reflect.DeepEqual(tx.SigningHash(""), moved.SigningHash("")) {
+		t.Errorf("Expected moving bytes from the receiver to the sender to change the signing hash")
+	}
+}
+
+func TestTransactionHashSeparatesFields(t *testing.T) {
+	tx := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 123}
+	resplit := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob1"), Amount: 23}
+	if reflect.DeepEqual(tx.CalculateHash(), resplit.CalculateHash()) {
+		t.Errorf("Expected transactions splitting the same bytes differently to have different hashes")
+	}
+	signed := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 123, PublicKey: []byte("ab"), Signature: []byte("c")}
+	moved := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 123, PublicKey: []byte("a"), Signature: []byte("bc")}
+	if reflect.DeepEqual(signed.CalculateHash(), moved.CalculateHash()) {
+		t.Errorf("Expected moving bytes from the public key to the signature to change the hash")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// replayCapture replays capture into a fresh fixture node that knows a, and returns
+// it with the replayer.
+func replayCapture(t *testing.T, capture []byte) (*Node, *Replayer) {
+	t.Helper()
+	replayer := NewReplayer()
+	node := NewNode(newFixtureBlockchain(WithClock(replayer.Clock())), "b", WithNetwork(replayer.Network()))
+	node.AddNodes([]byte("a"))
+	if _, err := replayer.Replay(node, bytes.NewReader(capture)); err != nil {
+		t.Fatalf("Failed to replay capture: %v", err)
+	}
+	return node, replayer
+}
+
+func TestRecordAndReplaySync(t *testing.T) {
+	network := NewSimNetwork(4)
+	blocks := fixtureBlocks("main", 5)
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+
+	var capture bytes.Buffer
+	newSimNode(t, network, "a", source)
+	b := NewNode(newFixtureBlockchain(), "b", WithNetwork(network), WithRecorder(NewRecorder(&capture)))
+	b.AddNodes([]byte("a"))
+	if err := b.Join(); err != nil {
+		t.Fatal(err)
+	}
+	network.Run()
+	assertHead(t, b, blocks[len(blocks)-1])
+
+	inbound, outbound := 0, 0
+	reader := NewCaptureReader(bytes.NewReader(capture.Bytes()))
+	for {
+		msg, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read capture: %v", err)
+		}
+		if msg.Peer != "a" || msg.Message == nil {
+			t.Errorf("Expected a decoded message exchanged with a, got %+v", msg)
+		}
+		if msg.Outbound {
+			outbound++
+		} else {
+			inbound++
+		}
+	}
+	if inbound == 0 || outbound == 0 {
+		t.Fatalf("Expected inbound and outbound messages, got %d and %d", inbound, outbound)
+	}
+
+	replayed, replayer := replayCapture(t, capture.Bytes())
+	assertHead(t, replayed, blocks[len(blocks)-1])
+	if sent := replayer.Sent(); len(sent) != outbound-1 {
+		// The welcome request b sent when joining is not a reply to a captured message.
+		t.Errorf("Expected the replayed node to send %d messages, got %d", outbound-1, len(sent))
+	}
+
+	_, again := replayCapture(t, capture.Bytes())
+	first, second := replayer.Sent(), again.Sent()
+	for i := range first {
+		if i >= len(second) || !bytes.Equal(first[i].Data, second[i].Data) {
+			t.Fatalf("Expected replays to send the same messages, diverged at message %d", i)
+		}
+	}
+}
+
+func TestCaptureKeepsUndecodableMessages(t *testing.T) {
+	var capture bytes.Buffer
+	recorder := NewRecorder(&capture)
+	at := time.Unix(1800000000, 42)
+	garbage := []byte{0xff, 0xff, 0xff}
+	if err := recorder.Record(CapturedMessage{Time: at, Peer: "evil", Data: garbage}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := NewCaptureReader(&capture).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Message != nil || !bytes.Equal(msg.Data, garbage) || !msg.Time.Equal(at) || msg.Peer != "evil" {
+		t.Errorf("Expected the raw message to be kept, got %+v", msg)
+	}
+}
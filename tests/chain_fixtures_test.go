@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// Mining a block against the "000" prefix takes several seconds, so the fixture
+// chains below use a genesis block with a fixed timestamp and precomputed nonces.
+const fixtureTimestamp = 1700000000
+
+// fixtureBranch describes a deterministic branch of the fixture tree.
+type fixtureBranch struct {
+	sender       string
+	offset       uint64
+	parent       string
+	parentHeight uint64
+	nonces       []uint64
+}
+
+// fixtureBranches are the branches of the fixture tree:
+// "main" is genesis -> 1..12, "fork" is genesis -> 1..3 and
+// "late" forks off "main" at height 8 and runs from 9 to 13.
+var fixtureBranches = map[string]fixtureBranch{
+	"main": {sender: "Alice", offset: 0, nonces: []uint64{
+		7469301, 24301751, 8598916, 511953, 7490896, 722597,
+		15968734, 15080468, 30242958, 1072222, 14512171, 19485149,
+	}},
+	"fork": {sender: "Carol", offset: 1, nonces: []uint64{
+		3701178, 7420892, 4812653,
+	}},
+	"late": {sender: "Dave", offset: 2, parent: "main", parentHeight: 8, nonces: []uint64{
+		58398801, 562357, 8567089, 27194671, 11612557,
+	}},
+}
+
+// fixtureGenesis returns the genesis block of the fixture tree.
+func fixtureGenesis() *types.Block {
+	return &types.Block{
+		Index:        0,
+		Timestamp:    fixtureTimestamp,
+		Transactions: make([]types.Transaction, 0),
+		PreviousHash: []byte("0"),
+		Data:         0,
+	}
+}
+
+// fixtureBlocks returns the blocks of a fixture branch, from its first block up to the given height.
+func fixtureBlocks(branch string, to uint64) []*types.Block {
+	fb := fixtureBranches[branch]
+	parent := fixtureGenesis()
+	if fb.parent != "" {
+		parentBlocks := fixtureBlocks(fb.parent, fb.parentHeight)
+		parent = parentBlocks[len(parentBlocks)-1]
+	}
+
+	var blocks []*types.Block
+	for _, nonce := range fb.nonces {
+		height := parent.Index + 1
+		if height > to {
+			break
+		}
+		block := &types.Block{
+			Index:     height,
+			Timestamp: fixtureTimestamp + height*10 + fb.offset,
+			Transactions: []types.Transaction{
+				{Sender: []byte(fb.sender), Receiver: []byte("Bob"), Amount: height},
+			},
+			PreviousHash: parent.CalculateHash(),
+			Data:         nonce,
+		}
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return blocks
+}
+
+// newFixtureBlockchain creates a blockchain rooted at the fixture genesis block.
+func newFixtureBlockchain(opts ...BlockchainOption) *Blockchain {
+	return NewBlockchainFromGenesis(fixtureGenesis(), opts...)
+}
+
+// addFixtureBlocks adds blocks to bc, each one below its parent.
+func addFixtureBlocks(t *testing.T, bc *Blockchain, blocks []*types.Block) {
+	t.Helper()
+	for _, block := range blocks {
+		parent := bc.GetBlock(block.PreviousHash)
+		if parent == nil {
+			t.Fatalf("Parent of fixture block %d is unknown", block.Index)
+		}
+		if err := bc.AddBlock(parent, block); err != nil {
+			t.Fatalf("Failed to add fixture block %d: %v", block.Index, err)
+		}
+	}
+}
+
+func TestFixtureBlocksAreValid(t *testing.T) {
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+	addFixtureBlocks(t, bc, fixtureBlocks("fork", 3))
+	addFixtureBlocks(t, bc, fixtureBlocks("late", 13))
+}
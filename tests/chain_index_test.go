@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func generateValidBlockWithTransactions(parent *types.Block, transactions []types.Transaction) *types.Block {
+	newBlock := &types.Block{
+		Index:        parent.Index + 1,
+		Timestamp:    nextTimestamp(parent),
+		Transactions: transactions,
+		PreviousHash: parent.CalculateHash(),
+		Data:         0,
+	}
+	for !bytes.HasPrefix(newBlock.CalculateHash(), []byte("000")) {
+		newBlock.Data++
+	}
+	return newBlock
+}
+
+func TestChainIndexesFollowReorg(t *testing.T) {
+	bc := setupBlockchain()
+	genesis := bc.GetRoot()
+
+	aliceToBob := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1}
+	carolToDave := types.Transaction{Sender: []byte("Carol"), Receiver: []byte("Dave"), Amount: 2}
+	bobToCarol := types.Transaction{Sender: []byte("Bob"), Receiver: []byte("Carol"), Amount: 3}
+
+	forkA := generateValidBlockWithTransactions(genesis.Block, []types.Transaction{aliceToBob})
+	if err := bc.AddBlock(genesis, forkA); err != nil {
+		t.Fatalf("Failed to add block: %v", err)
+	}
+
+	if !bytes.Equal(bc.GetBlockByHeight(1).Block.CalculateHash(), forkA.CalculateHash()) {
+		t.Fatalf("Expected height 1 to point to the first branch")
+	}
+	tx, location := bc.GetTransaction(aliceToBob.CalculateHash())
+	if tx == nil || location.Height != 1 || location.Position != 0 {
+		t.Fatalf("Expected Alice's transaction at height 1, got %v %v", tx, location)
+	}
+	if len(bc.GetAddressTransactions([]byte("Bob"))) != 1 {
+		t.Fatalf("Expected Bob to have 1 transaction")
+	}
+
+	forkB1 := generateValidBlockWithTransactions(genesis.Block, []types.Transaction{carolToDave})
+	if err := bc.AddBlock(genesis, forkB1); err != nil {
+		t.Fatalf("Failed to add block: %v", err)
+	}
+	// Equal height forks do not replace the head.
+	if !bytes.Equal(bc.GetLatestBlock().CalculateHash(), forkA.CalculateHash()) {
+		t.Fatalf("Expected the first seen branch to stay canonical")
+	}
+
+	forkB2 := generateValidBlockWithTransactions(forkB1, []types.Transaction{bobToCarol})
+	if err := bc.AddBlock(bc.GetBlock(forkB1.CalculateHash()), forkB2); err != nil {
+		t.Fatalf("Failed to add block: %v", err)
+	}
+
+	if !bytes.Equal(bc.GetBlockByHeight(1).Block.CalculateHash(), forkB1.CalculateHash()) {
+		t.Errorf("Expected height 1 to point to the second branch after the reorg")
+	}
+	if !bytes.Equal(bc.GetBlockByHeight(2).Block.CalculateHash(), forkB2.CalculateHash()) {
+		t.Errorf("Expected height 2 to point to the second branch after the reorg")
+	}
+	if bc.GetBlockByHeight(3) != nil {
+		t.Errorf("Expected no block at height 3")
+	}
+
+	if tx, _ := bc.GetTransaction(aliceToBob.CalculateHash()); tx != nil {
+		t.Errorf("Expected Alice's transaction to be removed from the index after the reorg")
+	}
+	if _, location := bc.GetTransaction(bobToCarol.CalculateHash()); location == nil || location.Height != 2 {
+		t.Errorf("Expected Bob's transaction at height 2, got %v", location)
+	}
+
+	bobTransactions := bc.GetAddressTransactions([]byte("Bob"))
+	if len(bobTransactions) != 1 || bobTransactions[0].Height != 2 {
+		t.Errorf("Expected Bob to have only the transaction at height 2, got %v", bobTransactions)
+	}
+	if len(bc.GetAddressTransactions([]byte("Alice"))) != 0 {
+		t.Errorf("Expected Alice to have no canonical transactions")
+	}
+	if len(bc.GetAddressTransactions([]byte("Carol"))) != 2 {
+		t.Errorf("Expected Carol to have 2 transactions")
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func TestExportImportChain(t *testing.T) {
+	source := newFixtureBlockchain()
+	blocks := fixtureBlocks("main", 3)
+	addFixtureBlocks(t, source, blocks)
+	head := blocks[len(blocks)-1]
+
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		exported, err := ExportChain(source, &buf, 0, 100, compress)
+		if err != nil {
+			t.Fatalf("Failed to export chain: %v", err)
+		}
+		if exported != 4 {
+			t.Fatalf("Expected 4 exported blocks, got %d", exported)
+		}
+
+		loaded, state, err := LoadChain(bytes.NewReader(buf.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("Failed to load chain (gzip=%v): %v", compress, err)
+		}
+		if state.Imported != 3 || state.Skipped != 1 {
+			t.Errorf("Expected 3 imported and 1 skipped block, got %+v", state)
+		}
+		if !bytes.Equal(loaded.GetLatestBlock().CalculateHash(), head.CalculateHash()) {
+			t.Errorf("Expected loaded chain to end with the exported block")
+		}
+
+		target := newFixtureBlockchain()
+		var reports []ImportProgress
+		state, err = ImportChain(target, bytes.NewReader(buf.Bytes()), func(p ImportProgress) {
+			reports = append(reports, p)
+		})
+		if err != nil {
+			t.Fatalf("Failed to import chain: %v", err)
+		}
+		if state.Imported != 3 || len(reports) != 4 {
+			t.Errorf("Expected 3 imported blocks and 4 progress reports, got %+v and %d", state, len(reports))
+		}
+	}
+}
+
+func TestExportChainRange(t *testing.T) {
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, fixtureBlocks("main", 3))
+
+	var buf bytes.Buffer
+	exported, err := ExportChain(source, &buf, 2, 3, true)
+	if err != nil {
+		t.Fatalf("Failed to export chain: %v", err)
+	}
+	if exported != 2 {
+		t.Fatalf("Expected 2 exported blocks, got %d", exported)
+	}
+
+	target := newFixtureBlockchain()
+	if _, err := ImportChain(target, &buf, nil); err == nil {
+		t.Errorf("Expected import without the parent of the first block to fail")
+	}
+
+	addFixtureBlocks(t, target, fixtureBlocks("main", 1))
+	buf.Reset()
+	if _, err := ExportChain(source, &buf, 2, 3, false); err != nil {
+		t.Fatalf("Failed to export chain: %v", err)
+	}
+	state, err := ImportChain(target, &buf, nil)
+	if err != nil {
+		t.Fatalf("Failed to import chain: %v", err)
+	}
+	if state.Imported != 2 || target.GetLatestBlock().Index != 3 {
+		t.Errorf("Expected 2 imported blocks up to height 3, got %+v", state)
+	}
+}
+
+func TestImportChainRejectsForeignGenesis(t *testing.T) {
+	source := setupBlockchain()
+	var buf bytes.Buffer
+	if _, err := ExportChain(source, &buf, 0, 0, false); err != nil {
+		t.Fatalf("Failed to export chain: %v", err)
+	}
+
+	foreign := source.GetRoot().Block
+	target := NewBlockchainFromGenesis(&types.Block{
+		Index:        0,
+		Timestamp:    foreign.Timestamp + 1,
+		PreviousHash: []byte("0"),
+	})
+
+	if _, err := ImportChain(target, &buf, nil); err == nil {
+		t.Errorf("Expected import with a different genesis block to fail")
+	}
+}
+
+func TestLoadChainWithOptions(t *testing.T) {
+	chain := newPoAChain(t, 2)
+	for i := 0; i < 4; i++ {
+		chain.addNext(t)
+	}
+	var buf bytes.Buffer
+	if _, err := ExportChain(chain.bc, &buf, 0, 100, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadChain(bytes.NewReader(buf.Bytes()), nil); err == nil {
+		t.Errorf("Expected proof-of-authority blocks to be refused by a proof-of-work blockchain")
+	}
+	signers, err := chain.engine.Signers(chain.bc.GetRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, state, err := LoadChain(bytes.NewReader(buf.Bytes()), nil, WithConsensusEngine(NewProofOfAuthority(signers)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Imported != 4 || !bytes.Equal(loaded.GetLatestBlock().CalculateHash(), chain.bc.GetLatestBlock().CalculateHash()) {
+		t.Errorf("Expected the proof-of-authority chain to be loaded, got %+v", state)
+	}
+	if _, ok := loaded.GetConsensusEngine().(*ProofOfAuthority); !ok {
+		t.Errorf("Expected the loaded chain to keep the proof-of-authority engine, got %T", loaded.GetConsensusEngine())
+	}
+}
+
+func TestExportChainAfterPruning(t *testing.T) {
+	checkpoint := Checkpoint{Height: 10, Hash: fixtureBlocks("main", 10)[9].CalculateHash()}
+	blocks := fixtureBlocks("main", 12)
+	prune := PruneOptions{BelowCheckpoint: true, DropTransactions: true}
+
+	pruned := newFixtureBlockchain(WithCheckpoints(checkpoint))
+	addFixtureBlocks(t, pruned, blocks)
+	pruned.Prune(prune)
+	var buf bytes.Buffer
+	if _, err := ExportChain(pruned, &buf, 0, 100, false); !errors.Is(err, ErrBlockPruned) {
+		t.Errorf("Expected exporting pruned blocks to fail, got %v", err)
+	}
+
+	archive := newFixtureBlockchain(WithCheckpoints(checkpoint), WithArchive())
+	addFixtureBlocks(t, archive, blocks)
+	if report := archive.Prune(prune); report.TransactionsDropped != 0 {
+		t.Errorf("Expected an archive to keep its transactions, %d were dropped", report.TransactionsDropped)
+	}
+	buf.Reset()
+	exported, err := ExportChain(archive, &buf, 0, 100, false)
+	if err != nil || exported != 13 {
+		t.Fatalf("Expected the pruned archive to export 13 blocks, got %d (%v)", exported, err)
+	}
+	loaded, _, err := LoadChain(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("Failed to load the exported chain: %v", err)
+	}
+	if !bytes.Equal(loaded.GetLatestBlock().CalculateHash(), blocks[11].CalculateHash()) {
+		t.Errorf("Expected the loaded chain to end with the head of the archive")
+	}
+}
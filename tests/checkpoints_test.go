@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+func TestAutomaticCheckpointRejectsDeepReorg(t *testing.T) {
+	bc := newFixtureBlockchain()
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+
+	checkpoints := bc.GetCheckpoints()
+	if len(checkpoints) != 1 || checkpoints[0].Height != 10 {
+		t.Fatalf("Expected an automatic checkpoint at height 10, got %v", checkpoints)
+	}
+	if !bc.GetBlockByHeight(10).Block.Checkpoint || bc.GetBlockByHeight(9).Block.Checkpoint {
+		t.Errorf("Expected only the block at height 10 to be flagged as a checkpoint")
+	}
+
+	// The "late" branch forks at height 8, below the checkpoint.
+	late := fixtureBlocks("late", 13)
+	if err := bc.AdoptChain(late); err == nil {
+		t.Errorf("Expected a reorganization below the checkpoint to be rejected")
+	}
+	if err := bc.CheckCheckpoint(late[1]); err == nil {
+		t.Errorf("Expected a block conflicting with the checkpoint to be refused")
+	}
+	if err := bc.AddBlock(bc.GetBlockByHeight(8), late[0]); err == nil {
+		t.Errorf("Expected a block forking below the checkpoint to be rejected")
+	}
+	if bc.GetLatestBlock().Index != 12 {
+		t.Errorf("Expected the head to stay at height 12")
+	}
+}
+
+func TestHardcodedCheckpointSelectsBranch(t *testing.T) {
+	fork := fixtureBlocks("fork", 3)
+	bc := newFixtureBlockchain(WithCheckpoints(Checkpoint{Height: 2, Hash: fork[1].CalculateHash()}))
+
+	main := fixtureBlocks("main", 2)
+	addFixtureBlocks(t, bc, main[:1])
+	if err := bc.AddBlock(bc.GetBlock(main[0].CalculateHash()), main[1]); err == nil {
+		t.Errorf("Expected a block conflicting with a hard-coded checkpoint to be rejected")
+	}
+	if err := bc.CheckCheckpoint(main[1]); err == nil {
+		t.Errorf("Expected CheckCheckpoint to refuse the conflicting block")
+	}
+
+	addFixtureBlocks(t, bc, fork)
+	if !bytes.Equal(bc.GetLatestBlock().CalculateHash(), fork[2].CalculateHash()) {
+		t.Errorf("Expected the checkpointed branch to become canonical")
+	}
+	if !bc.GetBlockByHeight(2).Block.Checkpoint {
+		t.Errorf("Expected the block at the hard-coded checkpoint to be flagged")
+	}
+}
+
+func TestCheckpointsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+
+	bc := newFixtureBlockchain(WithCheckpointFile(path))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 10))
+
+	restarted := newFixtureBlockchain(WithCheckpointFile(path))
+	checkpoints := restarted.GetCheckpoints()
+	if len(checkpoints) != 1 || checkpoints[0].Height != 10 {
+		t.Fatalf("Expected the checkpoint at height 10 to be restored, got %v", checkpoints)
+	}
+
+	addFixtureBlocks(t, restarted, fixtureBlocks("main", 8))
+	late := fixtureBlocks("late", 10)
+	if err := restarted.AdoptChain(late); err == nil {
+		t.Errorf("Expected a branch conflicting with the restored checkpoint to be rejected")
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func TestBlockchainUsesClock(t *testing.T) {
+	start := time.Unix(1800000000, 0)
+	clock := NewManualClock(start)
+	bc := NewBlockchain(WithClock(clock))
+
+	genesis := bc.GetLatestBlock()
+	if genesis.Timestamp != uint64(start.Unix()) {
+		t.Errorf("Expected the genesis block at %d, got %d", start.Unix(), genesis.Timestamp)
+	}
+
+	clock.Advance(time.Minute)
+	transactions := []types.Transaction{{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1}}
+	block := bc.GenerateNewBlock(transactions)
+	if block.Timestamp != uint64(start.Add(time.Minute).Unix()) {
+		t.Errorf("Expected a new block at %d, got %d", start.Add(time.Minute).Unix(), block.Timestamp)
+	}
+
+	block.Timestamp = uint64(start.Add(3 * time.Hour).Unix())
+	if err := bc.ValidateBlock(block, genesis); !errors.Is(err, ErrTimestampTooFarInFuture) {
+		t.Errorf("Expected ErrTimestampTooFarInFuture, got %v", err)
+	}
+	clock.Advance(2 * time.Hour)
+	if err := bc.ValidateBlock(block, genesis); errors.Is(err, ErrTimestampTooFarInFuture) {
+		t.Errorf("Expected the block to be acceptable once the clock caught up, got %v", err)
+	}
+}
+
+func TestNetworkClock(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	clock := NewNetworkClock(NewManualClock(now))
+
+	clock.AddSample("a", now.Add(10*time.Second))
+	clock.AddSample("b", now.Add(30*time.Second))
+	if offset := clock.Offset(); offset != 0 {
+		t.Errorf("Expected no adjustment from two peers, got %v", offset)
+	}
+
+	clock.AddSample("c", now.Add(20*time.Second))
+	if offset := clock.Offset(); offset != 20*time.Second {
+		t.Errorf("Expected the median offset of 20s, got %v", offset)
+	}
+	if !clock.Now().Equal(now.Add(20 * time.Second)) {
+		t.Errorf("Expected the adjusted time to include the offset, got %v", clock.Now())
+	}
+
+	// A peer reporting again replaces its earlier sample.
+	clock.AddSample("c", now.Add(-time.Hour))
+	if offset := clock.Offset(); offset != 10*time.Second {
+		t.Errorf("Expected the median offset of 10s, got %v", offset)
+	}
+
+	for _, peer := range []string{"a", "b", "c"} {
+		clock.AddSample(peer, now.Add(2*time.Hour))
+	}
+	if offset := clock.Offset(); offset != 0 {
+		t.Errorf("Expected offsets beyond %v to be ignored, got %v", MaxClockAdjustment, offset)
+	}
+}
+
+func TestSimNetworkAdjustsTimeFromHandshakes(t *testing.T) {
+	network := NewSimNetwork(3)
+	now := time.Unix(1800000000, 0)
+	peers := []string{"b", "c", "d"}
+
+	// join starts a node whose clock runs offset ahead of now.
+	join := func(address string, offset time.Duration, peers ...string) *NetworkClock {
+		clock := NewNetworkClock(NewManualClock(now.Add(offset)))
+		node := NewNode(newFixtureBlockchain(WithClock(clock)), address, WithNetwork(network), WithNetworkClock(clock))
+		for _, peer := range peers {
+			node.AddNodes([]byte(peer))
+		}
+		if err := node.Join(); err != nil {
+			t.Fatalf("Failed to join node %s: %v", address, err)
+		}
+		return clock
+	}
+
+	for _, peer := range peers {
+		join(peer, 90*time.Second)
+	}
+	clock := join("a", 0, peers...)
+	network.Run()
+
+	if offset := clock.Offset(); offset != 90*time.Second {
+		t.Errorf("Expected a to adjust its clock by 90s, got %v", offset)
+	}
+	if !clock.Now().Equal(now.Add(90 * time.Second)) {
+		t.Errorf("Expected the adjusted time of a to match its peers, got %v", clock.Now())
+	}
+}
+
+func TestNetworkClockCountsEachHostOnce(t *testing.T) {
+	transport := NewTCPTransport()
+	defer transport.Close()
+	now := time.Now()
+
+	clock := NewNetworkClock(NewManualClock(now))
+	node := NewNode(newFixtureBlockchain(), "127.0.0.1:8085", WithTransport(transport), WithNetworkClock(clock), WithSealMode(SealManual))
+	startNode(t, node)
+
+	// Three nodes on the same host report a time 90s ahead under different addresses.
+	for _, address := range []string{"127.0.0.1:8086", "127.0.0.1:8087", "127.0.0.1:8088"} {
+		skewed := NewNetworkClock(NewManualClock(now.Add(90 * time.Second)))
+		peer := NewNode(newFixtureBlockchain(), address, WithTransport(transport), WithNetworkClock(skewed), WithSealMode(SealManual))
+		peer.AddNodes([]byte(node.GetAddress()))
+		startNode(t, peer)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(node.GetNodes()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected three peers to greet the node, got %d", len(node.GetNodes()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for end := time.Now().Add(200 * time.Millisecond); time.Now().Before(end); time.Sleep(10 * time.Millisecond) {
+		if offset := clock.Offset(); offset != 0 {
+			t.Fatalf("Expected the peers of one host to count as one sample, got an offset of %v", offset)
+		}
+	}
+}
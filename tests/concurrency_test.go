@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// These tests are meant to be run with "go test -race".
+
+func TestBlockchainConcurrentReadsAndWrites(t *testing.T) {
+	// The fork is added first, as it could not be added below the checkpoints
+	// of the main chain.
+	bc := newFixtureBlockchain(WithCheckpointInterval(4))
+	addFixtureBlocks(t, bc, fixtureBlocks("fork", 3))
+
+	var writers, readers sync.WaitGroup
+	done := make(chan struct{})
+
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for _, block := range fixtureBlocks("main", 12) {
+			if err := bc.AddBlock(bc.GetBlock(block.PreviousHash), block); err != nil {
+				t.Errorf("Failed to add block %d: %v", block.Index, err)
+			}
+		}
+	}()
+
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			bc.Prune(PruneOptions{ForkDepth: 20, DropTransactions: true})
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				latest := bc.GetLatestBlock()
+				_ = latest.ToProto()
+				for height := uint64(0); height <= latest.Index; height++ {
+					node := bc.GetBlockByHeight(height)
+					if node == nil {
+						continue
+					}
+					_ = node.Block.Checkpoint
+					if node.Parent != nil {
+						_ = node.Parent.Block.Checkpoint
+						_ = len(node.Parent.Block.Transactions)
+					}
+					for _, child := range node.Childs {
+						_ = child.Block.Checkpoint
+						_ = len(child.Block.Transactions)
+						_ = len(child.Childs)
+					}
+				}
+				for _, child := range bc.GetRoot().Childs {
+					_ = child.Hash
+					_ = child.Block.Checkpoint
+				}
+				bc.GetTransaction(latest.CalculateHash())
+				bc.GetAddressTransactions([]byte("Bob"))
+				bc.CheckCheckpoint(latest)
+				bc.GetCheckpoints()
+			}
+		}()
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	bc.Prune(PruneOptions{ForkDepth: 5})
+	if bc.GetLatestBlock().Index != 12 {
+		t.Errorf("Expected head at height 12, got %d", bc.GetLatestBlock().Index)
+	}
+}
+
+func TestNodePeerListConcurrentUpdates(t *testing.T) {
+	node := NewNode(NewBlockchain(), "127.0.0.1:0")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				node.AddNodes([]byte(fmt.Sprintf("127.0.0.1:%d", 9000+(i*50+j)%100)))
+				for _, address := range node.GetNodes() {
+					_ = string(address)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(node.GetNodes()) != 100 {
+		t.Errorf("Expected 100 distinct peers, got %d", len(node.GetNodes()))
+	}
+}
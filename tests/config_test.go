@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+const testConfigYAML = `
+node:
+  address: localhost:9000
+  peers: [localhost:9001, localhost:9002]
+  mining_interval: 2s
+chain:
+  difficulty: 2
+  checkpoint_interval: 5
+log:
+  level: debug
+  format: json
+`
+
+// writeConfig writes a config file with the given content and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "node.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(writeConfig(t, testConfigYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.Node = NodeConfig{Address: "localhost:9000", Peers: []string{"localhost:9001", "localhost:9002"}, MiningInterval: 2 * time.Second, Seal: SealProofOfWork}
+	want.Chain.Difficulty = 2
+	want.Chain.CheckpointInterval = 5
+	want.Log = LogConfig{Level: "debug", Format: LogFormatJSON}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("Expected %+v, got %+v", want, config)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the config to be valid, got %v", err)
+	}
+
+	if _, err := LoadConfig(writeConfig(t, "chain:\n  dificulty: 2\n")); err == nil {
+		t.Errorf("Expected unknown settings to be refused")
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	config, err := LoadConfig(writeConfig(t, testConfigYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"GOB_DIFFICULTY": "4", "GOB_PEERS": "localhost:9003", "GOB_MINING_INTERVAL": "1m"}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	if err := config.ApplyEnv(lookup); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("node", flag.ContinueOnError)
+	overrides := BindConfigFlags(flags)
+	if err := flags.Parse([]string{"-difficulty", "5", "-log-level", "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := overrides.Apply(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Chain.Difficulty != 5 || config.Log.Level != "warn" {
+		t.Errorf("Expected flags to override the file and environment, got %+v", config)
+	}
+	if !reflect.DeepEqual(config.Node.Peers, []string{"localhost:9003"}) || config.Node.MiningInterval != time.Minute {
+		t.Errorf("Expected the environment to override the file, got %+v", config.Node)
+	}
+	if config.Node.Address != "localhost:9000" || config.Chain.CheckpointInterval != 5 {
+		t.Errorf("Expected settings without overrides to keep their file values, got %+v", config)
+	}
+
+	env["GOB_DIFFICULTY"] = "three"
+	if err := config.ApplyEnv(lookup); err == nil {
+		t.Errorf("Expected an invalid environment variable to be refused")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Node.Address = "localhost"
+	config.Chain.Difficulty = 33
+	config.Log.Level = "loud"
+	config.Log.Format = "xml"
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected the config to be invalid")
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 4 {
+		t.Errorf("Expected every invalid setting to be reported, got %v", err)
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	config := DefaultConfig()
+	config.Chain.Difficulty = 4
+	config.Node.Peers = []string{"localhost:9001"}
+	logger, err := config.Logger(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := newFixtureBlockchain(append(profile.BlockchainOptions(), config.BlockchainOptions(logger)...)...)
+	block := fixtureBlocks("main", 1)[0]
+	if err := bc.ValidateBlock(block, bc.GetRoot().Block); !errors.Is(err, ErrInsufficientWork) {
+		t.Errorf("Expected a block with 3 leading zeros to fail difficulty 4, got %v", err)
+	}
+	easy := newFixtureBlockchain(WithDifficulty(2))
+	if err := easy.ValidateBlock(block, easy.GetRoot().Block); err != nil {
+		t.Errorf("Expected the block to pass difficulty 2, got %v", err)
+	}
+
+	node := profile.NewNode(bc, config.Node.Address, config.NodeOptions(logger)...)
+	if nodes := node.GetNodes(); len(nodes) != 1 || string(nodes[0]) != "localhost:9001" {
+		t.Errorf("Expected the configured peers, got %q", nodes)
+	}
+	if node.GetAddress() != "localhost:8080" {
+		t.Errorf("Expected the default address of the testnet, got %s", node.GetAddress())
+	}
+}
+
+func TestConfigNetwork(t *testing.T) {
+	config, err := LoadConfig(writeConfig(t, "network: private\nchain:\n  chain_id: acme\n  difficulty: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != NetworkPrivate || profile.ChainID != "acme" || profile.Difficulty != 2 || profile.CheckpointInterval != DefaultCheckpointInterval {
+		t.Errorf("Expected the private profile with the configured chain settings, got %+v", profile)
+	}
+
+	config.Chain.ChainID = ""
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a private network without chain ID to be refused")
+	}
+	config = DefaultConfig()
+	config.Chain.ChainID = "acme"
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected the chain ID of the testnet to be fixed")
+	}
+	config = DefaultConfig()
+	if err := config.ApplyEnv(func(key string) (string, bool) { return "mainnet", key == "GOB_NETWORK" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); !errors.Is(err, ErrUnknownNetwork) {
+		t.Errorf("Expected ErrUnknownNetwork, got %v", err)
+	}
+}
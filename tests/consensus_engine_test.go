@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// errNoWeight is returned by weightEngine for blocks without weight.
+var errNoWeight = errors.New("block has no weight")
+
+// weightEngine is a consensus engine whose blocks carry their own work in Data.
+type weightEngine struct {
+	finalized int
+}
+
+func (e *weightEngine) VerifyHeader(parent *types.BlockNode, block *types.Block) error {
+	if block.Data == 0 {
+		return errNoWeight
+	}
+	return nil
+}
+
+func (e *weightEngine) Prepare(parent *types.BlockNode, block *types.Block) error {
+	block.Data = 1
+	return nil
+}
+
+func (e *weightEngine) Finalize(parent *types.BlockNode, block *types.Block) error {
+	e.finalized++
+	return nil
+}
+
+func (e *weightEngine) Seal(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	sealed := *block
+	return &sealed, nil
+}
+
+func (e *weightEngine) CalculateWork(block *types.Block) *big.Int {
+	return new(big.Int).SetUint64(block.Data)
+}
+
+func TestProofOfWorkEngine(t *testing.T) {
+	pow := NewProofOfWork(1)
+	block := fixtureBlocks("main", 1)[0]
+	block.Data = 0
+	sealed, err := pow.Seal(block, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pow.VerifyHeader(nil, sealed); err != nil {
+		t.Errorf("Expected the sealed block to carry enough work, got %v", err)
+	}
+	if work := pow.CalculateWork(sealed); work.Cmp(big.NewInt(256)) != 0 {
+		t.Errorf("Expected a work of 256 at difficulty 1, got %v", work)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	if _, err := NewProofOfWork(32).Seal(block, stop); !errors.Is(err, ErrSealAborted) {
+		t.Errorf("Expected sealing to stop, got %v", err)
+	}
+}
+
+func TestCustomConsensusEngine(t *testing.T) {
+	engine := &weightEngine{}
+	bc := NewBlockchain(WithConsensusEngine(engine))
+	if bc.GetConsensusEngine() != engine {
+		t.Fatal("Expected the configured engine")
+	}
+
+	// A long branch of light blocks.
+	for i := 0; i < 3; i++ {
+		block := bc.GenerateNewBlock([]types.Transaction{{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: uint64(i + 1)}})
+		if err := bc.AddBlock(bc.GetBlock(block.PreviousHash), block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if engine.finalized != 3 {
+		t.Errorf("Expected every generated block to be finalized, got %d", engine.finalized)
+	}
+
+	// A single heavy block wins fork choice over the longer branch.
+	genesis := bc.GetRoot()
+	heavy := &types.Block{Index: 1, Timestamp: genesis.Block.Timestamp + 1, PreviousHash: genesis.Hash, Data: 5}
+	if err := bc.AddBlock(genesis, heavy); err != nil {
+		t.Fatal(err)
+	}
+	if head := bc.GetLatestBlock(); head.Index != 1 || head.Data != 5 {
+		t.Errorf("Expected the branch with the most work to become the head, got block %d", head.Index)
+	}
+
+	weightless := &types.Block{Index: 2, Timestamp: heavy.Timestamp + 1, PreviousHash: heavy.CalculateHash()}
+	if err := bc.AddBlock(bc.GetBlock(weightless.PreviousHash), weightless); !errors.Is(err, errNoWeight) {
+		t.Errorf("Expected the engine to reject the block, got %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// poaChain is a proof-of-authority blockchain with its signers' keys.
+type poaChain struct {
+	bc     *Blockchain
+	engine *ProofOfAuthority
+	keys   map[string]*wallet.Key
+}
+
+// newPoAChain creates a proof-of-authority blockchain with count genesis signers.
+func newPoAChain(t *testing.T, count int) *poaChain {
+	t.Helper()
+	chain := &poaChain{keys: make(map[string]*wallet.Key)}
+	var signers []ed25519.PublicKey
+	for i := 0; i < count; i++ {
+		key := newKey(t)
+		chain.keys[string(key.PublicKey())] = key
+		signers = append(signers, key.PublicKey())
+	}
+	chain.engine = NewProofOfAuthority(signers, WithOutOfTurnDelay(0))
+	chain.bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(chain.engine))
+	return chain
+}
+
+// newKey generates a signing key.
+func newKey(t *testing.T) *wallet.Key {
+	t.Helper()
+	key, err := wallet.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signer returns the key of the signer at position i of the signing order after parent.
+func (c *poaChain) signer(t *testing.T, parent *types.BlockNode, i int) *wallet.Key {
+	t.Helper()
+	signers, err := c.engine.Signers(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.keys[string(signers[i%len(signers)])]
+}
+
+// inTurn returns the key of the signer whose turn it is after parent.
+func (c *poaChain) inTurn(t *testing.T, parent *types.BlockNode) *wallet.Key {
+	return c.signer(t, parent, int(parent.Block.Index+1))
+}
+
+// seal produces a block on top of parent signed by key. Timestamps follow the
+// parent by a second, so that sealing does not wait.
+func (c *poaChain) seal(t *testing.T, parent *types.BlockNode, key *wallet.Key, transactions ...types.Transaction) *types.Block {
+	t.Helper()
+	sealed, err := c.trySeal(parent, key, transactions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+// trySeal is seal returning the engine's error.
+func (c *poaChain) trySeal(parent *types.BlockNode, key *wallet.Key, transactions ...types.Transaction) (*types.Block, error) {
+	c.engine.Authorize(key)
+	block := &types.Block{
+		Index:        parent.Block.Index + 1,
+		Timestamp:    parent.Block.Timestamp + 1,
+		PreviousHash: parent.Hash,
+		Transactions: transactions,
+	}
+	if err := c.engine.Prepare(parent, block); err != nil {
+		return nil, err
+	}
+	return c.engine.Seal(block, nil)
+}
+
+// forge signs a block on top of parent with key without asking the engine, so
+// that blocks the engine would refuse to seal can be built.
+func (c *poaChain) forge(parent *types.BlockNode, key *wallet.Key) *types.Block {
+	block := &types.Block{
+		Index:        parent.Block.Index + 1,
+		Timestamp:    parent.Block.Timestamp + 1,
+		PreviousHash: parent.Hash,
+		Signer:       key.PublicKey(),
+	}
+	block.Signature = key.Sign(block.SealHash())
+	return block
+}
+
+// sealNext seals a block on top of parent with the given transactions, signed
+// by the first signer, starting with the one in turn, who has not signed recently.
+func (c *poaChain) sealNext(t *testing.T, parent *types.BlockNode, transactions ...types.Transaction) *types.Block {
+	t.Helper()
+	signers, err := c.engine.Signers(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range signers {
+		block, err := c.trySeal(parent, c.signer(t, parent, int(parent.Block.Index+1)+i), transactions...)
+		if errors.Is(err, ErrRecentlySigned) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return block
+	}
+	t.Fatal("Expected a signer to be allowed to sign")
+	return nil
+}
+
+// addNext adds a block on top of the head sealed by sealNext.
+func (c *poaChain) addNext(t *testing.T, transactions ...types.Transaction) *types.BlockNode {
+	t.Helper()
+	head := c.bc.GetBlock(c.bc.GetLatestBlock().CalculateHash())
+	block := c.sealNext(t, head, transactions...)
+	if err := c.bc.AddBlock(head, block); err != nil {
+		t.Fatal(err)
+	}
+	return c.bc.GetBlock(block.CalculateHash())
+}
+
+// add seals a block on top of the head signed by key and adds it.
+func (c *poaChain) add(t *testing.T, key *wallet.Key, transactions ...types.Transaction) *types.BlockNode {
+	t.Helper()
+	head := c.bc.GetBlock(c.bc.GetLatestBlock().CalculateHash())
+	block := c.seal(t, head, key, transactions...)
+	if err := c.bc.AddBlock(head, block); err != nil {
+		t.Fatal(err)
+	}
+	return c.bc.GetBlock(block.CalculateHash())
+}
+
+// vote returns a signed vote by voter about candidate.
+func vote(voter *wallet.Key, kind types.TransactionType, candidate ed25519.PublicKey) types.Transaction {
+	tx := types.Transaction{Type: kind, Receiver: candidate}
+	voter.SignTransaction(&tx, "")
+	return tx
+}
+
+func TestProofOfAuthoritySigning(t *testing.T) {
+	chain := newPoAChain(t, 3)
+	genesis := chain.bc.GetRoot()
+
+	first := chain.add(t, chain.inTurn(t, genesis))
+	if first.TotalWork.Int64() != 2 {
+		t.Errorf("Expected an in-turn block to weigh 2, got %v", first.TotalWork)
+	}
+
+	again := chain.forge(first, chain.signer(t, first, int(first.Block.Index)))
+	if err := chain.bc.AddBlock(first, again); !errors.Is(err, ErrRecentlySigned) {
+		t.Errorf("Expected a signer to wait before signing again, got %v", err)
+	}
+
+	outOfTurn := chain.signer(t, first, int(first.Block.Index+2))
+	second := chain.add(t, outOfTurn)
+	if second.TotalWork.Int64() != 3 {
+		t.Errorf("Expected an out-of-turn block to weigh 1, got a total of %v", second.TotalWork)
+	}
+	// The in-turn block at the same height outweighs the out-of-turn one.
+	inTurn := chain.seal(t, first, chain.inTurn(t, first))
+	if err := chain.bc.AddBlock(first, inTurn); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chain.bc.GetLatestBlock().CalculateHash(), inTurn.CalculateHash()) {
+		t.Errorf("Expected the in-turn block to become the head")
+	}
+
+	intruder := newKey(t)
+	chain.engine.Authorize(intruder)
+	if _, err := chain.engine.Seal(chain.bc.GenerateNewBlock(nil), nil); !errors.Is(err, ErrUnauthorizedSigner) {
+		t.Errorf("Expected a key outside the signer set not to seal, got %v", err)
+	}
+	head := chain.bc.GetBlock(inTurn.CalculateHash())
+	if err := chain.bc.AddBlock(head, chain.forge(head, intruder)); !errors.Is(err, ErrUnauthorizedSigner) {
+		t.Errorf("Expected a block of an unknown signer to be refused, got %v", err)
+	}
+	if Penalty(ErrUnauthorizedSigner) != BanScore {
+		t.Errorf("Expected peers relaying unauthorized blocks to be banned")
+	}
+
+	tampered := chain.seal(t, head, chain.inTurn(t, head))
+	tampered.Timestamp++
+	if err := chain.bc.AddBlock(head, tampered); !errors.Is(err, ErrInvalidBlockSignature) {
+		t.Errorf("Expected a modified block to fail its signature, got %v", err)
+	}
+}
+
+func TestProofOfAuthorityWorkOutlivesSnapshots(t *testing.T) {
+	chain := newPoAChain(t, 1)
+	chain.bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(chain.engine), WithCheckpointInterval(0))
+	genesis := chain.bc.GetRoot()
+	key := chain.inTurn(t, genesis)
+	first := chain.add(t, key)
+	for i := 0; i < SnapshotDepth+1; i++ {
+		chain.add(t, key)
+	}
+
+	// The snapshot after the first block has been pruned, so it is recomputed
+	// for a fork on top of it, and the work of the fork does not depend on it.
+	fork := chain.seal(t, first, key, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1})
+	if err := chain.bc.AddBlock(first, fork); err != nil {
+		t.Fatal(err)
+	}
+	if work := chain.bc.GetBlock(fork.CalculateHash()).TotalWork.Int64(); work != 4 {
+		t.Errorf("Expected the fork to weigh 4 in total, got %d", work)
+	}
+
+	lying := chain.seal(t, first, key, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Carol"), Amount: 1})
+	lying.Data = 1
+	lying.Signature = key.Sign(lying.SealHash())
+	if err := chain.bc.AddBlock(first, lying); !errors.Is(err, ErrWrongTurn) {
+		t.Errorf("Expected a block declaring the wrong work to be refused, got %v", err)
+	}
+}
+
+func TestProofOfAuthorityVoting(t *testing.T) {
+	chain := newPoAChain(t, 3)
+	candidate := newKey(t)
+	chain.keys[string(candidate.PublicKey())] = candidate
+
+	genesis := chain.bc.GetRoot()
+	first := chain.inTurn(t, genesis)
+	node := chain.add(t, first, vote(first, types.AddSignerVote, candidate.PublicKey()))
+	if signers, _ := chain.engine.Signers(node); len(signers) != 3 {
+		t.Fatalf("Expected a single vote not to change the signers, got %d signers", len(signers))
+	}
+	second := chain.inTurn(t, node)
+	node = chain.add(t, second, vote(second, types.AddSignerVote, candidate.PublicKey()))
+	signers, err := chain.engine.Signers(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signers) != 4 {
+		t.Fatalf("Expected a majority to add the candidate, got %d signers", len(signers))
+	}
+	node = chain.add(t, candidate)
+
+	// Removing a signer takes 3 of the 4 signers.
+	removed := chain.signer(t, node, 0)
+	var voters []*wallet.Key
+	for i := 0; len(voters) < 3; i++ {
+		if voter := chain.signer(t, node, i); voter != removed {
+			voters = append(voters, voter)
+		}
+	}
+	for _, voter := range voters {
+		node = chain.addNext(t, vote(voter, types.RemoveSignerVote, removed.PublicKey()))
+	}
+	if signers, _ := chain.engine.Signers(node); len(signers) != 3 {
+		t.Errorf("Expected the signer to be removed, got %d signers", len(signers))
+	}
+
+	outsider := newKey(t)
+	invalid := chain.sealNext(t, node, vote(outsider, types.AddSignerVote, outsider.PublicKey()))
+	var txErr *TransactionError
+	if err := chain.bc.AddBlock(node, invalid); !errors.As(err, &txErr) || !errors.Is(err, ErrInvalidVote) {
+		t.Errorf("Expected a vote of a non-signer to be refused, got %v", err)
+	}
+}
+
+func TestProofOfAuthoritySealWaits(t *testing.T) {
+	chain := newPoAChain(t, 2)
+	engine := NewProofOfAuthority([]ed25519.PublicKey{chain.signer(t, chain.bc.GetRoot(), 0).PublicKey(), chain.signer(t, chain.bc.GetRoot(), 1).PublicKey()},
+		WithOutOfTurnDelay(time.Hour))
+	bc := NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(engine))
+	engine.Authorize(chain.signer(t, bc.GetRoot(), 0)) // Out of turn at height 1.
+
+	block := bc.GenerateNewBlock(nil)
+	stop := make(chan struct{})
+	close(stop)
+	if _, err := engine.Seal(block, stop); !errors.Is(err, ErrSealAborted) {
+		t.Errorf("Expected an out-of-turn signer to wait until stopped, got %v", err)
+	}
+	if _, err := NewProofOfAuthority(nil).Seal(block, nil); !errors.Is(err, ErrNoSignerKey) {
+		t.Errorf("Expected sealing without a key to fail, got %v", err)
+	}
+
+	// The fixture timestamps are long past, but the clock of the blockchain is
+	// an hour before them, so even the in-turn signer has to wait.
+	clock := NewManualClock(time.Unix(fixtureTimestamp, 0).Add(-time.Hour))
+	bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(engine), WithClock(clock))
+	engine.Authorize(chain.signer(t, bc.GetRoot(), 1))
+	if _, err := engine.Seal(bc.GenerateNewBlock(nil), stop); !errors.Is(err, ErrSealAborted) {
+		t.Errorf("Expected the signer to wait for the clock of the blockchain, got %v", err)
+	}
+}
+
+func TestConfigProofOfAuthority(t *testing.T) {
+	key := newKey(t)
+	config := DefaultConfig()
+	config.Network = NetworkPrivate
+	config.Chain.ChainID = "acme"
+	config.Chain.Consensus = ConsensusProofOfAuthority
+	config.Chain.Signers = []string{hex.EncodeToString(key.PublicKey())}
+	config.Chain.BlockPeriod = 5 * time.Second
+	config.Node.Signer = key.Address()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := profile.NewBlockchain(config.BlockchainOptions(nil)...)
+	engine, ok := bc.GetConsensusEngine().(*ProofOfAuthority)
+	if !ok {
+		t.Fatalf("Expected the proof-of-authority engine, got %T", bc.GetConsensusEngine())
+	}
+	if signers, _ := engine.Signers(bc.GetRoot()); len(signers) != 1 || !bytes.Equal(signers[0], key.PublicKey()) {
+		t.Errorf("Expected the configured signer, got %x", signers)
+	}
+
+	config.Chain.Signers = []string{"0123"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a malformed signer to be refused")
+	}
+	config.Chain.Signers = nil
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected proof of authority without signers to be refused")
+	}
+	config = DefaultConfig()
+	config.Chain.Consensus = ConsensusProofOfAuthority
+	config.Chain.Signers = []string{hex.EncodeToString(key.PublicKey())}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected the consensus of the testnet to be fixed")
+	}
+}
@@ -0,0 +1,411 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// posChain is a proof-of-stake blockchain with its validators' keys.
+type posChain struct {
+	bc         *Blockchain
+	engine     *ProofOfStake
+	validators []Validator
+	keys       map[string]*wallet.Key
+}
+
+// newPoSChain creates a proof-of-stake blockchain with a genesis validator for
+// every stake.
+func newPoSChain(t *testing.T, stakes ...uint64) *posChain {
+	t.Helper()
+	chain := &posChain{keys: make(map[string]*wallet.Key)}
+	for _, stake := range stakes {
+		key := newKey(t)
+		chain.keys[string(key.PublicKey())] = key
+		chain.validators = append(chain.validators, Validator{PublicKey: key.PublicKey(), Stake: stake})
+	}
+	chain.engine = NewProofOfStake(chain.validators)
+	chain.bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(chain.engine))
+	return chain
+}
+
+// proposer returns the key of the proposer of the child of parent in round.
+func (c *posChain) proposer(t *testing.T, parent *types.BlockNode, round uint64) *wallet.Key {
+	t.Helper()
+	proposer, err := c.engine.Proposer(parent, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.keys[string(proposer)]
+}
+
+// propose produces a block on top of parent in round, signed by its proposer.
+// Rounds last a second from the old fixture genesis on, so sealing does not wait.
+func (c *posChain) propose(t *testing.T, parent *types.BlockNode, round uint64, transactions ...types.Transaction) *types.Block {
+	t.Helper()
+	c.engine.Authorize(c.proposer(t, parent, round))
+	block := &types.Block{
+		Index:        parent.Block.Index + 1,
+		Timestamp:    parent.Block.Timestamp + round + 1,
+		PreviousHash: parent.Hash,
+		Transactions: transactions,
+	}
+	if err := c.engine.Prepare(parent, block); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.engine.Finalize(parent, block); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.engine.Seal(block, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+// add proposes a block on top of the head in the first round and adds it.
+func (c *posChain) add(t *testing.T, transactions ...types.Transaction) *types.BlockNode {
+	t.Helper()
+	head := c.bc.GetBlock(c.bc.GetLatestBlock().CalculateHash())
+	block := c.propose(t, head, 0, transactions...)
+	if err := c.bc.AddBlock(head, block); err != nil {
+		t.Fatal(err)
+	}
+	return c.bc.GetBlock(block.CalculateHash())
+}
+
+// stakeOf returns the stake of key after node.
+func (c *posChain) stakeOf(t *testing.T, node *types.BlockNode, key *wallet.Key) uint64 {
+	t.Helper()
+	validators, err := c.engine.Validators(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, validator := range validators {
+		if bytes.Equal(validator.PublicKey, key.PublicKey()) {
+			return validator.Stake
+		}
+	}
+	return 0
+}
+
+// stake returns a signed stake or unstake transaction of key with the given nonce.
+func stake(key *wallet.Key, kind types.TransactionType, amount, nonce uint64) types.Transaction {
+	tx := types.Transaction{Type: kind, Amount: amount, Nonce: nonce}
+	key.SignTransaction(&tx, "")
+	return tx
+}
+
+// doubleSign returns two different blocks on top of parent signed by the
+// proposer of its first round.
+func (c *posChain) doubleSign(t *testing.T, parent *types.BlockNode) (*types.Block, *types.Block) {
+	t.Helper()
+	first := c.propose(t, parent, 0)
+	second := c.propose(t, parent, 0, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1})
+	return first, second
+}
+
+func TestProofOfStakeProposers(t *testing.T) {
+	chain := newPoSChain(t, 1, 1000)
+	genesis := chain.bc.GetRoot()
+
+	heavy := 0
+	for round := uint64(0); round < 200; round++ {
+		if chain.proposer(t, genesis, round) == chain.keys[string(chain.validators[1].PublicKey)] {
+			heavy++
+		}
+	}
+	if heavy < 150 {
+		t.Errorf("Expected proposers to be weighted by stake, the heavy validator proposed %d of 200 rounds", heavy)
+	}
+	other := NewProofOfStake(chain.validators)
+	if got, _ := other.Proposer(NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(other)).GetRoot(), 7); !bytes.Equal(got, chain.proposer(t, genesis, 7).PublicKey()) {
+		t.Errorf("Expected every node to draw the same proposer")
+	}
+
+	first := chain.add(t)
+	if first.TotalWork.Int64() != 2 {
+		t.Errorf("Expected a block of the first round to weigh 2, got %v", first.TotalWork)
+	}
+	late := chain.propose(t, genesis, 3)
+	if err := chain.bc.AddBlock(genesis, late); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chain.bc.GetLatestBlock().CalculateHash(), first.Hash) {
+		t.Errorf("Expected the block of the first round to stay the head")
+	}
+
+	// A block signed by another validator than the proposer of its round.
+	wrong := chain.propose(t, first, 0)
+	for _, validator := range chain.validators {
+		if !bytes.Equal(validator.PublicKey, wrong.Signer) {
+			key := chain.keys[string(validator.PublicKey)]
+			wrong.Signer = key.PublicKey()
+			wrong.Signature = key.Sign(wrong.SealHash())
+			break
+		}
+	}
+	if err := chain.bc.AddBlock(first, wrong); !errors.Is(err, ErrWrongProposer) {
+		t.Errorf("Expected a block of another validator to be refused, got %v", err)
+	}
+	if Penalty(ErrWrongProposer) != BanScore {
+		t.Errorf("Expected peers relaying blocks of the wrong proposer to be banned")
+	}
+
+	early := chain.propose(t, first, 0)
+	key := chain.keys[string(early.Signer)]
+	early.Timestamp = first.Block.Timestamp
+	early.Signature = key.Sign(early.SealHash())
+	if err := chain.engine.VerifyHeader(first, early); !errors.Is(err, ErrBlockTooEarly) {
+		t.Errorf("Expected a block before the first round to be refused, got %v", err)
+	}
+}
+
+func TestProofOfStakeStaking(t *testing.T) {
+	chain := newPoSChain(t, 100)
+	newcomer := newKey(t)
+	chain.keys[string(newcomer.PublicKey())] = newcomer
+	chain.engine = NewProofOfStake(append(chain.validators, Validator{PublicKey: newcomer.PublicKey(), Funds: 80}))
+	chain.bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(chain.engine))
+
+	node := chain.add(t, stake(newcomer, types.StakeTransaction, 50, 1), stake(newcomer, types.StakeTransaction, 25, 2))
+	if got := chain.stakeOf(t, node, newcomer); got != 75 {
+		t.Fatalf("Expected a stake of 75, got %d", got)
+	}
+	if validators, _ := chain.engine.Validators(node); validators[0].Funds+validators[1].Funds != 5 {
+		t.Errorf("Expected staking to lock the funds of the newcomer, got %v", validators)
+	}
+	if got := chain.stakeOf(t, chain.bc.GetRoot(), newcomer); got != 0 {
+		t.Errorf("Expected stakes to be recorded per block, got %d at genesis", got)
+	}
+
+	node = chain.add(t, stake(newcomer, types.UnstakeTransaction, 70, 3))
+	if got := chain.stakeOf(t, node, newcomer); got != 5 {
+		t.Errorf("Expected a stake of 5 after unstaking, got %d", got)
+	}
+
+	var txErr *TransactionError
+	for _, tx := range []types.Transaction{
+		stake(newcomer, types.UnstakeTransaction, 6, 4),
+		stake(newcomer, types.StakeTransaction, 0, 4),
+		stake(newcomer, types.StakeTransaction, 76, 4),
+		stake(newcomer, types.UnstakeTransaction, 1, 3),
+		stake(newKey(t), types.StakeTransaction, 1, 1),
+		{Type: types.StakeTransaction, Sender: []byte("Alice"), Amount: 10},
+	} {
+		invalid := chain.propose(t, node, 0, tx)
+		if err := chain.bc.AddBlock(node, invalid); !errors.As(err, &txErr) || !errors.Is(err, ErrInvalidStake) {
+			t.Errorf("Expected an invalid stake transaction to be refused, got %v", err)
+		}
+	}
+
+	node = chain.add(t, stake(newcomer, types.UnstakeTransaction, 5, 4))
+	if validators, _ := chain.engine.Validators(node); len(validators) != 1 {
+		t.Errorf("Expected a validator without stake to leave the set, got %d validators", len(validators))
+	}
+}
+
+func TestProofOfStakeUnstakeReplay(t *testing.T) {
+	chain := newPoSChain(t, 100, 100)
+	validator := chain.keys[string(chain.validators[0].PublicKey)]
+
+	unstake := stake(validator, types.UnstakeTransaction, 10, 1)
+	node := chain.add(t, unstake)
+	if got := chain.stakeOf(t, node, validator); got != 90 {
+		t.Fatalf("Expected a stake of 90 after unstaking, got %d", got)
+	}
+	if nonce, err := chain.engine.NextStakeNonce(node, validator.PublicKey()); err != nil || nonce != 2 {
+		t.Errorf("Expected the next stake nonce to be 2, got %d (%v)", nonce, err)
+	}
+	decoded := types.BlockFromProto(node.Block.ToProto())
+	if !bytes.Equal(decoded.CalculateHash(), node.Hash) {
+		t.Errorf("Expected the nonce of a stake transaction to survive encoding")
+	}
+
+	// The same signed transaction, relayed again in a later block.
+	node = chain.add(t)
+	replay := chain.propose(t, node, 0, unstake)
+	var txErr *TransactionError
+	if err := chain.bc.AddBlock(node, replay); !errors.As(err, &txErr) || !errors.Is(err, ErrInvalidStake) {
+		t.Fatalf("Expected a replayed unstake transaction to be refused, got %v", err)
+	}
+	node = chain.add(t, stake(validator, types.UnstakeTransaction, 10, 2))
+	if got := chain.stakeOf(t, node, validator); got != 80 {
+		t.Errorf("Expected a stake of 80 after unstaking again, got %d", got)
+	}
+}
+
+func TestProofOfStakeSnapshotsAndClock(t *testing.T) {
+	chain := newPoSChain(t, 100)
+	chain.bc = NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(chain.engine), WithCheckpointInterval(0))
+	first := chain.add(t)
+	for i := 0; i < SnapshotDepth+1; i++ {
+		chain.add(t)
+	}
+
+	// The snapshot after the first block has been pruned, so it is recomputed
+	// for a late block on top of it, and the work of the block does not depend on it.
+	late := chain.propose(t, first, 2)
+	if err := chain.bc.AddBlock(first, late); err != nil {
+		t.Fatal(err)
+	}
+	if work := chain.bc.GetBlock(late.CalculateHash()).TotalWork.Int64(); work != 3 {
+		t.Errorf("Expected the late block to weigh 3 in total, got %d", work)
+	}
+	lying := chain.propose(t, first, 3)
+	lying.Data = 2
+	lying.Signature = chain.keys[string(lying.Signer)].Sign(lying.SealHash())
+	if err := chain.bc.AddBlock(first, lying); !errors.Is(err, ErrWrongTurn) {
+		t.Errorf("Expected a late block declaring the work of the first round to be refused, got %v", err)
+	}
+
+	// The fixture timestamps are long past, but the clock of the blockchain is
+	// an hour before them, so the proposer has to wait.
+	clock := NewManualClock(time.Unix(fixtureTimestamp, 0).Add(-time.Hour))
+	engine := NewProofOfStake(chain.validators)
+	bc := NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(engine), WithClock(clock))
+	engine.Authorize(chain.keys[string(chain.validators[0].PublicKey)])
+	stop := make(chan struct{})
+	close(stop)
+	if _, err := engine.Seal(bc.GenerateNewBlock(nil), stop); !errors.Is(err, ErrSealAborted) {
+		t.Errorf("Expected the proposer to wait for the clock of the blockchain, got %v", err)
+	}
+}
+
+func TestProofOfStakeSlashing(t *testing.T) {
+	chain := newPoSChain(t, 100, 100, 100)
+	var reported []types.DoubleSignEvidence
+	chain.engine.OnEvidence(func(evidence types.DoubleSignEvidence) {
+		reported = append(reported, evidence)
+	})
+
+	genesis := chain.bc.GetRoot()
+	first, second := chain.doubleSign(t, genesis)
+	offender := chain.keys[string(first.Signer)]
+	for _, block := range []*types.Block{first, second} {
+		if err := chain.bc.AddBlock(genesis, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(reported) != 1 || len(chain.engine.PendingEvidence()) != 1 {
+		t.Fatalf("Expected the double signing to be detected, got %d reports", len(reported))
+	}
+	if err := chain.engine.ReportEvidence(reported[0]); !errors.Is(err, ErrEvidenceKnown) {
+		t.Errorf("Expected known evidence to be reported as such, got %v", err)
+	}
+
+	node := chain.add(t)
+	if len(node.Block.Evidence) != 1 {
+		t.Fatalf("Expected the next block to include the evidence, got %d", len(node.Block.Evidence))
+	}
+	if got := chain.stakeOf(t, node, offender); got != 0 {
+		t.Errorf("Expected the offender to be slashed, got a stake of %d", got)
+	}
+	if len(chain.engine.PendingEvidence()) != 1 {
+		t.Errorf("Expected the evidence to stay pending until a block on top of the slashing is finalized")
+	}
+	next := chain.propose(t, node, 0)
+	if len(next.Evidence) != 0 || len(chain.engine.PendingEvidence()) != 0 {
+		t.Errorf("Expected the evidence to be dropped once the offender is slashed")
+	}
+
+	// The same evidence cannot slash twice, and the offender cannot stake again.
+	again := chain.propose(t, node, 0)
+	again.Evidence = reported
+	key := chain.keys[string(again.Signer)]
+	again.Signature = key.Sign(again.SealHash())
+	if err := chain.bc.AddBlock(node, again); !errors.Is(err, ErrInvalidEvidence) {
+		t.Errorf("Expected evidence against a slashed validator to be refused, got %v", err)
+	}
+	restake := chain.propose(t, node, 0, stake(offender, types.StakeTransaction, 10, 1))
+	if err := chain.bc.AddBlock(node, restake); !errors.Is(err, ErrInvalidStake) {
+		t.Errorf("Expected a slashed validator not to stake again, got %v", err)
+	}
+
+	same := types.DoubleSignEvidence{First: first, Second: first}
+	if err := chain.engine.ReportEvidence(same); !errors.Is(err, ErrInvalidEvidence) {
+		t.Errorf("Expected evidence with the same block twice to be refused, got %v", err)
+	}
+	forged := *second
+	forged.Signature = first.Signature
+	if err := chain.engine.ReportEvidence(types.DoubleSignEvidence{First: first, Second: &forged}); !errors.Is(err, ErrInvalidEvidence) {
+		t.Errorf("Expected evidence with a forged signature to be refused, got %v", err)
+	}
+
+	decoded := types.BlockFromProto(node.Block.ToProto())
+	if !bytes.Equal(decoded.CalculateHash(), node.Hash) {
+		t.Errorf("Expected a block with evidence to survive encoding")
+	}
+}
+
+func TestProofOfStakeEvidenceGossip(t *testing.T) {
+	chain := newPoSChain(t, 100, 100)
+	network := NewSimNetwork(1)
+	newNode := func(address string, peers ...string) (*Node, *ProofOfStake) {
+		engine := NewProofOfStake(chain.validators)
+		return newSimNode(t, network, address, NewBlockchainFromGenesis(fixtureGenesis(), WithConsensusEngine(engine)), peers...), engine
+	}
+	a, _ := newNode("a")
+	b, engineB := newNode("b", "a")
+	network.Run()
+
+	first, second := chain.doubleSign(t, chain.bc.GetRoot())
+	a.BroadcastEvidence(types.DoubleSignEvidence{First: first, Second: second})
+	network.Run()
+	if pending := engineB.PendingEvidence(); len(pending) != 1 {
+		t.Fatalf("Expected b to receive the evidence, got %d", len(pending))
+	}
+
+	a.BroadcastEvidence(types.DoubleSignEvidence{First: first, Second: first})
+	network.Run()
+	if len(engineB.PendingEvidence()) != 1 || !b.GetPeerScores().Banned("a") {
+		t.Errorf("Expected b to refuse invalid evidence and ban its sender")
+	}
+}
+
+func TestConfigProofOfStake(t *testing.T) {
+	key := newKey(t)
+	config := DefaultConfig()
+	config.Network = NetworkPrivate
+	config.Chain.ChainID = "acme"
+	config.Chain.Consensus = ConsensusProofOfStake
+	config.Chain.Validators = []string{fmt.Sprintf("%s:%d", hex.EncodeToString(key.PublicKey()), 500)}
+	config.Node.Signer = key.Address()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := profile.NewBlockchain(config.BlockchainOptions(nil)...)
+	engine, ok := bc.GetConsensusEngine().(*ProofOfStake)
+	if !ok {
+		t.Fatalf("Expected the proof-of-stake engine, got %T", bc.GetConsensusEngine())
+	}
+	if validators, _ := engine.Validators(bc.GetRoot()); len(validators) != 1 || validators[0].Stake != 500 {
+		t.Errorf("Expected the configured validator, got %v", validators)
+	}
+
+	config.Chain.Validators = append(config.Chain.Validators, hex.EncodeToString(newKey(t).PublicKey())+":0:300")
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected a validator with only unstaked funds to be accepted, got %v", err)
+	}
+	for _, validator := range []string{hex.EncodeToString(key.PublicKey()), hex.EncodeToString(key.PublicKey()) + ":0", hex.EncodeToString(key.PublicKey()) + ":1:2:3", "0123:10"} {
+		config.Chain.Validators = []string{validator}
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validator %q to be refused", validator)
+		}
+	}
+	config.Chain.Validators = nil
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected proof of stake without validators to be refused")
+	}
+}
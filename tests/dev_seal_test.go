@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// newDevNode creates a node of the dev network that seals blocks in the given mode.
+func newDevNode(t *testing.T, mode SealMode) (*Node, NetworkProfile) {
+	t.Helper()
+	dev, err := LookupNetwork(NetworkDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.CheckSealMode(mode); err != nil {
+		t.Fatal(err)
+	}
+	return dev.NewNode(dev.NewBlockchain(WithoutProofOfWork()), "", WithSealMode(mode)), dev
+}
+
+func TestInstantSeal(t *testing.T) {
+	node, dev := newDevNode(t, SealInstant)
+	for i := 1; i <= 3; i++ {
+		if err := node.SubmitTransaction(signedTransaction(t, dev.ChainID)); err != nil {
+			t.Fatal(err)
+		}
+		head := node.GetBlockchain().GetLatestBlock()
+		if head.Index != uint64(i) || len(head.Transactions) != 1 {
+			t.Errorf("Expected block %d with the submitted transaction, got block %d with %d transactions", i, head.Index, len(head.Transactions))
+		}
+	}
+	if size := node.GetMempool().Size(); size != 0 {
+		t.Errorf("Expected the mempool to be empty, got %d transactions", size)
+	}
+}
+
+func TestManualSealRPC(t *testing.T) {
+	node, dev := newDevNode(t, SealManual)
+	server := httptest.NewServer(NewDevRPCServer("", node).Handler)
+	defer server.Close()
+
+	if err := node.SubmitTransaction(signedTransaction(t, dev.ChainID)); err != nil {
+		t.Fatal(err)
+	}
+	if height := node.GetBlockchain().GetLatestBlock().Index; height != 0 {
+		t.Fatalf("Expected no block before mining, got height %d", height)
+	}
+
+	for i, want := range []int{1, 0} {
+		resp, err := http.Post(server.URL+"/mine", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sealed struct {
+			Height       uint64 `json:"height"`
+			Transactions int    `json:"transactions"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&sealed)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sealed.Height != uint64(i+1) || sealed.Transactions != want {
+			t.Errorf("Expected block %d with %d transactions, got %+v", i+1, want, sealed)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be refused, got %s", resp.Status)
+	}
+}
+
+func TestSealModeRefusedOnOtherNetworks(t *testing.T) {
+	for _, network := range []string{NetworkTestnet, NetworkPrivate} {
+		config := DefaultConfig()
+		config.Network = network
+		if network == NetworkPrivate {
+			config.Chain.ChainID = "acme"
+		}
+		config.Node.Seal = SealInstant
+		if err := config.Validate(); !errors.Is(err, ErrInstantSealNotAllowed) {
+			t.Errorf("Expected instant sealing to be refused on %s, got %v", network, err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.Network = NetworkDev
+	config.Node.Seal = SealManual
+	config.Node.RPCAddress = "localhost:18545"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected manual sealing on the dev network to be valid, got %v", err)
+	}
+	config.Node.Seal = SealProofOfWork
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected the dev RPC to need a seal mode without proof of work")
+	}
+	config.Node.Seal = "fast"
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an unknown seal mode to be refused")
+	}
+}
+
+func TestInstantSealRefusedByBlockchain(t *testing.T) {
+	testnet, err := LookupNetwork(NetworkTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, bc := range map[string]*Blockchain{
+		NetworkTestnet: testnet.NewBlockchain(WithoutProofOfWork()),
+		"acme":         NewBlockchain(WithChainID("acme"), WithoutProofOfWork()),
+	} {
+		node := NewNode(bc, "", WithSealMode(SealManual))
+		if _, err := node.Seal(); !errors.Is(err, ErrInstantSealNotAllowed) {
+			t.Errorf("Expected sealing without proof of work to be refused on %s, got %v", name, err)
+		}
+		block := bc.GenerateNewBlock(nil)
+		if err := bc.AddBlock(bc.GetRoot(), block); !errors.Is(err, ErrInstantSealNotAllowed) {
+			t.Errorf("Expected blocks without proof of work to be refused on %s, got %v", name, err)
+		}
+	}
+}
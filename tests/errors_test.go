@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	block_chain "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func TestBlockchainErrorsAreTyped(t *testing.T) {
+	bc := newFixtureBlockchain()
+	blocks := fixtureBlocks("main", 2)
+	genesis := bc.GetRoot()
+
+	wrongIndex := *blocks[0]
+	wrongIndex.Index = 5
+	if err := bc.ValidateBlock(&wrongIndex, genesis.Block); !errors.Is(err, ErrInvalidIndex) {
+		t.Errorf("Expected ErrInvalidIndex, got %v", err)
+	}
+	if err := bc.ValidateBlock(blocks[1], genesis.Block); !errors.Is(err, ErrInvalidIndex) {
+		t.Errorf("Expected ErrInvalidIndex, got %v", err)
+	}
+	wrongParent := *blocks[0]
+	wrongParent.PreviousHash = []byte("unknown")
+	if err := bc.ValidateBlock(&wrongParent, genesis.Block); !errors.Is(err, ErrPrevHashMismatch) {
+		t.Errorf("Expected ErrPrevHashMismatch, got %v", err)
+	}
+	unmined := *blocks[0]
+	unmined.Data = 0
+	if err := bc.ValidateBlock(&unmined, genesis.Block); !errors.Is(err, ErrInsufficientWork) {
+		t.Errorf("Expected ErrInsufficientWork, got %v", err)
+	}
+
+	if err := bc.AddBlock(&types.BlockNode{Block: blocks[0]}, blocks[1]); !errors.Is(err, ErrUnknownParent) {
+		t.Errorf("Expected ErrUnknownParent, got %v", err)
+	}
+	addFixtureBlocks(t, bc, blocks[:1])
+	if err := bc.AddBlock(genesis, blocks[0]); !errors.Is(err, ErrBlockExists) {
+		t.Errorf("Expected ErrBlockExists, got %v", err)
+	}
+	if err := bc.AdoptChain([]*types.Block{&wrongParent}); !errors.Is(err, ErrNoCommonAncestor) {
+		t.Errorf("Expected ErrNoCommonAncestor, got %v", err)
+	}
+}
+
+func TestPenalty(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{ErrBlockExists, 0},
+		{ErrUnknownParent, 0},
+		{&PeerError{Address: "a", Kind: ErrPeerUnreachable, Err: errors.New("refused")}, 0},
+		{&PeerError{Address: "a", Kind: ErrPeerTimeout, Err: errors.New("i/o timeout")}, 5},
+		{fmt.Errorf("%w: 10 > 5", ErrTimestampTooFarInFuture), 10},
+		{fmt.Errorf("%w at height 10", ErrBelowCheckpoint), 20},
+		{fmt.Errorf("block 3: %w", ErrInsufficientWork), BanScore},
+		{ErrPrevHashMismatch, BanScore},
+		{fmt.Errorf("%w at height 10", ErrCheckpointConflict), BanScore},
+		{&TransactionError{Position: 1, Err: ErrZeroAmount}, BanScore},
+		{types.ErrAmountOverflow, BanScore},
+		{errors.New("disk full"), 0},
+	}
+
+	for _, tt := range tests {
+		if got := Penalty(tt.err); got != tt.want {
+			t.Errorf("Penalty(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPeerErrors(t *testing.T) {
+	node := NewNode(newFixtureBlockchain(), "a", WithTransport(NewMemoryTransport()))
+	node.AddNodes([]byte("nowhere"))
+
+	err := node.GetMessageSender().SendMsg([]byte("hello"))
+	if !errors.Is(err, ErrPeerUnreachable) {
+		t.Fatalf("Expected ErrPeerUnreachable, got %v", err)
+	}
+	var peerErr *PeerError
+	if !errors.As(err, &peerErr) || peerErr.Address != "nowhere" {
+		t.Errorf("Expected a PeerError for nowhere, got %v", err)
+	}
+	if score := node.GetPeerScores().Score("nowhere"); score != 0 {
+		t.Errorf("Expected an unreachable peer not to be penalized, got score %d", score)
+	}
+}
+
+func TestSimNetworkBansMisbehavingPeer(t *testing.T) {
+	network := NewSimNetwork(7)
+	node := newSimNode(t, network, "a", newFixtureBlockchain())
+	evil, err := network.Dial("evil", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendBlockResponse := func(block *types.Block) {
+		data, err := EncodeBlockMessage(&block_chain.BlockMessage{
+			BlockMessageType: &block_chain.BlockMessage_BlockResponse{
+				BlockResponse: &block_chain.BlockResponse{Success: true, Block: block.ToProto()},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		evil.SendMsg(data)
+		network.Run()
+	}
+
+	valid := fixtureBlocks("main", 1)[0]
+	unmined := *valid
+	unmined.Data = 0
+	sendBlockResponse(&unmined)
+
+	if !node.GetPeerScores().Banned("evil") {
+		t.Fatalf("Expected a peer sending a block without proof of work to be banned, got score %d", node.GetPeerScores().Score("evil"))
+	}
+
+	sendBlockResponse(valid)
+	if node.GetBlockchain().BlockExists(valid.CalculateHash()) {
+		t.Errorf("Expected blocks from a banned peer to be ignored")
+	}
+}
+
+func TestPeerScoresDecay(t *testing.T) {
+	clock := NewManualClock(time.Unix(fixtureTimestamp, 0))
+	node := NewNode(newFixtureBlockchain(), "a", WithNetworkClock(NewNetworkClock(clock)))
+	scores := node.GetPeerScores()
+
+	for i := 0; i < 3; i++ {
+		scores.Penalize("slow", ErrPeerTimeout)
+		clock.Advance(5 * ScoreDecayInterval)
+	}
+	if score := scores.Score("slow"); score != 0 {
+		t.Errorf("Expected timeouts spread over time not to add up, got score %d", score)
+	}
+	scores.Penalize("slow", ErrPeerTimeout)
+	clock.Advance(2 * ScoreDecayInterval)
+	if score := scores.Score("slow"); score != 3 {
+		t.Errorf("Expected the score to drop by one per interval, got %d", score)
+	}
+}
+
+func TestBannedPeerReconnects(t *testing.T) {
+	transport := NewMemoryTransport()
+	defer transport.Close()
+	clock := NewManualClock(time.Unix(fixtureTimestamp, 0))
+	node := NewNode(newFixtureBlockchain(), "a", WithTransport(transport), WithNetworkClock(NewNetworkClock(clock)))
+	if err := node.Join(); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// connect opens a new connection whose hello announces from. All memory
+	// connections come from the remote host "dialer".
+	connect := func(from string) interfaces.MessageSender {
+		network := NewStreamNetwork(transport)
+		t.Cleanup(func() { network.Close() })
+		sender, err := network.Dial(from, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sender
+	}
+	sendBlock := func(sender interfaces.MessageSender, block *types.Block) {
+		data, err := EncodeBlockMessage(&block_chain.BlockMessage{
+			BlockMessageType: &block_chain.BlockMessage_BlockResponse{
+				BlockResponse: &block_chain.BlockResponse{Success: true, Block: block.ToProto()},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sender.SendMsg(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	valid := fixtureBlocks("main", 1)[0]
+	unmined := *valid
+	unmined.Data = 0
+	sendBlock(connect("evil"), &unmined)
+	deadline := time.Now().Add(5 * time.Second)
+	for !node.GetPeerScores().Banned("dialer") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the peer to be banned by its remote host")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if node.GetPeerScores().Banned("evil") {
+		t.Errorf("Expected the ban not to apply to the address the peer announced")
+	}
+
+	// Announcing another address does not lift the ban.
+	sendBlock(connect("honest"), valid)
+	time.Sleep(100 * time.Millisecond)
+	if node.GetBlockchain().BlockExists(valid.CalculateHash()) {
+		t.Errorf("Expected a banned peer reconnecting under another address to be refused")
+	}
+
+	clock.Advance(BanDuration - time.Minute)
+	if !node.GetPeerScores().Banned("dialer") {
+		t.Fatalf("Expected the peer to stay banned until the ban ends")
+	}
+	clock.Advance(time.Minute)
+	if node.GetPeerScores().Banned("dialer") || node.GetPeerScores().Score("dialer") != 0 {
+		t.Fatalf("Expected the ban to end with a clean score")
+	}
+	sendBlock(connect("evil"), valid)
+	waitForHead(t, node, valid.CalculateHash())
+}
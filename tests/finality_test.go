@@ -0,0 +1,336 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// newValidatorKeys generates the keys of count finality validators.
+func newValidatorKeys(t *testing.T, count int) ([]*wallet.Key, []ed25519.PublicKey) {
+	t.Helper()
+	var keys []*wallet.Key
+	var validators []ed25519.PublicKey
+	for i := 0; i < count; i++ {
+		key := newKey(t)
+		keys = append(keys, key)
+		validators = append(validators, key.PublicKey())
+	}
+	return keys, validators
+}
+
+// signedVote returns a vote of key for block in the first round.
+func signedVote(key *wallet.Key, kind types.VoteType, block *types.Block) types.Vote {
+	return signedRoundVote(key, kind, block, 0)
+}
+
+// signedRoundVote returns a vote of key for block in round.
+func signedRoundVote(key *wallet.Key, kind types.VoteType, block *types.Block, round uint32) types.Vote {
+	vote := types.Vote{Type: kind, Height: block.Index, Round: round, BlockHash: block.CalculateHash()}
+	key.SignVote(&vote, "")
+	return vote
+}
+
+// addVotes adds the votes of keys for block in round to gadget.
+func addVotes(t *testing.T, gadget *FinalityGadget, keys []*wallet.Key, kind types.VoteType, block *types.Block, round uint32) {
+	t.Helper()
+	for _, key := range keys {
+		if err := gadget.AddVote(signedRoundVote(key, kind, block, round)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newVotingNode returns a node without peers whose finality gadget votes with key.
+func newVotingNode(t *testing.T, key *wallet.Key, validators []ed25519.PublicKey) (*Node, *Blockchain, *FinalityGadget) {
+	t.Helper()
+	bc := newFixtureBlockchain()
+	gadget := NewFinalityGadget(bc, validators)
+	gadget.Authorize(key)
+	node := NewNode(bc, "a", WithNetwork(NewSimNetwork(1)), WithFinality(gadget))
+	if err := node.Join(); err != nil {
+		t.Fatal(err)
+	}
+	return node, bc, gadget
+}
+
+// assertLatest checks that the latest block of bc is want.
+func assertLatest(t *testing.T, bc *Blockchain, want *types.Block) {
+	t.Helper()
+	if got := bc.GetLatestBlock(); !bytes.Equal(got.CalculateHash(), want.CalculateHash()) {
+		t.Errorf("Expected the head at height %d, got height %d", want.Index, got.Index)
+	}
+}
+
+func TestFinalizedCheckpointIsNeverReverted(t *testing.T) {
+	_, validators := newValidatorKeys(t, 1)
+	bc := newFixtureBlockchain()
+	NewFinalityGadget(bc, validators)
+	main := fixtureBlocks("main", 12)
+	addFixtureBlocks(t, bc, main)
+
+	if checkpoints := bc.GetCheckpoints(); len(checkpoints) != 0 {
+		t.Fatalf("Expected no automatic checkpoint with a finality gadget, got %v", checkpoints)
+	}
+	if err := bc.FinalizeCheckpoint(Checkpoint{Height: 10, Hash: main[9].CalculateHash()}); err != nil {
+		t.Fatal(err)
+	}
+	if !bc.GetBlockByHeight(10).Block.Checkpoint {
+		t.Errorf("Expected the finalized block to be flagged as a checkpoint")
+	}
+	if finalized := bc.GetFinalized(); finalized == nil || finalized.Height != 10 {
+		t.Errorf("Expected the checkpoint at height 10 to be final, got %v", finalized)
+	}
+
+	// The "late" branch forks at height 8 and has more work than "main".
+	for _, block := range fixtureBlocks("late", 13) {
+		if parent := bc.GetBlock(block.PreviousHash); parent != nil {
+			_ = bc.AddBlock(parent, block)
+		}
+	}
+	assertLatest(t, bc, main[11])
+
+	if err := bc.FinalizeCheckpoint(Checkpoint{Height: 10, Hash: fixtureBlocks("late", 10)[1].CalculateHash()}); !errors.Is(err, ErrCheckpointConflict) {
+		t.Errorf("Expected a conflicting final checkpoint to be refused, got %v", err)
+	}
+}
+
+func TestFinalizingCheckpointSwitchesBranch(t *testing.T) {
+	_, validators := newValidatorKeys(t, 1)
+	bc := newFixtureBlockchain()
+	NewFinalityGadget(bc, validators)
+	main := fixtureBlocks("main", 12)
+	late := fixtureBlocks("late", 13)
+	addFixtureBlocks(t, bc, main[:8])
+	addFixtureBlocks(t, bc, late)
+	addFixtureBlocks(t, bc, main[8:])
+	assertLatest(t, bc, late[4])
+
+	if err := bc.FinalizeCheckpoint(Checkpoint{Height: 10, Hash: main[9].CalculateHash()}); err != nil {
+		t.Fatal(err)
+	}
+	assertLatest(t, bc, main[11])
+	if !bc.GetBlockByHeight(10).Block.Checkpoint {
+		t.Errorf("Expected the finalized block to be flagged as a checkpoint")
+	}
+}
+
+func TestFinalityVotes(t *testing.T) {
+	keys, validators := newValidatorKeys(t, 4)
+	bc := newFixtureBlockchain()
+	gadget := NewFinalityGadget(bc, validators)
+	main := fixtureBlocks("main", 12)
+	addFixtureBlocks(t, bc, main)
+	late := fixtureBlocks("late", 10)[1]
+
+	if err := gadget.AddVote(signedVote(newKey(t), types.Prevote, main[9])); !errors.Is(err, ErrInvalidFinalityVote) {
+		t.Errorf("Expected a vote of an unknown validator to be refused, got %v", err)
+	}
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, main[8])); !errors.Is(err, ErrInvalidFinalityVote) {
+		t.Errorf("Expected a vote below no checkpoint height to be refused, got %v", err)
+	}
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, &types.Block{Index: 30})); !errors.Is(err, ErrInvalidFinalityVote) {
+		t.Errorf("Expected a vote more than an interval above the head to be refused, got %v", err)
+	}
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, &types.Block{Index: 20})); err != nil {
+		t.Errorf("Expected a vote for the next checkpoint to be counted, got %v", err)
+	}
+	forged := signedVote(keys[0], types.Prevote, main[9])
+	forged.BlockHash = late.CalculateHash()
+	if err := gadget.AddVote(forged); !errors.Is(err, ErrInvalidFinalityVote) {
+		t.Errorf("Expected a vote with an invalid signature to be refused, got %v", err)
+	}
+
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, main[9])); err != nil {
+		t.Fatal(err)
+	}
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, main[9])); !errors.Is(err, ErrVoteKnown) {
+		t.Errorf("Expected a repeated vote to be known, got %v", err)
+	}
+	if err := gadget.AddVote(signedVote(keys[0], types.Prevote, late)); !errors.Is(err, ErrConflictingVote) {
+		t.Errorf("Expected a conflicting vote to be refused, got %v", err)
+	}
+
+	for _, key := range keys[:3] {
+		if err := gadget.AddVote(signedVote(key, types.Precommit, main[9])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	certificate := gadget.Certificate(0)
+	if certificate == nil || certificate.Height != 10 || len(certificate.Precommits) != 3 {
+		t.Fatalf("Expected three precommits to finalize height 10, got %v", certificate)
+	}
+	if finalized := bc.GetFinalized(); finalized == nil || !bytes.Equal(finalized.Hash, main[9].CalculateHash()) {
+		t.Errorf("Expected the blockchain to finalize the block, got %v", finalized)
+	}
+
+	other := newFixtureBlockchain()
+	otherGadget := NewFinalityGadget(other, validators)
+	short := *certificate
+	short.Precommits = certificate.Precommits[:2]
+	if err := otherGadget.AddCertificate(&short); !errors.Is(err, ErrInvalidCertificate) {
+		t.Errorf("Expected a certificate without a quorum to be refused, got %v", err)
+	}
+	if err := otherGadget.AddCertificate(certificate); err != nil {
+		t.Fatal(err)
+	}
+	if other.GetFinalized() == nil {
+		t.Errorf("Expected a valid certificate to finalize the checkpoint")
+	}
+}
+
+func TestFinalityRevotesAfterSplitPrevotes(t *testing.T) {
+	keys, validators := newValidatorKeys(t, 4)
+	node, bc, gadget := newVotingNode(t, keys[0], validators)
+	main := fixtureBlocks("main", 12)
+	late := fixtureBlocks("late", 10)[1]
+	publishBlocks(t, node, main[:11])
+
+	// Two validators prevote for another block, so no block gets a quorum.
+	addVotes(t, gadget, keys[1:2], types.Prevote, main[9], 0)
+	addVotes(t, gadget, keys[2:], types.Prevote, late, 0)
+	publishBlocks(t, node, main[11:])
+
+	addVotes(t, gadget, keys[1:3], types.Prevote, main[9], 1)
+	addVotes(t, gadget, keys[1:3], types.Precommit, main[9], 1)
+	certificate := gadget.Certificate(0)
+	if certificate == nil || certificate.Round != 1 || !bytes.Equal(certificate.BlockHash, main[9].CalculateHash()) {
+		t.Fatalf("Expected the validator to vote again and finalize height 10 in round 1, got %v", certificate)
+	}
+	if finalized := bc.GetFinalized(); finalized == nil || finalized.Height != 10 {
+		t.Errorf("Expected the blockchain to finalize height 10, got %v", finalized)
+	}
+}
+
+func TestFinalityRevotesAfterReorganization(t *testing.T) {
+	keys, validators := newValidatorKeys(t, 4)
+	node, bc, gadget := newVotingNode(t, keys[0], validators)
+	main := fixtureBlocks("main", 12)
+	late := fixtureBlocks("late", 13)
+	publishBlocks(t, node, main[:11])
+
+	// The "late" branch forks at height 8 and has more work than "main".
+	publishBlocks(t, node, late)
+	assertLatest(t, bc, late[4])
+
+	addVotes(t, gadget, keys[1:3], types.Prevote, late[1], 1)
+	addVotes(t, gadget, keys[1:3], types.Precommit, late[1], 1)
+	if finalized := bc.GetFinalized(); finalized == nil || !bytes.Equal(finalized.Hash, late[1].CalculateHash()) {
+		t.Errorf("Expected the validator to prevote for the new canonical block and finalize it, got %v", finalized)
+	}
+}
+
+func TestFinalityCertificatesSurviveRestart(t *testing.T) {
+	keys, validators := newValidatorKeys(t, 4)
+	path := filepath.Join(t.TempDir(), "certificates.json")
+	main := fixtureBlocks("main", 12)
+
+	bc := newFixtureBlockchain()
+	gadget := NewFinalityGadget(bc, validators, WithCertificateFile(path))
+	addFixtureBlocks(t, bc, main)
+	addVotes(t, gadget, keys[:3], types.Precommit, main[9], 0)
+	if bc.GetFinalized() == nil {
+		t.Fatalf("Expected three precommits to finalize height 10")
+	}
+
+	restarted := newFixtureBlockchain()
+	restartedGadget := NewFinalityGadget(restarted, validators, WithCertificateFile(path))
+	if finalized := restarted.GetFinalized(); finalized == nil || !bytes.Equal(finalized.Hash, main[9].CalculateHash()) {
+		t.Errorf("Expected the checkpoint to be final after a restart, got %v", finalized)
+	}
+	if certificate := restartedGadget.Certificate(10); certificate == nil || len(certificate.Precommits) != 3 {
+		t.Errorf("Expected the certificate to be reloaded, got %v", certificate)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"height": 10, "hash": "00", "precommits": []}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	forged := newFixtureBlockchain()
+	NewFinalityGadget(forged, validators, WithCertificateFile(path))
+	if finalized := forged.GetFinalized(); finalized != nil {
+		t.Errorf("Expected a certificate without precommits to be ignored, got %v", finalized)
+	}
+}
+
+func TestSimNetworkFinalizesCheckpoints(t *testing.T) {
+	keys, validators := newValidatorKeys(t, 4)
+	main := fixtureBlocks("main", 12)
+	network := NewSimNetwork(1)
+	newNode := func(address string, key *wallet.Key, peers ...string) (*Node, *Blockchain) {
+		bc := newFixtureBlockchain()
+		gadget := NewFinalityGadget(bc, validators)
+		if key != nil {
+			gadget.Authorize(key)
+		}
+		node := NewNode(bc, address, WithNetwork(network), WithFinality(gadget), WithPeers(peers...))
+		if err := node.Join(); err != nil {
+			t.Fatal(err)
+		}
+		return node, bc
+	}
+
+	var nodes []*Node
+	var chains []*Blockchain
+	for i, address := range []string{"a", "b", "c", "d"} {
+		var peers []string
+		if i > 0 {
+			peers = []string{"a"}
+		}
+		node, bc := newNode(address, keys[i], peers...)
+		nodes = append(nodes, node)
+		chains = append(chains, bc)
+	}
+	network.Run()
+
+	publishBlocks(t, nodes[0], main)
+	network.Run()
+	for i, bc := range chains {
+		assertHead(t, nodes[i], main[11])
+		if finalized := bc.GetFinalized(); finalized == nil || !bytes.Equal(finalized.Hash, main[9].CalculateHash()) {
+			t.Errorf("Expected %s to finalize height 10, got %v", nodes[i].GetAddress(), finalized)
+		}
+	}
+
+	late, bc := newNode("e", nil, "a")
+	network.Run()
+	assertHead(t, late, main[11])
+	if finalized := bc.GetFinalized(); finalized == nil || finalized.Height != 10 {
+		t.Errorf("Expected a late node to receive the finality certificate, got %v", finalized)
+	}
+}
+
+func TestConfigFinalityValidators(t *testing.T) {
+	key := newKey(t)
+	config := DefaultConfig()
+	config.Network = NetworkPrivate
+	config.Chain.ChainID = "acme"
+	config.Node.Signer = key.Address()
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a proof-of-work signer without finality validators to be refused")
+	}
+	config.Chain.FinalityValidators = []string{hex.EncodeToString(key.PublicKey())}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gadget := config.FinalityGadget(profile.NewBlockchain(config.BlockchainOptions(nil)...))
+	if gadget == nil || len(gadget.Validators()) != 1 || !bytes.Equal(gadget.Validators()[0], key.PublicKey()) {
+		t.Errorf("Expected a finality gadget with the configured validator")
+	}
+
+	config.Chain.FinalityValidators = []string{"0123"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an invalid finality validator to be refused")
+	}
+}
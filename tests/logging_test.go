@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// logRecords decodes the JSON log records written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNodeLogsWithContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelInfo, LogFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := NewSimNetwork(6)
+	blocks := fixtureBlocks("main", 3)
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+	newSimNode(t, network, "a", source)
+	b := NewNode(newFixtureBlockchain(WithLogger(logger)), "b", WithNetwork(network), WithNodeLogger(logger))
+	b.AddNodes([]byte("a"))
+	if err := b.Join(); err != nil {
+		t.Fatal(err)
+	}
+	network.Run()
+	assertHead(t, b, blocks[len(blocks)-1])
+
+	accepted := 0
+	for _, record := range logRecords(t, &buf) {
+		if record["level"] == "DEBUG" {
+			t.Errorf("Expected no debug records at info level, got %v", record)
+		}
+		if record["msg"] != "Accepted block" {
+			continue
+		}
+		accepted++
+		if record[LogKeyNode] != "b" || record[LogKeyPeer] != "a" || record[LogKeyBlockHash] == nil || record[LogKeyHeight] == nil {
+			t.Errorf("Expected node, peer, block hash and height fields, got %v", record)
+		}
+	}
+	if accepted != len(blocks) {
+		t.Errorf("Expected %d accepted block records, got %d", len(blocks), accepted)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer that can be written from several goroutines.
+type lockedBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON log records written so far.
+func (b *lockedBuffer) records(t *testing.T) []map[string]any {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return logRecords(t, bytes.NewBuffer(b.buf.Bytes()))
+}
+
+func TestNetworkAndClockLogWithContext(t *testing.T) {
+	var buf lockedBuffer
+	logger, err := NewLogger(&buf, slog.LevelInfo, LogFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	clock := NewNetworkClock(SystemClock{})
+	node := NewNode(newFixtureBlockchain(), "a", WithTransport(memory), WithNetworkClock(clock), WithNodeLogger(logger))
+	if err := node.Join(); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	// A peer that hangs up before announcing its address.
+	conn, err := memory.Dial("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// Peers that first agree on a small adjustment, then on one that is too large.
+	now := time.Now()
+	for _, peer := range []string{"b", "c", "d"} {
+		clock.AddSample(peer, now.Add(time.Minute))
+	}
+	for _, peer := range []string{"e", "f", "g", "h"} {
+		clock.AddSample(peer, now.Add(2*MaxClockAdjustment))
+	}
+
+	want := map[string]bool{"Failed to read the address of the peer": false, "Peers disagree with the local clock, check the system time": false}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		for _, record := range buf.records(t) {
+			msg, _ := record["msg"].(string)
+			if _, ok := want[msg]; ok && record[LogKeyNode] == "a" {
+				want[msg] = true
+			}
+		}
+		if want["Failed to read the address of the peer"] && want["Peers disagree with the local clock, check the system time"] {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the network and the clock to log through the node's logger, got %v", want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, slog.LevelDebug, LogFormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("Received message", LogKeyMessageType, "welcome_request")
+	if !bytes.Contains(buf.Bytes(), []byte("level=DEBUG msg=\"Received message\" message_type=welcome_request")) {
+		t.Errorf("Expected a text record, got %q", buf.String())
+	}
+
+	if _, err := NewLogger(&buf, slog.LevelInfo, "xml"); err == nil {
+		t.Errorf("Expected an unknown log format to be refused")
+	}
+}
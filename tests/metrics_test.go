@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// scrape fetches /metrics from the server of m and returns the body.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	server := httptest.NewServer(NewMetricsServer("", m).Handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, got %s", contentType)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// assertSamples checks that the scraped metrics contain every sample line.
+func assertSamples(t *testing.T, metrics string, samples ...string) {
+	t.Helper()
+	for _, sample := range samples {
+		if !strings.Contains(metrics, "\n"+sample+"\n") {
+			t.Errorf("Expected sample %q in:\n%s", sample, metrics)
+		}
+	}
+}
+
+func TestBlockchainMetrics(t *testing.T) {
+	m := NewMetrics()
+	bc := newFixtureBlockchain(WithMetrics(m), WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+	addFixtureBlocks(t, bc, fixtureBlocks("late", 13))
+
+	if count := m.BlockValidationSeconds.Count(); count != 17 {
+		t.Errorf("Expected 17 validated blocks, got %d", count)
+	}
+	assertSamples(t, scrape(t, m),
+		"# TYPE blockchain_height gauge",
+		"blockchain_height 13",
+		"blockchain_forks 1",
+		"# TYPE blockchain_reorg_depth histogram",
+		`blockchain_reorg_depth_bucket{le="3"} 0`,
+		`blockchain_reorg_depth_bucket{le="5"} 1`,
+		`blockchain_reorg_depth_bucket{le="+Inf"} 1`,
+		"blockchain_reorg_depth_sum 4",
+		"blockchain_block_validation_seconds_count 17",
+	)
+}
+
+func TestNodeMetrics(t *testing.T) {
+	network := NewSimNetwork(5)
+	blocks := fixtureBlocks("main", 5)
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+	newSimNode(t, network, "a", source)
+
+	m := NewMetrics()
+	b := NewNode(newFixtureBlockchain(WithMetrics(m)), "b", WithNetwork(network), WithNodeMetrics(m))
+	b.AddNodes([]byte("a"))
+	if err := b.Join(); err != nil {
+		t.Fatal(err)
+	}
+	network.Run()
+	assertHead(t, b, blocks[len(blocks)-1])
+
+	if got := m.MessagesTotal.Value("in", "block_response"); got != 5 {
+		t.Errorf("Expected 5 block responses, got %d", got)
+	}
+	if got := m.MessagesTotal.Value("out", "welcome_request"); got != 1 {
+		t.Errorf("Expected 1 welcome request, got %d", got)
+	}
+	if m.BytesTotal.Value("in", "block_response") == 0 {
+		t.Errorf("Expected the bytes of block responses to be counted")
+	}
+
+	tx := types.Transaction{Sender: []byte("Carol"), Receiver: []byte("Dave"), Amount: 7}
+	if err := b.SubmitTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SubmitTransaction(tx); !errors.Is(err, ErrTransactionKnown) {
+		t.Errorf("Expected ErrTransactionKnown, got %v", err)
+	}
+	if err := b.SubmitTransaction(types.Transaction{Sender: []byte("Carol"), Receiver: []byte("Dave")}); !errors.Is(err, ErrZeroAmount) {
+		t.Errorf("Expected ErrZeroAmount, got %v", err)
+	}
+
+	assertSamples(t, scrape(t, m),
+		"blockchain_height 5",
+		"node_peers 1",
+		"node_orphan_blocks 0",
+		"node_mempool_transactions 1",
+		"node_blocks_rejected_total 0",
+		`network_messages_total{direction="out",type="welcome_request"} 1`,
+	)
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// mineBlock grinds a block with the given transactions on top of the head of bc.
+func mineBlock(t *testing.T, bc *Blockchain, transactions []types.Transaction) *types.Block {
+	t.Helper()
+	block := bc.GenerateNewBlock(transactions)
+	for {
+		err := bc.ValidateBlock(block, bc.GetLatestBlock())
+		if !errors.Is(err, ErrInsufficientWork) {
+			return block
+		}
+		block.Data++
+	}
+}
+
+// signedTransaction returns a transfer from a new key signed for chainID.
+func signedTransaction(t *testing.T, chainID string) types.Transaction {
+	t.Helper()
+	key, err := wallet.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.Transaction{Receiver: []byte(newKey(t).Address()), Amount: 10}
+	key.SignTransaction(&tx, chainID)
+	return tx
+}
+
+func TestNetworkProfiles(t *testing.T) {
+	genesisHashes := make(map[string]string)
+	for _, name := range Networks() {
+		profile, err := LookupNetwork(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == NetworkPrivate {
+			if err := profile.Validate(); err == nil {
+				t.Errorf("Expected the private network to need a chain ID")
+			}
+			profile.ChainID = "acme"
+		}
+		if err := profile.Validate(); err != nil {
+			t.Errorf("Expected network %s to be valid, got %v", name, err)
+		}
+		bc := profile.NewBlockchain()
+		if bc.ChainID() != profile.ChainID {
+			t.Errorf("Expected chain ID %s, got %s", profile.ChainID, bc.ChainID())
+		}
+		hash := string(bc.GetRoot().Block.CalculateHash())
+		if other, ok := genesisHashes[hash]; ok {
+			t.Errorf("Expected networks %s and %s to have different genesis blocks", name, other)
+		}
+		genesisHashes[hash] = name
+		if again := profile.NewBlockchain(); string(again.GetRoot().Block.CalculateHash()) != hash {
+			t.Errorf("Expected every node of network %s to share the genesis block", name)
+		}
+	}
+
+	if _, err := LookupNetwork("mainnet"); !errors.Is(err, ErrUnknownNetwork) {
+		t.Errorf("Expected ErrUnknownNetwork, got %v", err)
+	}
+}
+
+func TestSignedTransactionsDoNotReplayAcrossNetworks(t *testing.T) {
+	dev, err := LookupNetwork(NetworkDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := LookupNetwork(NetworkPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private.ChainID = "acme"
+	private.Difficulty = dev.Difficulty
+
+	tx := signedTransaction(t, dev.ChainID)
+	devChain := dev.NewBlockchain()
+	block := mineBlock(t, devChain, []types.Transaction{tx})
+	if err := devChain.AddBlock(devChain.GetRoot(), block); err != nil {
+		t.Fatalf("Expected the signed transaction to be valid on its network, got %v", err)
+	}
+
+	privateChain := private.NewBlockchain()
+	replayed := mineBlock(t, privateChain, []types.Transaction{tx})
+	if err := privateChain.AddBlock(privateChain.GetRoot(), replayed); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected the replayed transaction to be refused, got %v", err)
+	}
+
+	devNode := dev.NewNode(devChain, "")
+	if err := devNode.SubmitTransaction(signedTransaction(t, dev.ChainID)); err != nil {
+		t.Errorf("Expected the mempool to accept the transaction, got %v", err)
+	}
+	privateNode := private.NewNode(privateChain, "")
+	if err := privateNode.SubmitTransaction(tx); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected the mempool to refuse the replayed transaction, got %v", err)
+	}
+	if privateNode.GetAddress() != "localhost:28080" {
+		t.Errorf("Expected the default address of the private network, got %s", privateNode.GetAddress())
+	}
+}
+
+func TestSignatureRule(t *testing.T) {
+	ctx := &ValidationContext{ChainID: "gob-dev"}
+	tx := signedTransaction(t, "gob-dev")
+	if err := SignatureRule(ctx, &tx); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+
+	tampered := tx
+	tampered.Amount++
+	if err := SignatureRule(ctx, &tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a changed amount to break the signature, got %v", err)
+	}
+	stolen := tx
+	stolen.Sender = []byte("Mallory")
+	if err := SignatureRule(ctx, &stolen); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a sender other than the key's address to be refused, got %v", err)
+	}
+	resplit := tx
+	resplit.Receiver = append(append([]byte{}, tx.Receiver...), '1')
+	resplit.Amount = 0
+	if err := SignatureRule(ctx, &resplit); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected moving a digit from the amount to the receiver to break the signature, got %v", err)
+	}
+	key := newKey(t)
+	toName := types.Transaction{Receiver: []byte("Bob"), Amount: 10}
+	key.SignTransaction(&toName, "gob-dev")
+	if err := SignatureRule(ctx, &toName); !errors.Is(err, ErrInvalidReceiver) {
+		t.Errorf("Expected a receiver that is not an address to be refused, got %v", err)
+	}
+	unsigned := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 10}
+	if err := SignatureRule(ctx, &unsigned); err != nil {
+		t.Errorf("Expected unsigned transactions to be accepted, got %v", err)
+	}
+	if string(tx.CalculateHash()) == string((&types.Transaction{Sender: tx.Sender, Receiver: tx.Receiver, Amount: tx.Amount}).CalculateHash()) {
+		t.Errorf("Expected the transaction hash to commit to the signature")
+	}
+}
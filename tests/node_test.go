@@ -1,78 +1,20 @@
 package tests
 
 import (
-	"log"
 	"net"
 	"testing"
 	"time"
 
-	pb "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
 	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
-	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
 )
 
-func startNode(node *Node, address string) {
-	go node.Start()
-	time.Sleep(1 * time.Second)
-}
-
-func sendWelcomeRequest(node *Node, address string) {
-	conn, err := net.Dial("tcp", address)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer conn.Close()
-
-	welcomeRequest := &pb.WelcomeRequest{
-		Message: []byte(address),
-	}
-
-	data, err := EncodeMessage(welcomeRequest)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = conn.Write(data)
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func requestLatestBlock(node *Node, address string) {
-	conn, err := net.Dial("tcp", address)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer conn.Close()
-
-	latestBlockRequest := &pb.GetLatestBlockRequest{}
-	data, err := EncodeMessage(latestBlockRequest)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	_, err = conn.Write(data)
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func generateBlocks(num int) []*types.Block {
-	blocks := make([]*types.Block, num)
-	for i := 0; i < num; i++ {
-		block := &types.Block{
-			Index:        uint64(i + 1), // Indeks zaczyna się od 1, ponieważ 0 to blok genesis
-			Timestamp:    uint64(time.Now().Unix()),
-			Transactions: make([]types.Transaction, 0),
-			PreviousHash: []byte("0"),
-			Data:         uint64(i),
-		}
-		if i > 0 {
-			block.PreviousHash = blocks[i-1].CalculateHash()
-		}
-		blocks[i] = block
+// startNode makes node listen and greet the nodes it knows, and closes it when the test ends.
+func startNode(t *testing.T, node *Node) {
+	t.Helper()
+	if err := node.Join(); err != nil {
+		t.Fatalf("Failed to start node %s: %v", node.GetAddress(), err)
 	}
-	return blocks
+	t.Cleanup(func() { node.Close() })
 }
 
 func TestNewNode(t *testing.T) {
@@ -95,20 +37,23 @@ func TestNodeStart(t *testing.T) {
 	blockchain := NewBlockchain()
 	address := "127.0.0.1:8080"
 
-	// Tworzymy nowy węzeł
-	node := NewNode(blockchain, address)
+	// Tworzymy nowy węzeł; nie kopie on w tle, żeby nie wpływał na inne testy
+	node := NewNode(blockchain, address, WithSealMode(SealManual))
 
 	// Uruchamiamy serwer w osobnej gorutynie
 	go node.Start()
 
-	// Czekamy chwilę, aby serwer się uruchomił
-	time.Sleep(1 * time.Second)
-
-	// Próbujemy połączyć się z serwerem
+	// Próbujemy połączyć się z serwerem, aż zacznie nasłuchiwać
+	deadline := time.Now().Add(5 * time.Second)
 	conn, err := net.Dial("tcp", address)
+	for err != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		conn, err = net.Dial("tcp", address)
+	}
 	if err != nil {
 		t.Fatalf("Failed to connect to server: %v", err)
 	}
+	defer node.Close()
 	defer conn.Close()
 
 	// Sprawdzamy, czy połączenie zostało nawiązane
@@ -118,36 +63,34 @@ func TestNodeStart(t *testing.T) {
 }
 
 func TestNodeSync(t *testing.T) {
-	// Tworzymy dwa blockchainy
-	blockchain1 := NewBlockchain()
-	blockchain2 := NewBlockchain()
-
-	// Dodajemy bloki do blockchainów
-	for _, block := range generateBlocks(3) {
-		blockchain1.AddBlock(blockchain1.GetRoot(), block)
-	}
-	for _, block := range generateBlocks(7) {
-		blockchain2.AddBlock(blockchain2.GetRoot(), block)
-	}
-
-	// Tworzymy dwa nody
+	// Tworzymy dwa blockchainy z blokami stałego drzewa testowego
+	blocks := fixtureBlocks("main", 7)
+	blockchain1 := newFixtureBlockchain()
+	blockchain2 := newFixtureBlockchain()
+	addFixtureBlocks(t, blockchain1, blocks[:3])
+	addFixtureBlocks(t, blockchain2, blocks)
+
+	// Tworzymy dwa nody, które nie kopią, więc oba łańcuchy zmieniają się
+	// tylko przez synchronizację
 	address1 := "127.0.0.1:8081"
 	address2 := "127.0.0.1:8082"
-	node1 := NewNode(blockchain1, address1)
-	node2 := NewNode(blockchain2, address2)
+	node1 := NewNode(blockchain1, address1, WithSealMode(SealManual))
+	node2 := NewNode(blockchain2, address2, WithSealMode(SealManual))
 
-	// Uruchamiamy serwery w osobnych gorutynach
-	startNode(node1, address1)
-	startNode(node2, address2)
+	// Node1 zna Node2, więc po starcie wysyła mu powitanie, a w odpowiedzi
+	// na nie prosi o najnowszy blok. Od zmiany na MainMessage odpowiedź na
+	// zapytanie z osobnego połączenia nie trafiłaby do Node1.
+	node1.AddNodes([]byte(address2))
 
-	// Node1 wysyła zapytanie o najnowszy blok do Node2
-	requestLatestBlock(node1, address2)
+	// Uruchamiamy serwery; Join wraca, gdy node już nasłuchuje
+	startNode(t, node2)
+	startNode(t, node1)
 
-	// Czekamy chwilę, aby synchronizacja się zakończyła
-	time.Sleep(2 * time.Second)
+	// Czekamy, aż Node1 pobierze brakujące bloki
+	waitForHead(t, node1, blocks[len(blocks)-1].CalculateHash())
 
 	// Sprawdzamy, czy oba nody mają teraz taką samą liczbę bloków
-	if len(node1.GetBlockchain().GetRoot().Childs) != len(node2.GetBlockchain().GetRoot().Childs) {
-		t.Errorf("Expected both nodes to have the same number of blocks, but got %d and %d", len(node1.GetBlockchain().GetRoot().Childs), len(node2.GetBlockchain().GetRoot().Childs))
+	if blockchain1.GetLatestBlock().Index != blockchain2.GetLatestBlock().Index {
+		t.Errorf("Expected both nodes to have the same number of blocks, but got %d and %d", blockchain1.GetLatestBlock().Index, blockchain2.GetLatestBlock().Index)
 	}
 }
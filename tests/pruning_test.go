@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+func TestPruneStaleForks(t *testing.T) {
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+	fork := fixtureBlocks("fork", 3)
+	addFixtureBlocks(t, bc, fork)
+	late := fixtureBlocks("late", 11)
+	addFixtureBlocks(t, bc, late)
+
+	// The "fork" branch forked at genesis, 12 blocks below the head; "late" forked at height 8.
+	report := bc.Prune(PruneOptions{ForkDepth: 6})
+	if report.BranchesRemoved != 1 || report.BlocksRemoved != 3 {
+		t.Fatalf("Expected 1 branch of 3 blocks to be removed, got %+v", report)
+	}
+	if report.BytesReclaimed == 0 {
+		t.Errorf("Expected reclaimed memory to be reported")
+	}
+	if bc.BlockExists(fork[0].CalculateHash()) {
+		t.Errorf("Expected the stale fork to be removed")
+	}
+	if !bc.BlockExists(late[0].CalculateHash()) {
+		t.Errorf("Expected the recent fork to be kept")
+	}
+	if len(bc.GetRoot().Childs) != 1 {
+		t.Errorf("Expected genesis to keep only its canonical child, got %d", len(bc.GetRoot().Childs))
+	}
+	if bc.GetLatestBlock().Index != 12 {
+		t.Errorf("Expected the canonical chain to be untouched")
+	}
+}
+
+func TestPruneFinalizedHistory(t *testing.T) {
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("fork", 3))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+
+	checkpointed := newFixtureBlockchain(WithCheckpoints(Checkpoint{Height: 10, Hash: fixtureBlocks("main", 10)[9].CalculateHash()}))
+	addFixtureBlocks(t, checkpointed, fixtureBlocks("fork", 3))
+	addFixtureBlocks(t, checkpointed, fixtureBlocks("main", 12))
+
+	report := checkpointed.Prune(PruneOptions{BelowCheckpoint: true, DropTransactions: true})
+	if report.BranchesRemoved != 1 || report.BlocksRemoved != 3 {
+		t.Errorf("Expected the fork below the checkpoint to be removed, got %+v", report)
+	}
+	if report.TransactionsDropped != 9 {
+		t.Errorf("Expected the transactions of blocks 1 to 9 to be dropped, got %d", report.TransactionsDropped)
+	}
+
+	old := checkpointed.GetBlockByHeight(5)
+	if !old.Pruned || len(old.Block.Transactions) != 0 {
+		t.Errorf("Expected block 5 to keep only its header")
+	}
+	if checkpointed.GetBlockByHeight(10).Pruned {
+		t.Errorf("Expected the checkpoint block to keep its transactions")
+	}
+	if stored := checkpointed.GetBlock(old.Hash); stored == nil || !stored.Pruned {
+		t.Errorf("Expected pruned blocks to stay reachable by hash")
+	}
+	tx, location := checkpointed.GetTransaction(fixtureBlocks("main", 5)[4].Transactions[0].CalculateHash())
+	if tx != nil || location == nil || location.Height != 5 {
+		t.Errorf("Expected only the location of a pruned transaction, got %v %v", tx, location)
+	}
+
+	// Without a checkpoint nothing is final, so nothing is dropped.
+	if report := bc.Prune(PruneOptions{BelowCheckpoint: true, DropTransactions: true}); report.BlocksRemoved != 0 || report.TransactionsDropped != 0 {
+		t.Errorf("Expected nothing to be pruned without a checkpoint, got %+v", report)
+	}
+}
+
+func TestPruneConsensusSnapshots(t *testing.T) {
+	chain := newPoAChain(t, 2)
+	for i := 0; i < 12; i++ {
+		chain.addNext(t)
+	}
+
+	report := chain.bc.Prune(PruneOptions{DropTransactions: true})
+	if report.SnapshotsDropped != 10 {
+		t.Errorf("Expected the snapshots of blocks 0 to 9 to be dropped, got %d", report.SnapshotsDropped)
+	}
+	if report := chain.bc.Prune(PruneOptions{}); report.SnapshotsDropped != 0 {
+		t.Errorf("Expected the snapshots to be dropped once, got %d", report.SnapshotsDropped)
+	}
+
+	// Blocks on top of the checkpoint still verify once older transactions are gone.
+	checkpoint := chain.bc.GetBlockByHeight(10)
+	if signers, err := chain.engine.Signers(checkpoint); err != nil || len(signers) != 2 {
+		t.Fatalf("Expected the signers at the checkpoint to be known, got %x, %v", signers, err)
+	}
+	proposal := vote(chain.signer(t, checkpoint, 0), types.AddSignerVote, newKey(t).PublicKey())
+	if err := chain.bc.AddBlock(checkpoint, chain.sealNext(t, checkpoint, proposal)); err != nil {
+		t.Errorf("Expected a fork on the checkpoint to verify, got %v", err)
+	}
+	chain.addNext(t)
+}
+
+func TestConfigPruning(t *testing.T) {
+	config := DefaultConfig()
+	config.Node.PruneInterval = time.Minute
+	config.Node.PruneForkDepth = 6
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	bc := newFixtureBlockchain(WithCheckpointInterval(0))
+	addFixtureBlocks(t, bc, fixtureBlocks("main", 12))
+	addFixtureBlocks(t, bc, fixtureBlocks("fork", 3))
+
+	node := NewNode(bc, "a", append(config.NodeOptions(slog.Default()), WithNetwork(NewSimNetwork(1)))...)
+	if report := node.Prune(); report.BlocksRemoved != 3 {
+		t.Errorf("Expected the configured fork depth to remove the stale fork, got %+v", report)
+	}
+
+	config.Node.PruneInterval = -time.Minute
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a negative prune interval to be refused")
+	}
+}
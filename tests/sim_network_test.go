@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// newSimNode creates a node on network that knows the given peers.
+func newSimNode(t *testing.T, network *SimNetwork, address string, bc *Blockchain, peers ...string) *Node {
+	t.Helper()
+	node := NewNode(bc, address, WithNetwork(network))
+	for _, peer := range peers {
+		node.AddNodes([]byte(peer))
+	}
+	if err := node.Join(); err != nil {
+		t.Fatalf("Failed to join node %s: %v", address, err)
+	}
+	return node
+}
+
+// publishBlocks publishes blocks from node, failing the test on error.
+func publishBlocks(t *testing.T, node *Node, blocks []*types.Block) {
+	t.Helper()
+	for _, block := range blocks {
+		if err := node.PublishBlock(block); err != nil {
+			t.Fatalf("Failed to publish block %d: %v", block.Index, err)
+		}
+	}
+}
+
+// assertHead checks that the latest block of node is want.
+func assertHead(t *testing.T, node *Node, want *types.Block) {
+	t.Helper()
+	got := node.GetBlockchain().GetLatestBlock()
+	if !bytes.Equal(got.CalculateHash(), want.CalculateHash()) {
+		t.Errorf("Expected %s to be at height %d, got height %d", node.GetAddress(), want.Index, got.Index)
+	}
+}
+
+func TestSimNetworkSyncsNewNode(t *testing.T) {
+	network := NewSimNetwork(1)
+	blocks := fixtureBlocks("main", 5)
+
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+	a := newSimNode(t, network, "a", source)
+	b := newSimNode(t, network, "b", newFixtureBlockchain(), "a")
+
+	network.Run()
+
+	assertHead(t, b, blocks[len(blocks)-1])
+	if len(a.GetNodes()) != 1 || string(a.GetNodes()[0]) != "b" {
+		t.Errorf("Expected a to learn about b, got %q", a.GetNodes())
+	}
+}
+
+func TestSimNetworkResolvesForks(t *testing.T) {
+	network := NewSimNetwork(2)
+	mainBlocks := fixtureBlocks("main", 4)
+	forkBlocks := fixtureBlocks("fork", 3)
+
+	mainChain := newFixtureBlockchain()
+	addFixtureBlocks(t, mainChain, mainBlocks)
+	forkChain := newFixtureBlockchain()
+	addFixtureBlocks(t, forkChain, forkBlocks)
+
+	a := newSimNode(t, network, "a", mainChain)
+	b := newSimNode(t, network, "b", forkChain, "a")
+	network.Run()
+
+	head := mainBlocks[len(mainBlocks)-1]
+	assertHead(t, a, head)
+	assertHead(t, b, head)
+	if !a.GetBlockchain().BlockExists(forkBlocks[2].CalculateHash()) {
+		t.Errorf("Expected a to keep the losing fork as a side branch")
+	}
+}
+
+func TestSimNetworkGossipAcrossPartition(t *testing.T) {
+	network := NewSimNetwork(3)
+	network.SetLatency(5*time.Millisecond, 20*time.Millisecond)
+	blocks := fixtureBlocks("main", 4)
+
+	a := newSimNode(t, network, "a", newFixtureBlockchain())
+	b := newSimNode(t, network, "b", newFixtureBlockchain(), "a")
+	c := newSimNode(t, network, "c", newFixtureBlockchain(), "a")
+	network.Run()
+	if len(b.GetNodes()) != 2 || len(c.GetNodes()) != 2 {
+		t.Fatalf("Expected every node to know the others, got %q and %q", b.GetNodes(), c.GetNodes())
+	}
+
+	network.Partition([]string{"c"})
+	publishBlocks(t, a, blocks[:3])
+	network.Run()
+	assertHead(t, b, blocks[2])
+	assertHead(t, c, fixtureGenesis())
+
+	network.Heal()
+	publishBlocks(t, a, blocks[3:])
+	network.Run()
+	assertHead(t, b, blocks[3])
+	assertHead(t, c, blocks[3])
+}
+
+func TestSimNetworkReorderingIsDeterministic(t *testing.T) {
+	run := func() (SimStats, time.Duration) {
+		network := NewSimNetwork(4)
+		network.SetLatency(time.Millisecond, 50*time.Millisecond)
+		blocks := fixtureBlocks("main", 6)
+
+		a := newSimNode(t, network, "a", newFixtureBlockchain())
+		b := newSimNode(t, network, "b", newFixtureBlockchain(), "a")
+		network.Run()
+
+		// All blocks are sent at the same simulated time, so jitter delivers them out of order.
+		publishBlocks(t, a, blocks)
+		network.Run()
+		assertHead(t, b, blocks[len(blocks)-1])
+		return network.Stats(), network.Now()
+	}
+
+	stats, now := run()
+	if stats.Dropped != 0 || stats.Delivered != stats.Sent {
+		t.Errorf("Expected every message to be delivered, got %+v", stats)
+	}
+	if againStats, againNow := run(); againStats != stats || againNow != now {
+		t.Errorf("Expected identical runs, got %+v at %v and %+v at %v", stats, now, againStats, againNow)
+	}
+}
+
+func TestSimNetworkPacketLoss(t *testing.T) {
+	network := NewSimNetwork(5)
+	blocks := fixtureBlocks("main", 3)
+
+	a := newSimNode(t, network, "a", newFixtureBlockchain())
+	b := newSimNode(t, network, "b", newFixtureBlockchain(), "a")
+	network.Run()
+
+	network.SetLossRate(1)
+	publishBlocks(t, a, blocks[:2])
+	network.Run()
+	assertHead(t, b, fixtureGenesis())
+	if network.Stats().Dropped == 0 {
+		t.Errorf("Expected lost messages to be counted")
+	}
+
+	// The next announcement lets b fetch the blocks it missed.
+	network.SetLossRate(0)
+	publishBlocks(t, a, blocks[2:])
+	network.Run()
+	assertHead(t, b, blocks[2])
+}
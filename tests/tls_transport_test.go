@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// newTLSTransport wraps inner with a new identity, failing the test on error.
+func newTLSTransport(t *testing.T, inner interfaces.Transport, allowedPeers ...string) (interfaces.Transport, *Identity) {
+	t.Helper()
+	identity, err := GenerateIdentity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	transport, err := NewTLSTransport(inner, identity, allowedPeers)
+	if err != nil {
+		t.Fatalf("Failed to create TLS transport: %v", err)
+	}
+	return transport, identity
+}
+
+func TestNodeSyncsOverTLS(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	transport1, identity1 := newTLSTransport(t, memory)
+	transport2, _ := newTLSTransport(t, memory, identity1.ID())
+
+	blocks := fixtureBlocks("main", 3)
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+
+	node1 := NewNode(source, "a", WithTransport(transport1))
+	if err := node1.Join(); err != nil {
+		t.Fatalf("Failed to join node1: %v", err)
+	}
+	defer node1.Close()
+	node2 := NewNode(newFixtureBlockchain(), "b", WithTransport(transport2))
+	node2.AddNodes([]byte("a"))
+	if err := node2.Join(); err != nil {
+		t.Fatalf("Failed to join node2: %v", err)
+	}
+	defer node2.Close()
+
+	waitForHead(t, node2, blocks[len(blocks)-1].CalculateHash())
+}
+
+func TestTLSTransportAuthenticatesPeers(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	server, serverIdentity := newTLSTransport(t, memory)
+	client, clientIdentity := newTLSTransport(t, memory)
+
+	ln, err := server.Listen("server")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	accepted := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			accepted <- ""
+			return
+		}
+		id, _ := PeerID(conn)
+		accepted <- id
+	}()
+
+	conn, err := client.Dial("server")
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if id, err := PeerID(conn); err != nil || id != serverIdentity.ID() {
+		t.Errorf("Expected server ID %s, got %s (%v)", serverIdentity.ID(), id, err)
+	}
+	if id := <-accepted; id != clientIdentity.ID() {
+		t.Errorf("Expected client ID %s, got %s", clientIdentity.ID(), id)
+	}
+}
+
+func TestTLSTransportAllowList(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	friend, friendIdentity := newTLSTransport(t, memory)
+	private, _ := newTLSTransport(t, memory, friendIdentity.ID())
+	stranger, _ := newTLSTransport(t, memory, friendIdentity.ID())
+
+	blocks := fixtureBlocks("main", 2)
+	source := newFixtureBlockchain()
+	addFixtureBlocks(t, source, blocks)
+	node := NewNode(source, "private", WithTransport(private))
+	if err := node.Join(); err != nil {
+		t.Fatalf("Failed to join node: %v", err)
+	}
+	defer node.Close()
+
+	// The stranger refuses the private node, whose ID is not on its list.
+	if _, err := stranger.Dial("private"); !errors.Is(err, ErrPeerNotAllowed) {
+		t.Errorf("Expected ErrPeerNotAllowed, got %v", err)
+	}
+
+	// The private node refuses a client whose ID is not on its list.
+	outsider, _ := newTLSTransport(t, memory)
+	intruder := NewNode(newFixtureBlockchain(), "intruder", WithTransport(outsider))
+	intruder.AddNodes([]byte("private"))
+	if err := intruder.Join(); err != nil {
+		t.Fatalf("Failed to join intruder: %v", err)
+	}
+	defer intruder.Close()
+	time.Sleep(200 * time.Millisecond)
+	if intruder.GetBlockchain().GetLatestBlock().Index != 0 {
+		t.Errorf("Expected the private node to refuse an unknown peer")
+	}
+
+	member := NewNode(newFixtureBlockchain(), "member", WithTransport(friend))
+	member.AddNodes([]byte("private"))
+	if err := member.Join(); err != nil {
+		t.Fatalf("Failed to join member: %v", err)
+	}
+	defer member.Close()
+	waitForHead(t, member, blocks[len(blocks)-1].CalculateHash())
+}
+
+func TestTLSTransportPinsPeerID(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	server, serverIdentity := newTLSTransport(t, memory)
+	client, _ := newTLSTransport(t, memory)
+	_, impostor := newTLSTransport(t, memory)
+
+	ln, err := server.Listen("server")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go PeerID(conn)
+		}
+	}()
+
+	if _, err := client.Dial(impostor.ID() + "@server"); !errors.Is(err, ErrUnexpectedPeer) {
+		t.Errorf("Expected ErrUnexpectedPeer, got %v", err)
+	}
+	conn, err := client.Dial(serverIdentity.ID() + "@server")
+	if err != nil {
+		t.Fatalf("Failed to dial the pinned peer: %v", err)
+	}
+	conn.Close()
+}
+
+func TestPeerScoresUseNodeID(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	server, serverIdentity := newTLSTransport(t, memory)
+	client, clientIdentity := newTLSTransport(t, memory)
+
+	node := NewNode(newFixtureBlockchain(), "a", WithTransport(server))
+	if err := node.Join(); err != nil {
+		t.Fatalf("Failed to join node: %v", err)
+	}
+	defer node.Close()
+
+	network := NewStreamNetwork(client)
+	defer network.Close()
+	sender, err := network.Dial("b", serverIdentity.ID()+"@a")
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if err := sender.SendMsg([]byte("garbage")); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for node.GetPeerScores().Score(clientIdentity.ID()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the malformed message to count against the node ID %s", clientIdentity.ID())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if score := node.GetPeerScores().Score("b"); score != 0 {
+		t.Errorf("Expected no score for the address of an authenticated peer, got %d", score)
+	}
+}
+
+func TestConfigTLS(t *testing.T) {
+	_, peer := newTLSTransport(t, NewMemoryTransport())
+	config := DefaultConfig()
+	config.Node.Peers = []string{peer.ID() + "@localhost:8080"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a pinned peer to need tls")
+	}
+
+	config.Node.TLS = true
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected tls to need an identity file")
+	}
+	config.Node.IdentityFile = filepath.Join(t.TempDir(), "node.key")
+	config.Node.AllowedPeers = []string{"not-a-node-id"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an invalid allowed peer to be refused")
+	}
+	config.Node.AllowedPeers = []string{peer.ID()}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, identity, err := config.Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.Close()
+	loaded, err := LoadOrCreateIdentity(config.Node.IdentityFile)
+	if err != nil || loaded.ID() != identity.ID() {
+		t.Errorf("Expected the identity to be stored in the identity file, got %v", err)
+	}
+}
+
+func TestLoadOrCreateIdentity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+
+	created, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("Failed to create identity: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected identity file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected identity file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := LoadOrCreateIdentity(path)
+	if err != nil {
+		t.Fatalf("Failed to load identity: %v", err)
+	}
+	if loaded.ID() != created.ID() || len(created.ID()) != 40 {
+		t.Errorf("Expected the same 40 character node ID, got %s and %s", created.ID(), loaded.ID())
+	}
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/interfaces"
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+)
+
+// waitForHead waits until the latest block of node has the given hash.
+func waitForHead(t *testing.T, node *Node, hash []byte) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Equal(node.GetBlockchain().GetLatestBlock().CalculateHash(), hash) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to sync, got height %d", node.GetAddress(), node.GetBlockchain().GetLatestBlock().Index)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNodeSyncsOverTransports(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		transport interfaces.Transport
+		addresses [2]string
+	}{
+		{"tcp", NewTCPTransport(), [2]string{"127.0.0.1:8083", "127.0.0.1:8084"}},
+		{"unix", NewUnixTransport(), [2]string{filepath.Join(dir, "a.sock"), filepath.Join(dir, "b.sock")}},
+		{"memory", NewMemoryTransport(), [2]string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.transport.Close()
+			blocks := fixtureBlocks("main", 4)
+			source := newFixtureBlockchain()
+			addFixtureBlocks(t, source, blocks)
+
+			node1 := NewNode(source, tt.addresses[0], WithTransport(tt.transport))
+			if err := node1.Join(); err != nil {
+				t.Fatalf("Failed to join node1: %v", err)
+			}
+			defer node1.Close()
+			node2 := NewNode(newFixtureBlockchain(), tt.addresses[1], WithTransport(tt.transport))
+			node2.AddNodes([]byte(node1.GetAddress()))
+			if err := node2.Join(); err != nil {
+				t.Fatalf("Failed to join node2: %v", err)
+			}
+			defer node2.Close()
+
+			waitForHead(t, node2, blocks[len(blocks)-1].CalculateHash())
+		})
+	}
+}
+
+// stalledTransport holds up dialing the address "stalled" until release is closed.
+type stalledTransport struct {
+	interfaces.Transport
+	release chan struct{}
+}
+
+func (t *stalledTransport) Dial(address string) (net.Conn, error) {
+	if address == "stalled" {
+		<-t.release
+	}
+	return t.Transport.Dial(address)
+}
+
+// discard accepts connections on address and drops everything sent over them.
+func discard(t *testing.T, transport interfaces.Transport, address string) {
+	t.Helper()
+	ln, err := transport.Listen(address)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", address, err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+}
+
+func TestStreamNetworkDialsConcurrently(t *testing.T) {
+	memory := NewMemoryTransport()
+	defer memory.Close()
+	discard(t, memory, "stalled")
+	discard(t, memory, "fast")
+	transport := &stalledTransport{Transport: memory, release: make(chan struct{})}
+	network := NewStreamNetwork(transport)
+
+	type dialed struct {
+		sender interfaces.MessageSender
+		err    error
+	}
+	stalled := make(chan dialed, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			sender, err := network.Dial("me", "stalled")
+			stalled <- dialed{sender, err}
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := network.Dial("me", "fast")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a stalled dial not to hold up dialing another peer")
+	}
+
+	close(transport.release)
+	first, second := <-stalled, <-stalled
+	if first.err != nil || second.err != nil {
+		t.Fatalf("Failed to dial: %v, %v", first.err, second.err)
+	}
+	if first.sender != second.sender {
+		t.Errorf("Expected concurrent dials to share one connection")
+	}
+
+	closing := &stalledTransport{Transport: memory, release: make(chan struct{})}
+	network = NewStreamNetwork(closing)
+	go func() {
+		_, err := network.Dial("me", "stalled")
+		done <- err
+	}()
+	network.Close()
+	close(closing.release)
+	if err := <-done; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Expected a dial finishing after Close to fail with net.ErrClosed, got %v", err)
+	}
+}
+
+func TestMemoryTransportAddresses(t *testing.T) {
+	transport := NewMemoryTransport()
+	if _, err := transport.Dial("missing"); err == nil {
+		t.Errorf("Expected dialing an unknown address to fail")
+	}
+
+	ln, err := transport.Listen("a")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	if _, err := transport.Listen("a"); err == nil {
+		t.Errorf("Expected listening twice on the same address to fail")
+	}
+
+	ln.Close()
+	if _, err := transport.Dial("a"); err == nil {
+		t.Errorf("Expected dialing a closed listener to fail")
+	}
+	if _, err := transport.Listen("a"); err != nil {
+		t.Errorf("Expected the address to be free after closing its listener: %v", err)
+	}
+}
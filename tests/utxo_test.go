@@ -0,0 +1,353 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// newUTXOChain creates a blockchain on the UTXO ledger without proof of work.
+func newUTXOChain() *Blockchain {
+	return newFixtureBlockchain(WithoutProofOfWork(), WithLedgerModel(LedgerUTXO))
+}
+
+// utxoBlock returns a block with transactions on top of parent.
+func utxoBlock(parent *types.Block, transactions ...types.Transaction) *types.Block {
+	return &types.Block{
+		Index:        parent.Index + 1,
+		Timestamp:    parent.Timestamp + 1,
+		PreviousHash: parent.CalculateHash(),
+		Transactions: transactions,
+	}
+}
+
+// coinbase returns the coinbase of the block at height paying amount to key.
+func coinbase(height uint64, key *wallet.Key, amount uint64) types.Transaction {
+	return types.Transaction{
+		Type:    types.UTXOTransaction,
+		Amount:  height,
+		Outputs: []types.TxOutput{{Amount: amount, LockingKey: key.PublicKey()}},
+	}
+}
+
+// spend returns a transaction of key spending the outputs at outPoints.
+func spend(key *wallet.Key, outPoints []types.OutPoint, outputs ...types.TxOutput) types.Transaction {
+	tx := types.Transaction{Type: types.UTXOTransaction, Outputs: outputs}
+	for _, outPoint := range outPoints {
+		tx.Inputs = append(tx.Inputs, types.TxInput{PreviousOutput: outPoint})
+	}
+	key.SignInputs(&tx, "")
+	return tx
+}
+
+// pay returns an output paying amount to key.
+func pay(key *wallet.Key, amount uint64) types.TxOutput {
+	return types.TxOutput{Amount: amount, LockingKey: key.PublicKey()}
+}
+
+// addBlock adds block on top of its parent in bc.
+func addBlock(bc *Blockchain, block *types.Block) error {
+	return bc.AddBlock(bc.GetBlock(block.PreviousHash), block)
+}
+
+// unspent returns the total of the unspent outputs of key on the canonical chain of bc.
+func unspent(bc *Blockchain, key *wallet.Key) uint64 {
+	var total uint64
+	for _, utxo := range bc.GetUnspentOutputs([]byte(key.Address())) {
+		total += utxo.Output.Amount
+	}
+	return total
+}
+
+func TestUTXOSpending(t *testing.T) {
+	alice, bob := newKey(t), newKey(t)
+	bc := newUTXOChain()
+
+	reward := coinbase(1, alice, BlockSubsidy)
+	first := utxoBlock(bc.GetLatestBlock(), reward)
+	if err := addBlock(bc, first); err != nil {
+		t.Fatal(err)
+	}
+	utxos := bc.GetUnspentOutputs([]byte(alice.Address()))
+	if len(utxos) != 1 || utxos[0].Output.Amount != BlockSubsidy || utxos[0].Height != 1 {
+		t.Fatalf("Expected the coinbase output, got %v", utxos)
+	}
+
+	payment := spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(bob, 30), pay(alice, BlockSubsidy-35))
+	second := utxoBlock(first, coinbase(2, bob, BlockSubsidy+5), payment)
+	if err := addBlock(bc, second); err != nil {
+		t.Fatal(err)
+	}
+	if got := unspent(bc, alice); got != BlockSubsidy-35 {
+		t.Errorf("Expected alice to keep the change, got %d", got)
+	}
+	if got := unspent(bc, bob); got != BlockSubsidy+35 {
+		t.Errorf("Expected bob to get the payment, the subsidy and the fee, got %d", got)
+	}
+	if bc.GetUnspentOutput(reward.OutPoint(0)) != nil {
+		t.Errorf("Expected the spent output to leave the UTXO set")
+	}
+
+	tests := []struct {
+		name         string
+		transactions []types.Transaction
+		want         error
+	}{
+		{"spent output", []types.Transaction{spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(alice, 1))}, ErrDoubleSpend},
+		{"two spends in a block", []types.Transaction{
+			spend(alice, []types.OutPoint{payment.OutPoint(1)}, pay(bob, 1)),
+			spend(alice, []types.OutPoint{payment.OutPoint(1)}, pay(alice, 1)),
+		}, ErrDoubleSpend},
+		{"same input twice", []types.Transaction{spend(alice, []types.OutPoint{payment.OutPoint(1), payment.OutPoint(1)}, pay(bob, 1))}, ErrDoubleSpend},
+		{"unknown output", []types.Transaction{spend(alice, []types.OutPoint{{TxHash: []byte("unknown")}}, pay(bob, 1))}, ErrDoubleSpend},
+		{"wrong key", []types.Transaction{spend(bob, []types.OutPoint{payment.OutPoint(1)}, pay(bob, 1))}, ErrInvalidSignature},
+		{"outputs exceed inputs", []types.Transaction{spend(alice, []types.OutPoint{payment.OutPoint(1)}, pay(bob, BlockSubsidy))}, ErrOutputsExceedInputs},
+		{"coinbase too large", []types.Transaction{coinbase(3, bob, BlockSubsidy+1)}, ErrInvalidCoinbase},
+		{"coinbase of another height", []types.Transaction{coinbase(2, bob, 1)}, ErrInvalidCoinbase},
+		{"second coinbase", []types.Transaction{coinbase(3, bob, 1), coinbase(3, alice, 1)}, ErrInvalidCoinbase},
+		{"transfer", []types.Transaction{{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1}}, ErrWrongLedgerModel},
+		{"no outputs", []types.Transaction{spend(alice, []types.OutPoint{payment.OutPoint(1)})}, ErrInvalidOutput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := addBlock(bc, utxoBlock(second, tt.transactions...)); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if bc.GetLatestBlock().Index != 2 || unspent(bc, alice) != BlockSubsidy-35 {
+		t.Errorf("Expected rejected blocks to leave the UTXO set untouched")
+	}
+}
+
+func TestUTXOReorganization(t *testing.T) {
+	alice, bob, carol := newKey(t), newKey(t), newKey(t)
+	bc := newUTXOChain()
+
+	reward := coinbase(1, alice, BlockSubsidy)
+	first := utxoBlock(bc.GetLatestBlock(), reward)
+	toBob := utxoBlock(first, spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(bob, BlockSubsidy)))
+	for _, block := range []*types.Block{first, toBob} {
+		if err := addBlock(bc, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The side branch spends the same coinbase output to carol.
+	payment := spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(carol, BlockSubsidy))
+	toCarol := utxoBlock(first, payment)
+	if err := addBlock(bc, toCarol); err != nil {
+		t.Fatalf("Expected a conflicting spend on a side branch to be valid, got %v", err)
+	}
+	if err := addBlock(bc, utxoBlock(toCarol, spend(bob, []types.OutPoint{toBob.Transactions[0].OutPoint(0)}, pay(carol, 1)))); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("Expected an output of the canonical chain to be missing on the side branch, got %v", err)
+	}
+	if err := addBlock(bc, utxoBlock(toCarol, spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(alice, 1)))); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("Expected an output spent on the side branch to be spent, got %v", err)
+	}
+	if unspent(bc, bob) != BlockSubsidy || unspent(bc, carol) != 0 {
+		t.Fatalf("Expected the side branch to leave the UTXO set untouched")
+	}
+
+	// Spending carol's output on top of the side branch makes it canonical.
+	fromCarol := utxoBlock(toCarol, spend(carol, []types.OutPoint{payment.OutPoint(0)}, pay(carol, 10), pay(bob, BlockSubsidy-10)))
+	if err := bc.AdoptChain([]*types.Block{first, toCarol, fromCarol, utxoBlock(fromCarol)}); err != nil {
+		t.Fatal(err)
+	}
+	if bc.GetLatestBlock().Index != 4 {
+		t.Fatalf("Expected the longer branch to become canonical, got height %d", bc.GetLatestBlock().Index)
+	}
+	if unspent(bc, alice) != 0 || unspent(bc, bob) != BlockSubsidy-10 || unspent(bc, carol) != 10 {
+		t.Errorf("Expected the UTXO set of the new branch, got alice %d, bob %d, carol %d", unspent(bc, alice), unspent(bc, bob), unspent(bc, carol))
+	}
+
+	// Reorganizing back restores the outputs of the first branch from undo data.
+	tip := toBob
+	for i := 0; i < 3; i++ {
+		tip = utxoBlock(tip)
+		if err := addBlock(bc, tip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bc.GetLatestBlock().Index != 5 {
+		t.Fatalf("Expected the first branch to become canonical again, got height %d", bc.GetLatestBlock().Index)
+	}
+	if unspent(bc, bob) != BlockSubsidy || unspent(bc, carol) != 0 || bc.GetUnspentOutput(toBob.Transactions[0].OutPoint(0)) == nil {
+		t.Errorf("Expected the UTXO set of the first branch, got bob %d, carol %d", unspent(bc, bob), unspent(bc, carol))
+	}
+}
+
+func TestLedgerModels(t *testing.T) {
+	alice := newKey(t)
+	bc := newFixtureBlockchain(WithoutProofOfWork())
+	if model := bc.LedgerModel(); model != LedgerAccount {
+		t.Errorf("Expected the account ledger by default, got %s", model)
+	}
+	if err := addBlock(bc, utxoBlock(bc.GetLatestBlock(), coinbase(1, alice, 1))); !errors.Is(err, ErrWrongLedgerModel) {
+		t.Errorf("Expected a UTXO transaction to be refused on the account ledger, got %v", err)
+	}
+	if bc.GetUnspentOutputs([]byte(alice.Address())) != nil {
+		t.Errorf("Expected no unspent outputs on the account ledger")
+	}
+
+	mempool := NewMempool(DefaultMempoolSize, DefaultRules().TransactionRules, "")
+	outPoint := types.OutPoint{TxHash: []byte("funding"), Index: 0}
+	if err := mempool.Add(spend(alice, []types.OutPoint{outPoint}, pay(alice, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := mempool.Add(spend(alice, []types.OutPoint{outPoint}, pay(alice, 1))); !errors.Is(err, ErrDoubleSpend) {
+		t.Errorf("Expected the mempool to refuse a conflicting spend, got %v", err)
+	}
+	mempool.Take(1)
+	if err := mempool.Add(spend(alice, []types.OutPoint{outPoint}, pay(alice, 1))); err != nil {
+		t.Errorf("Expected the output to be free once the spend left the mempool, got %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Chain.Ledger = LedgerUTXO
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected the UTXO ledger to be refused on the testnet")
+	}
+	config.Network = NetworkDev
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := config.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model := profile.NewBlockchain(config.BlockchainOptions(nil)...).LedgerModel(); model != LedgerUTXO {
+		t.Errorf("Expected the configured ledger model, got %s", model)
+	}
+	config.Chain.Ledger = "ledger"
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an unknown ledger model to be refused")
+	}
+}
+
+func TestUTXOUndoPruning(t *testing.T) {
+	alice := newKey(t)
+	bc := newUTXOChain()
+
+	blocks := []*types.Block{bc.GetLatestBlock()}
+	for height := uint64(1); height <= 12; height++ {
+		block := utxoBlock(blocks[len(blocks)-1], coinbase(height, alice, BlockSubsidy))
+		if err := addBlock(bc, block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	// Blocks up to the checkpoint at height 10 can never be disconnected.
+	report := bc.Prune(PruneOptions{})
+	if report.UndoDropped != 11 || report.BytesReclaimed == 0 {
+		t.Errorf("Expected the undo data of blocks 0 to 10 to be dropped, got %+v", report)
+	}
+	if report := bc.Prune(PruneOptions{}); report.UndoDropped != 0 {
+		t.Errorf("Expected the undo data to be dropped once, got %+v", report)
+	}
+	if err := addBlock(bc, utxoBlock(blocks[5], coinbase(6, alice, 1))); !errors.Is(err, ErrBelowCheckpoint) {
+		t.Errorf("Expected a fork below the checkpoint to be refused, got %v", err)
+	}
+	if err := addBlock(bc, utxoBlock(blocks[10], coinbase(11, alice, 1))); err != nil {
+		t.Errorf("Expected a fork above the checkpoint to be valid, got %v", err)
+	}
+	if err := addBlock(bc, utxoBlock(blocks[12], coinbase(13, alice, BlockSubsidy))); err != nil {
+		t.Fatal(err)
+	}
+	if got := unspent(bc, alice); got != 13*BlockSubsidy {
+		t.Errorf("Expected the coinbases of 13 blocks, got %d", got)
+	}
+}
+
+func TestUTXOChainRoundTrip(t *testing.T) {
+	alice, bob := newKey(t), newKey(t)
+	bc := newUTXOChain()
+	reward := coinbase(1, alice, BlockSubsidy)
+	first := utxoBlock(bc.GetLatestBlock(), reward)
+	second := utxoBlock(first, coinbase(2, bob, BlockSubsidy), spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(bob, 20), pay(alice, BlockSubsidy-20)))
+	for _, block := range []*types.Block{first, second} {
+		if err := addBlock(bc, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := ExportChain(bc, &buf, 0, 100, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadChain(bytes.NewReader(buf.Bytes()), nil); err == nil {
+		t.Errorf("Expected a UTXO chain to be refused by a default blockchain")
+	}
+	loaded, state, err := LoadChain(bytes.NewReader(buf.Bytes()), nil, WithoutProofOfWork(), WithLedgerModel(LedgerUTXO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Imported != 2 || loaded.LedgerModel() != LedgerUTXO {
+		t.Errorf("Expected 2 imported blocks on the UTXO ledger, got %+v on %s", state, loaded.LedgerModel())
+	}
+	if unspent(loaded, alice) != BlockSubsidy-20 || unspent(loaded, bob) != BlockSubsidy+20 {
+		t.Errorf("Expected the UTXO set of the exported chain, got alice %d, bob %d", unspent(loaded, alice), unspent(loaded, bob))
+	}
+}
+
+func TestMinerPaysCoinbase(t *testing.T) {
+	alice, bob := newKey(t), newKey(t)
+	bc := newFixtureBlockchain(WithDifficulty(1), WithLedgerModel(LedgerUTXO))
+	node := NewNode(bc, "miner", WithNetwork(NewSimNetwork(1)), WithMiningInterval(10*time.Millisecond), WithCoinbaseKey(alice.PublicKey()))
+	go node.TryToFindNewBlock()
+	defer node.Close()
+
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %s, got height %d", what, bc.GetLatestBlock().Index)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("mined blocks", func() bool { return bc.GetLatestBlock().Index >= 2 })
+	reward := bc.GetBlockByHeight(1).Block.Transactions[0]
+	if !reward.IsCoinbase() || len(reward.Outputs) != 1 || reward.Outputs[0].Amount != BlockSubsidy {
+		t.Fatalf("Expected block 1 to start with a coinbase paying the subsidy, got %+v", reward)
+	}
+
+	// A transaction spending a missing output fails the block it is mined in,
+	// but the valid one queued after it is mined in a later block.
+	missing := spend(alice, []types.OutPoint{{TxHash: make([]byte, 32)}}, pay(bob, 1))
+	payment := spend(alice, []types.OutPoint{reward.OutPoint(0)}, pay(bob, 20), pay(alice, BlockSubsidy-20))
+	for _, tx := range []types.Transaction{missing, payment} {
+		if err := node.SubmitTransaction(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor("the coinbase output to be spent", func() bool { return unspent(bc, bob) == 20 })
+	if size := node.GetMempool().Size(); size != 0 {
+		t.Errorf("Expected the invalid transaction to be dropped, got %d queued transactions", size)
+	}
+}
+
+func TestConfigCoinbaseKey(t *testing.T) {
+	config := DefaultConfig()
+	config.Network = NetworkDev
+	config.Node.CoinbaseKey = hex.EncodeToString(newKey(t).PublicKey())
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected a coinbase key to need the UTXO ledger")
+	}
+	config.Chain.Ledger = LedgerUTXO
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	config.Node.CoinbaseKey = "alice"
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an invalid coinbase key to be refused")
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/pabloaaa/GO_BLOCKCHAIN/src"
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// ruleTestBlock returns an unmined child of parent with the given transactions.
+func ruleTestBlock(parent *types.Block, transactions ...types.Transaction) *types.Block {
+	return &types.Block{
+		Index:        parent.Index + 1,
+		Timestamp:    parent.Timestamp + 10,
+		Transactions: transactions,
+		PreviousHash: parent.CalculateHash(),
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	bc := newFixtureBlockchain()
+	genesis := bc.GetRoot().Block
+	alice := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 5}
+
+	tooMany := make([]types.Transaction, DefaultMaxBlockTransactions+1)
+	for i := range tooMany {
+		tooMany[i] = types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: uint64(i + 1)}
+	}
+	tooLarge := types.Transaction{Sender: make([]byte, DefaultMaxBlockSize), Receiver: []byte("Bob"), Amount: 1}
+
+	future := ruleTestBlock(genesis, alice)
+	future.Timestamp = uint64(time.Now().Add(MaxFutureBlockTime + time.Minute).Unix())
+	stale := ruleTestBlock(genesis, alice)
+	stale.Timestamp = genesis.Timestamp
+
+	tests := []struct {
+		name     string
+		block    *types.Block
+		want     error
+		position int
+	}{
+		{"zero amount", ruleTestBlock(genesis, alice, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob")}), ErrZeroAmount, 1},
+		{"self transfer", ruleTestBlock(genesis, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Alice"), Amount: 1}), ErrSelfTransfer, 0},
+		{"duplicate", ruleTestBlock(genesis, alice, alice), ErrDuplicateTransaction, 1},
+		{"too many transactions", ruleTestBlock(genesis, tooMany...), ErrTooManyTransactions, -1},
+		{"too large", ruleTestBlock(genesis, tooLarge), ErrBlockTooLarge, -1},
+		{"future timestamp", future, ErrTimestampTooFarInFuture, -1},
+		{"timestamp not after median", stale, ErrTimestampTooOld, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bc.ValidateBlock(tt.block, genesis)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			var txErr *TransactionError
+			if tt.position >= 0 && (!errors.As(err, &txErr) || txErr.Position != tt.position) {
+				t.Errorf("Expected the error to point at transaction %d, got %v", tt.position, err)
+			}
+		})
+	}
+
+	// A block that passes every rule still fails the proof of work check.
+	if err := bc.ValidateBlock(ruleTestBlock(genesis, alice), genesis); !errors.Is(err, ErrInsufficientWork) {
+		t.Errorf("Expected only the proof of work check to fail, got %v", err)
+	}
+}
+
+func TestTimestampMustExceedMedianOfPastBlocks(t *testing.T) {
+	bc := newFixtureBlockchain()
+	blocks := fixtureBlocks("main", 11)
+	addFixtureBlocks(t, bc, blocks)
+	parent := blocks[len(blocks)-1]
+
+	// The median of the last 11 blocks is the timestamp of block 6.
+	block := ruleTestBlock(parent, types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1})
+	block.Timestamp = blocks[5].Timestamp
+	if err := bc.ValidateBlock(block, parent); !errors.Is(err, ErrTimestampTooOld) {
+		t.Errorf("Expected ErrTimestampTooOld, got %v", err)
+	}
+	block.Timestamp = blocks[5].Timestamp + 1
+	if err := bc.ValidateBlock(block, parent); errors.Is(err, ErrTimestampTooOld) {
+		t.Errorf("Expected a timestamp after the median to be accepted, got %v", err)
+	}
+
+	next := bc.GenerateNewBlock([]types.Transaction{{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1}})
+	if next.Timestamp <= blocks[5].Timestamp {
+		t.Errorf("Expected generated blocks to be after the median of past blocks")
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	errNoCarol := errors.New("Carol may not receive funds")
+	rules := DefaultRules()
+	rules.TransactionRules = append(rules.TransactionRules, func(ctx *ValidationContext, tx *types.Transaction) error {
+		if string(tx.Receiver) == "Carol" {
+			return errNoCarol
+		}
+		return nil
+	})
+	rules.BlockRules[0] = MaxTransactionsRule(1)
+
+	bc := newFixtureBlockchain(WithRules(rules))
+	genesis := bc.GetRoot().Block
+	toCarol := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Carol"), Amount: 1}
+	toBob := types.Transaction{Sender: []byte("Alice"), Receiver: []byte("Bob"), Amount: 1}
+
+	if err := bc.ValidateBlock(ruleTestBlock(genesis, toCarol), genesis); !errors.Is(err, errNoCarol) {
+		t.Errorf("Expected the custom rule to reject the block, got %v", err)
+	}
+	if err := bc.ValidateBlock(ruleTestBlock(genesis, toBob, toBob), genesis); !errors.Is(err, ErrTooManyTransactions) {
+		t.Errorf("Expected the lowered limit to reject the block, got %v", err)
+	}
+}
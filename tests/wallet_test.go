@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+func TestAddressChecksum(t *testing.T) {
+	key, err := wallet.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	address := key.Address()
+	if !strings.HasPrefix(address, wallet.AddressPrefix+"1") {
+		t.Fatalf("Expected address to start with %s1, got %s", wallet.AddressPrefix, address)
+	}
+	if err := wallet.ValidateAddress(address); err != nil {
+		t.Errorf("Expected generated address to be valid: %v", err)
+	}
+	if err := wallet.ValidateAddress(strings.ToUpper(address)); err != nil {
+		t.Errorf("Expected upper case address to be valid: %v", err)
+	}
+
+	// Replace one character of the data part to simulate a typo.
+	typo := []byte(address)
+	if typo[len(typo)-1] == 'q' {
+		typo[len(typo)-1] = 'p'
+	} else {
+		typo[len(typo)-1] = 'q'
+	}
+	if err := wallet.ValidateAddress(string(typo)); !errors.Is(err, wallet.ErrAddressChecksum) {
+		t.Errorf("Expected ErrAddressChecksum for %s, got %v", typo, err)
+	}
+	if err := wallet.ValidateAddress("Alice"); !errors.Is(err, wallet.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+}
+
+func TestKeySignsAndVerifies(t *testing.T) {
+	key, err := wallet.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	message := []byte("transfer 10 to Bob")
+	signature := key.Sign(message)
+
+	if !wallet.Verify(key.PublicKey(), message, signature) {
+		t.Errorf("Expected signature to verify")
+	}
+	if wallet.Verify(key.PublicKey(), []byte("transfer 11 to Bob"), signature) {
+		t.Errorf("Expected signature of a different message to be rejected")
+	}
+}
+
+func TestDeriveKeyMatchesSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{"m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{"m/0'/1'", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+	}
+
+	for _, tt := range tests {
+		key, err := wallet.DeriveKey(seed, tt.path)
+		if err != nil {
+			t.Fatalf("Failed to derive %s: %v", tt.path, err)
+		}
+		if got := hex.EncodeToString(key.Seed()); got != tt.key {
+			t.Errorf("Expected key %s at %s, got %s", tt.key, tt.path, got)
+		}
+	}
+
+	if _, err := wallet.DeriveKey(seed, "m/0"); err == nil {
+		t.Errorf("Expected non-hardened derivation to fail")
+	}
+}
+
+func TestMnemonicRecoversKeys(t *testing.T) {
+	mnemonic, err := wallet.NewMnemonic()
+	if err != nil {
+		t.Fatalf("Failed to generate seed phrase: %v", err)
+	}
+	if words := len(strings.Fields(mnemonic)); words != 24 {
+		t.Errorf("Expected 24 words, got %d", words)
+	}
+
+	derive := func(phrase string, index uint32) string {
+		seed, err := wallet.SeedFromMnemonic(phrase, "")
+		if err != nil {
+			t.Fatalf("Failed to read seed phrase: %v", err)
+		}
+		key, err := wallet.DeriveKey(seed, wallet.DerivationPath(0, index))
+		if err != nil {
+			t.Fatalf("Failed to derive key: %v", err)
+		}
+		return key.Address()
+	}
+
+	first := derive(mnemonic, 0)
+	if again := derive("  "+strings.ReplaceAll(mnemonic, " ", "  ")+"\n", 0); again != first {
+		t.Errorf("Expected the same address from the same seed phrase, got %s and %s", first, again)
+	}
+	if second := derive(mnemonic, 1); second == first {
+		t.Errorf("Expected different indexes to derive different addresses")
+	}
+
+	if _, err := wallet.SeedFromMnemonic("not a valid seed phrase", ""); err == nil {
+		t.Errorf("Expected an invalid seed phrase to be rejected")
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	keystore := wallet.NewKeystore(t.TempDir(), wallet.WithScryptN(wallet.LightScryptN))
+	key, err := wallet.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	if err := keystore.Store(key, "correct horse"); err != nil {
+		t.Fatalf("Failed to store key: %v", err)
+	}
+	if err := keystore.Store(key, "correct horse"); !errors.Is(err, wallet.ErrKeyExists) {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
+
+	addresses, err := keystore.List()
+	if err != nil || len(addresses) != 1 || addresses[0] != key.Address() {
+		t.Fatalf("Expected the stored address to be listed, got %v (%v)", addresses, err)
+	}
+
+	loaded, err := keystore.Load(key.Address(), "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to load key: %v", err)
+	}
+	if hex.EncodeToString(loaded.Seed()) != hex.EncodeToString(key.Seed()) {
+		t.Errorf("Expected the loaded key to match the stored key")
+	}
+	if _, err := keystore.Load(key.Address(), "battery staple"); !errors.Is(err, wallet.ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+
+	other, _ := wallet.GenerateKey()
+	if _, err := keystore.Load(other.Address(), "correct horse"); !errors.Is(err, wallet.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
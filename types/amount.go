@@ -0,0 +1,97 @@
+package types
+
+import (
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// AmountDecimals is the number of decimal places of a coin; amounts are stored
+// as integer base units.
+const AmountDecimals = 8
+
+// UnitsPerCoin is the number of base units in one coin.
+const UnitsPerCoin uint64 = 100_000_000
+
+var (
+	// ErrAmountOverflow is returned when an amount does not fit in 64 bits.
+	ErrAmountOverflow = errors.New("amount overflows")
+	// ErrInvalidAmount is returned when a string is not a valid decimal amount.
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+// FormatAmount formats base units as a decimal coin amount, e.g. 150000000 as "1.5".
+func FormatAmount(units uint64) string {
+	whole := strconv.FormatUint(units/UnitsPerCoin, 10)
+	fraction := units % UnitsPerCoin
+	if fraction == 0 {
+		return whole
+	}
+	digits := strconv.FormatUint(fraction, 10)
+	digits = strings.Repeat("0", AmountDecimals-len(digits)) + digits
+	return whole + "." + strings.TrimRight(digits, "0")
+}
+
+// ParseAmount parses a decimal coin amount with at most AmountDecimals decimal
+// places into base units. It is the exact inverse of FormatAmount.
+func ParseAmount(s string) (uint64, error) {
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > AmountDecimals || !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+
+	coins, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+	units, err := MulAmount(coins, UnitsPerCoin)
+	if err != nil {
+		return 0, err
+	}
+	if fraction != "" {
+		fractionUnits, _ := strconv.ParseUint(fraction+strings.Repeat("0", AmountDecimals-len(fraction)), 10, 64)
+		return AddAmounts(units, fractionUnits)
+	}
+	return units, nil
+}
+
+// AddAmounts returns a+b, or ErrAmountOverflow if the sum does not fit in 64 bits.
+func AddAmounts(a, b uint64) (uint64, error) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return 0, ErrAmountOverflow
+	}
+	return sum, nil
+}
+
+// MulAmount returns a*b, or ErrAmountOverflow if the product does not fit in 64 bits.
+func MulAmount(a, b uint64) (uint64, error) {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return 0, ErrAmountOverflow
+	}
+	return lo, nil
+}
+
+// TotalAmount returns the sum of the amounts of transactions, checking for overflow.
+func TotalAmount(transactions []Transaction) (uint64, error) {
+	var total uint64
+	for _, tx := range transactions {
+		var err error
+		if total, err = AddAmounts(total, tx.Amount); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,7 +1,11 @@
 package types
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -16,6 +20,19 @@ type Block struct {
 	Transactions []Transaction
 	Data         uint64
 	Checkpoint   bool
+	// Signer and Signature are set on blocks of signature-based consensus
+	// engines; see SealHash.
+	Signer    []byte
+	Signature []byte
+	// Evidence proves that validators signed conflicting blocks.
+	Evidence []DoubleSignEvidence
+}
+
+// DoubleSignEvidence proves that a validator signed two different blocks with
+// the same parent.
+type DoubleSignEvidence struct {
+	First  *Block
+	Second *Block
 }
 
 // BlockNode represents a node in the blockchain tree.
@@ -23,37 +40,206 @@ type BlockNode struct {
 	Block  *Block
 	Parent *BlockNode
 	Childs []*BlockNode
+	// Hash caches the block hash, which cannot be recomputed once the block is pruned.
+	Hash []byte
+	// Pruned is set when the transactions of the block have been dropped.
+	Pruned bool
+	// TotalWork is the work of the chain up to and including the block.
+	TotalWork *big.Int
 }
 
+// TransactionType tells what a transaction does.
+type TransactionType uint32
+
+// Transaction types. Transfers move funds between accounts and UTXO transactions
+// move them between outputs; the other types are instructions to the consensus
+// engine. Votes carry the public key they are about in Receiver, and stake
+// changes apply to the public key that signed them.
+const (
+	TransferTransaction TransactionType = iota
+	// AddSignerVote votes to make Receiver a proof-of-authority signer.
+	AddSignerVote
+	// RemoveSignerVote votes to remove Receiver from the proof-of-authority signers.
+	RemoveSignerVote
+	// StakeTransaction adds Amount to the stake of the signer. Stake and
+	// unstake transactions carry the next nonce of the signer in Nonce.
+	StakeTransaction
+	// UnstakeTransaction withdraws Amount from the stake of the signer.
+	UnstakeTransaction
+	// UTXOTransaction spends the outputs referenced by Inputs and creates
+	// Outputs; Sender and Receiver are unused. A UTXO transaction without inputs
+	// is a coinbase, which carries the height of its block in Amount.
+	UTXOTransaction
+)
+
 // Transaction represents a transaction in the blockchain.
 type Transaction struct {
+	Type     TransactionType
 	Sender   []byte
 	Receiver []byte
-	Amount   float64
+	// Amount is in base units; see UnitsPerCoin.
+	Amount uint64
+	// PublicKey and Signature are set on signed transactions; see SigningHash.
+	PublicKey []byte
+	Signature []byte
+	// Inputs and Outputs are set on UTXO transactions.
+	Inputs  []TxInput
+	Outputs []TxOutput
+	// Nonce orders the stake and unstake transactions of a signer, so that they
+	// cannot be replayed. Other transactions leave it zero.
+	Nonce uint64
 }
 
-// CalculateHash calculates the SHA-256 hash of the block.
+// CalculateHash calculates the SHA-256 hash of the block. The signer and
+// signature are only included in signed blocks, so that the hashes of unsigned
+// ones are unchanged.
 func (b *Block) CalculateHash() []byte {
+	data := b.sealData()
+	if len(b.Signer) > 0 || len(b.Signature) > 0 {
+		data += hex.EncodeToString(b.Signature)
+	}
+	hash := sha256.Sum256([]byte(data))
+	return hash[:]
+}
+
+// SealHash returns the hash the signer of a block signs: the hash of the block
+// without its signature.
+func (b *Block) SealHash() []byte {
+	hash := sha256.Sum256([]byte(b.sealData()))
+	return hash[:]
+}
+
+// sealData returns the string representation of the block without its
+// signature. Transactions are represented by their hex-encoded hashes, which
+// have a fixed length. Evidence is only included in blocks that carry some.
+func (b *Block) sealData() string {
 	var transactionsStrings []string
 	for _, t := range b.Transactions {
-		transactionsStrings = append(transactionsStrings, string(t.Sender)+string(t.Receiver)+strconv.FormatFloat(t.Amount, 'f', -1, 32))
+		transactionsStrings = append(transactionsStrings, hex.EncodeToString(t.CalculateHash()))
 	}
 	data := strconv.FormatUint((b.Index), 10) + strconv.FormatUint(b.Timestamp, 10) + strings.Join(transactionsStrings, "") + string(b.PreviousHash) + strconv.FormatUint(b.Data, 10)
-	hash := sha256.Sum256([]byte(data))
+	if len(b.Signer) > 0 || len(b.Signature) > 0 {
+		data += hex.EncodeToString(b.Signer)
+	}
+	for _, evidence := range b.Evidence {
+		data += "evidence" + hex.EncodeToString(evidence.Hash())
+	}
+	return data
+}
+
+// Offender returns the public key of the validator who signed both blocks.
+func (e *DoubleSignEvidence) Offender() []byte {
+	return e.First.Signer
+}
+
+// Hash identifies the evidence regardless of the order of its blocks.
+func (e *DoubleSignEvidence) Hash() []byte {
+	first, second := e.First.CalculateHash(), e.Second.CalculateHash()
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+	hash := sha256.Sum256(append(first, second...))
 	return hash[:]
 }
 
+// DoubleSignEvidenceFromProto converts a protobuf DoubleSignEvidence to a
+// DoubleSignEvidence. Missing blocks stay nil.
+func DoubleSignEvidenceFromProto(pbEvidence *pb.DoubleSignEvidence) DoubleSignEvidence {
+	var evidence DoubleSignEvidence
+	if pbEvidence.GetFirst() != nil {
+		evidence.First = BlockFromProto(pbEvidence.GetFirst())
+	}
+	if pbEvidence.GetSecond() != nil {
+		evidence.Second = BlockFromProto(pbEvidence.GetSecond())
+	}
+	return evidence
+}
+
+// ToProto converts a DoubleSignEvidence to a protobuf DoubleSignEvidence.
+func (e *DoubleSignEvidence) ToProto() *pb.DoubleSignEvidence {
+	return &pb.DoubleSignEvidence{First: e.First.ToProto(), Second: e.Second.ToProto()}
+}
+
+// hashData returns the encoding of the transaction used for hashing: its
+// signing data followed by its public key and signatures, each prefixed with
+// its length.
+func (t *Transaction) hashData() []byte {
+	data := appendField(t.signingData(), t.PublicKey)
+	data = appendField(data, t.Signature)
+	for _, input := range t.Inputs {
+		data = appendField(data, input.Signature)
+	}
+	return data
+}
+
+// signingData returns the encoding of what the transaction does, without its
+// public key and signatures. Variable-length fields are prefixed with their
+// length, so that moving bytes from one field to the next changes the encoding.
+func (t *Transaction) signingData() []byte {
+	data := binary.AppendUvarint(nil, uint64(t.Type))
+	data = appendField(data, t.Sender)
+	data = appendField(data, t.Receiver)
+	data = binary.AppendUvarint(data, t.Amount)
+	data = binary.AppendUvarint(data, uint64(len(t.Inputs)))
+	for _, input := range t.Inputs {
+		data = appendField(data, input.PreviousOutput.TxHash)
+		data = binary.AppendUvarint(data, uint64(input.PreviousOutput.Index))
+	}
+	data = binary.AppendUvarint(data, uint64(len(t.Outputs)))
+	for _, output := range t.Outputs {
+		data = binary.AppendUvarint(data, output.Amount)
+		data = appendField(data, output.LockingKey)
+	}
+	// The nonce is only included when set, so that the hashes of transactions
+	// without one are unchanged.
+	if t.Nonce != 0 {
+		data = binary.AppendUvarint(data, t.Nonce)
+	}
+	return data
+}
+
+// SigningHash returns the hash a transaction is signed over on the chain with
+// the given ID. Including the chain ID keeps a transaction signed for one
+// network from being replayed on another.
+func (t *Transaction) SigningHash(chainID string) []byte {
+	hash := sha256.Sum256(append(appendField(nil, []byte(chainID)), t.signingData()...))
+	return hash[:]
+}
+
+// CalculateHash calculates the SHA-256 hash of the transaction.
+func (t *Transaction) CalculateHash() []byte {
+	hash := sha256.Sum256(t.hashData())
+	return hash[:]
+}
+
+// appendField appends field to data, prefixed with its length.
+func appendField(data []byte, field []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(field)))
+	return append(data, field...)
+}
+
 // BlockFromProto converts a protobuf Block to a Block.
 func BlockFromProto(pbBlock *pb.Block) *Block {
 	transactions := make([]Transaction, len(pbBlock.GetTransactions()))
 	for i, pbTransaction := range pbBlock.GetTransactions() {
 		transactions[i] = Transaction{
-			Sender:   pbTransaction.GetSender(),
-			Receiver: pbTransaction.GetReceiver(),
-			Amount:   pbTransaction.GetAmount(),
+			Type:      TransactionType(pbTransaction.GetType()),
+			Sender:    pbTransaction.GetSender(),
+			Receiver:  pbTransaction.GetReceiver(),
+			Amount:    pbTransaction.GetAmount(),
+			PublicKey: pbTransaction.GetPublicKey(),
+			Signature: pbTransaction.GetSignature(),
+			Inputs:    inputsFromProto(pbTransaction.GetInputs()),
+			Outputs:   outputsFromProto(pbTransaction.GetOutputs()),
+			Nonce:     pbTransaction.GetNonce(),
 		}
 	}
 
+	var evidence []DoubleSignEvidence
+	for _, pbEvidence := range pbBlock.GetEvidence() {
+		evidence = append(evidence, DoubleSignEvidenceFromProto(pbEvidence))
+	}
+
 	return &Block{
 		Index:        pbBlock.GetIndex(),
 		Timestamp:    pbBlock.GetTimestamp(),
@@ -61,6 +247,9 @@ func BlockFromProto(pbBlock *pb.Block) *Block {
 		Transactions: transactions,
 		Data:         pbBlock.GetData(),
 		Checkpoint:   pbBlock.GetCheckpoint(),
+		Signer:       pbBlock.GetSigner(),
+		Signature:    pbBlock.GetSignature(),
+		Evidence:     evidence,
 	}
 }
 
@@ -69,12 +258,23 @@ func (b *Block) ToProto() *pb.Block {
 	pbTransactions := make([]*pb.Transaction, len(b.Transactions))
 	for i, transaction := range b.Transactions {
 		pbTransactions[i] = &pb.Transaction{
-			Sender:   transaction.Sender,
-			Receiver: transaction.Receiver,
-			Amount:   transaction.Amount,
+			Type:      uint32(transaction.Type),
+			Sender:    transaction.Sender,
+			Receiver:  transaction.Receiver,
+			Amount:    transaction.Amount,
+			PublicKey: transaction.PublicKey,
+			Signature: transaction.Signature,
+			Inputs:    inputsToProto(transaction.Inputs),
+			Outputs:   outputsToProto(transaction.Outputs),
+			Nonce:     transaction.Nonce,
 		}
 	}
 
+	var pbEvidence []*pb.DoubleSignEvidence
+	for i := range b.Evidence {
+		pbEvidence = append(pbEvidence, b.Evidence[i].ToProto())
+	}
+
 	return &pb.Block{
 		Index:        b.Index,
 		Timestamp:    b.Timestamp,
@@ -82,5 +282,8 @@ func (b *Block) ToProto() *pb.Block {
 		Transactions: pbTransactions,
 		Data:         b.Data,
 		Checkpoint:   b.Checkpoint,
+		Signer:       b.Signer,
+		Signature:    b.Signature,
+		Evidence:     pbEvidence,
 	}
 }
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"crypto/sha256"
+	"strconv"
+
+	pb "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+)
+
+// VoteType tells the step of the finality protocol a vote belongs to.
+type VoteType uint32
+
+// Vote types. Validators prevote for the checkpoint block they see as canonical
+// and precommit to a block once more than two thirds of them prevoted for it in
+// the same round.
+const (
+	Prevote VoteType = iota
+	Precommit
+)
+
+// String returns the name of the vote type.
+func (t VoteType) String() string {
+	if t == Precommit {
+		return "precommit"
+	}
+	return "prevote"
+}
+
+// Vote is the signed vote of a finality validator for a checkpoint block in a
+// round. Validators vote again in a later round when a round fails.
+type Vote struct {
+	Type      VoteType
+	Height    uint64
+	Round     uint32
+	BlockHash []byte
+	Validator []byte
+	Signature []byte
+}
+
+// FinalityCertificate proves that a checkpoint block is final with the
+// precommits of more than two thirds of the finality validators.
+type FinalityCertificate struct {
+	Height     uint64
+	Round      uint32
+	BlockHash  []byte
+	Precommits []Vote
+}
+
+// SigningHash returns the hash a vote is signed over on the chain with the given ID.
+func (v *Vote) SigningHash(chainID string) []byte {
+	data := chainID + "\x00" + v.Type.String() + strconv.FormatUint(v.Height, 10) + "\x00" + strconv.FormatUint(uint64(v.Round), 10) + "\x00" + string(v.BlockHash)
+	hash := sha256.Sum256([]byte(data))
+	return hash[:]
+}
+
+// VoteFromProto converts a protobuf FinalityVote to a Vote.
+func VoteFromProto(pbVote *pb.FinalityVote) Vote {
+	return Vote{
+		Type:      VoteType(pbVote.GetType()),
+		Height:    pbVote.GetHeight(),
+		Round:     pbVote.GetRound(),
+		BlockHash: pbVote.GetBlockHash(),
+		Validator: pbVote.GetValidator(),
+		Signature: pbVote.GetSignature(),
+	}
+}
+
+// ToProto converts a Vote to a protobuf FinalityVote.
+func (v *Vote) ToProto() *pb.FinalityVote {
+	return &pb.FinalityVote{
+		Type:      uint32(v.Type),
+		Height:    v.Height,
+		Round:     v.Round,
+		BlockHash: v.BlockHash,
+		Validator: v.Validator,
+		Signature: v.Signature,
+	}
+}
+
+// FinalityCertificateFromProto converts a protobuf FinalityCertificate to a FinalityCertificate.
+func FinalityCertificateFromProto(pbCertificate *pb.FinalityCertificate) *FinalityCertificate {
+	precommits := make([]Vote, len(pbCertificate.GetPrecommits()))
+	for i, pbVote := range pbCertificate.GetPrecommits() {
+		precommits[i] = VoteFromProto(pbVote)
+	}
+	return &FinalityCertificate{
+		Height:     pbCertificate.GetHeight(),
+		Round:      pbCertificate.GetRound(),
+		BlockHash:  pbCertificate.GetBlockHash(),
+		Precommits: precommits,
+	}
+}
+
+// ToProto converts a FinalityCertificate to a protobuf FinalityCertificate.
+func (c *FinalityCertificate) ToProto() *pb.FinalityCertificate {
+	pbPrecommits := make([]*pb.FinalityVote, len(c.Precommits))
+	for i := range c.Precommits {
+		pbPrecommits[i] = c.Precommits[i].ToProto()
+	}
+	return &pb.FinalityCertificate{
+		Height:     c.Height,
+		Round:      c.Round,
+		BlockHash:  c.BlockHash,
+		Precommits: pbPrecommits,
+	}
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	pb "github.com/pabloaaa/GO_BLOCKCHAIN/protos"
+)
+
+// OutPoint references an output of a UTXO transaction.
+type OutPoint struct {
+	TxHash []byte
+	Index  uint32
+}
+
+// TxInput spends the output at PreviousOutput. Signature signs the SigningHash
+// of the spending transaction with the key the output is locked to.
+type TxInput struct {
+	PreviousOutput OutPoint
+	Signature      []byte
+}
+
+// TxOutput locks Amount to the holder of the private key of LockingKey, an
+// ed25519 public key.
+type TxOutput struct {
+	Amount     uint64
+	LockingKey []byte
+}
+
+// UTXO is an unspent transaction output together with where it is.
+type UTXO struct {
+	OutPoint OutPoint
+	Output   TxOutput
+	// Height is the height of the block that created the output.
+	Height uint64
+}
+
+// String returns the outpoint as the hex-encoded transaction hash and the
+// output index separated by a colon.
+func (o OutPoint) String() string {
+	return hex.EncodeToString(o.TxHash) + ":" + strconv.FormatUint(uint64(o.Index), 10)
+}
+
+// IsCoinbase reports whether the transaction is a UTXO transaction that
+// creates coins instead of spending outputs.
+func (t *Transaction) IsCoinbase() bool {
+	return t.Type == UTXOTransaction && len(t.Inputs) == 0
+}
+
+// OutPoint returns the outpoint of the output at index of the transaction.
+func (t *Transaction) OutPoint(index uint32) OutPoint {
+	return OutPoint{TxHash: t.CalculateHash(), Index: index}
+}
+
+// inputsFromProto converts protobuf TxInputs to TxInputs.
+func inputsFromProto(pbInputs []*pb.TxInput) []TxInput {
+	var inputs []TxInput
+	for _, pbInput := range pbInputs {
+		inputs = append(inputs, TxInput{
+			PreviousOutput: OutPoint{TxHash: pbInput.GetTxHash(), Index: pbInput.GetIndex()},
+			Signature:      pbInput.GetSignature(),
+		})
+	}
+	return inputs
+}
+
+// inputsToProto converts TxInputs to protobuf TxInputs.
+func inputsToProto(inputs []TxInput) []*pb.TxInput {
+	var pbInputs []*pb.TxInput
+	for _, input := range inputs {
+		pbInputs = append(pbInputs, &pb.TxInput{
+			TxHash:    input.PreviousOutput.TxHash,
+			Index:     input.PreviousOutput.Index,
+			Signature: input.Signature,
+		})
+	}
+	return pbInputs
+}
+
+// outputsFromProto converts protobuf TxOutputs to TxOutputs.
+func outputsFromProto(pbOutputs []*pb.TxOutput) []TxOutput {
+	var outputs []TxOutput
+	for _, pbOutput := range pbOutputs {
+		outputs = append(outputs, TxOutput{Amount: pbOutput.GetAmount(), LockingKey: pbOutput.GetLockingKey()})
+	}
+	return outputs
+}
+
+// outputsToProto converts TxOutputs to protobuf TxOutputs.
+func outputsToProto(outputs []TxOutput) []*pb.TxOutput {
+	var pbOutputs []*pb.TxOutput
+	for _, output := range outputs {
+		pbOutputs = append(pbOutputs, &pb.TxOutput{Amount: output.Amount, LockingKey: output.LockingKey})
+	}
+	return pbOutputs
+}
@@ -0,0 +1,165 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddressPrefix is the human-readable part of every address.
+const AddressPrefix = "gob"
+
+// addressHashSize is the number of public key hash bytes encoded in an address.
+const addressHashSize = 20
+
+// bech32Charset is the alphabet of the data part of an address.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var (
+	// ErrInvalidAddress is returned for strings that are not well-formed addresses.
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrAddressChecksum is returned for addresses whose checksum does not match, usually a typo.
+	ErrAddressChecksum = errors.New("address checksum mismatch")
+)
+
+// AddressFromPublicKey returns the address of a public key: the bech32 encoding
+// of the first 20 bytes of its SHA-256 hash, with the AddressPrefix.
+func AddressFromPublicKey(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return encodeBech32(AddressPrefix, sum[:addressHashSize])
+}
+
+// DecodeAddress validates an address and returns the public key hash it encodes.
+func DecodeAddress(address string) ([]byte, error) {
+	prefix, hash, err := decodeBech32(address)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != AddressPrefix {
+		return nil, fmt.Errorf("%w: unexpected prefix %q", ErrInvalidAddress, prefix)
+	}
+	if len(hash) != addressHashSize {
+		return nil, fmt.Errorf("%w: unexpected length %d", ErrInvalidAddress, len(hash))
+	}
+	return hash, nil
+}
+
+// ValidateAddress reports whether address is a well-formed address with a valid checksum.
+func ValidateAddress(address string) error {
+	_, err := DecodeAddress(address)
+	return err
+}
+
+// encodeBech32 encodes data as a BIP-173 bech32 string.
+func encodeBech32(prefix string, data []byte) string {
+	values := convertBits(data, 8, 5, true)
+	checksum := bech32Checksum(prefix, values)
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteByte('1')
+	for _, v := range append(values, checksum...) {
+		sb.WriteByte(bech32Charset[v])
+	}
+	return sb.String()
+}
+
+// decodeBech32 decodes a BIP-173 bech32 string and verifies its checksum.
+func decodeBech32(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("%w: mixed case", ErrInvalidAddress)
+	}
+	s = strings.ToLower(s)
+
+	separator := strings.LastIndexByte(s, '1')
+	if separator < 1 || separator+7 > len(s) {
+		return "", nil, fmt.Errorf("%w: missing separator or checksum", ErrInvalidAddress)
+	}
+	prefix := s[:separator]
+
+	values := make([]byte, 0, len(s)-separator-1)
+	for _, c := range s[separator+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", nil, fmt.Errorf("%w: invalid character %q", ErrInvalidAddress, c)
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32ExpandPrefix(prefix), values...)) != 1 {
+		return "", nil, ErrAddressChecksum
+	}
+
+	data := convertBits(values[:len(values)-6], 5, 8, false)
+	if data == nil {
+		return "", nil, fmt.Errorf("%w: invalid padding", ErrInvalidAddress)
+	}
+	return prefix, data, nil
+}
+
+// bech32Checksum computes the six checksum values of a bech32 string.
+func bech32Checksum(prefix string, values []byte) []byte {
+	input := append(bech32ExpandPrefix(prefix), values...)
+	input = append(input, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(input) ^ 1
+
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(polymod>>uint(5*(5-i))) & 31
+	}
+	return checksum
+}
+
+// bech32ExpandPrefix expands the human-readable part for checksum computation.
+func bech32ExpandPrefix(prefix string) []byte {
+	expanded := make([]byte, 0, len(prefix)*2+1)
+	for i := 0; i < len(prefix); i++ {
+		expanded = append(expanded, prefix[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(prefix); i++ {
+		expanded = append(expanded, prefix[i]&31)
+	}
+	return expanded
+}
+
+// bech32Polymod computes the BCH checksum polynomial of values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// convertBits regroups data from fromBits-bit to toBits-bit values. It returns
+// nil if pad is false and the input has non-zero or excess padding.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc, bits uint
+	maxValue := uint(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, value := range data {
+		acc = acc<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil
+	}
+	return out
+}
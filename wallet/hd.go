@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// CoinType is the BIP-44 coin type used in derivation paths.
+const CoinType = 1
+
+// hardenedOffset marks a hardened derivation index.
+const hardenedOffset = 0x80000000
+
+// mnemonicEntropyBits is the entropy of generated seed phrases: 24 words.
+const mnemonicEntropyBits = 256
+
+// NewMnemonic generates a new 24-word BIP-39 seed phrase.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic validates a BIP-39 seed phrase and returns the seed it
+// encodes. The optional passphrase yields a different seed for the same phrase.
+func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid seed phrase")
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// DerivationPath returns the BIP-44 path of the key with the given account and index.
+func DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0'/%d'", CoinType, account, index)
+}
+
+// DeriveKey derives the key at path from seed following SLIP-10 for ed25519,
+// which only supports hardened derivation, so every path element must end in '.
+func DeriveKey(seed []byte, path string) (*Key, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, chainCode := slip10Split(hmacSHA512([]byte("ed25519 seed"), seed))
+	for _, index := range indexes {
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index)
+		key, chainCode = slip10Split(hmacSHA512(chainCode, data))
+	}
+	return NewKeyFromSeed(key)
+}
+
+// parseDerivationPath parses a path such as m/44'/1'/0'/0'/0'.
+func parseDerivationPath(path string) ([]uint32, error) {
+	elements := strings.Split(path, "/")
+	if elements[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q must start with m", path)
+	}
+
+	indexes := make([]uint32, 0, len(elements)-1)
+	for _, element := range elements[1:] {
+		if !strings.HasSuffix(element, "'") {
+			return nil, fmt.Errorf("derivation path element %q must be hardened", element)
+		}
+		index, err := strconv.ParseUint(strings.TrimSuffix(element, "'"), 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path element %q", element)
+		}
+		indexes = append(indexes, uint32(index)+hardenedOffset)
+	}
+	return indexes, nil
+}
+
+// hmacSHA512 computes HMAC-SHA512 of data with key.
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// slip10Split splits an HMAC output into the child key and chain code.
+func slip10Split(digest []byte) ([]byte, []byte) {
+	return digest[:32], digest[32:]
+}
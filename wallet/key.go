@@ -0,0 +1,83 @@
+// Package wallet manages the ed25519 keys that own funds on the blockchain:
+// key generation, HD derivation from a seed phrase, the address format and an
+// encrypted keystore.
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/types"
+)
+
+// Key is an ed25519 signing key.
+type Key struct {
+	privateKey ed25519.PrivateKey
+}
+
+// GenerateKey creates a new random Key.
+func GenerateKey() (*Key, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{privateKey: privateKey}, nil
+}
+
+// NewKeyFromSeed creates the Key for a 32-byte ed25519 seed.
+func NewKeyFromSeed(seed []byte) (*Key, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("key seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return &Key{privateKey: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// Seed returns the 32-byte seed the key can be recreated from.
+func (k *Key) Seed() []byte {
+	return k.privateKey.Seed()
+}
+
+// PublicKey returns the public half of the key.
+func (k *Key) PublicKey() ed25519.PublicKey {
+	return k.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Address returns the address owned by the key.
+func (k *Key) Address() string {
+	return AddressFromPublicKey(k.PublicKey())
+}
+
+// Sign signs message with the key.
+func (k *Key) Sign(message []byte) []byte {
+	return ed25519.Sign(k.privateKey, message)
+}
+
+// SignTransaction makes the key's address the sender of tx and signs it for the
+// chain with the given ID.
+func (k *Key) SignTransaction(tx *types.Transaction, chainID string) {
+	tx.Sender = []byte(k.Address())
+	tx.PublicKey = k.PublicKey()
+	tx.Signature = k.Sign(tx.SigningHash(chainID))
+}
+
+// SignInputs signs every input of the UTXO transaction tx for the chain with the
+// given ID. The outputs the inputs spend must be locked to the key.
+func (k *Key) SignInputs(tx *types.Transaction, chainID string) {
+	signature := k.Sign(tx.SigningHash(chainID))
+	for i := range tx.Inputs {
+		tx.Inputs[i].Signature = signature
+	}
+}
+
+// SignVote makes the key the validator of vote and signs it for the chain with
+// the given ID.
+func (k *Key) SignVote(vote *types.Vote, chainID string) {
+	vote.Validator = k.PublicKey()
+	vote.Signature = k.Sign(vote.SigningHash(chainID))
+}
+
+// Verify reports whether signature is a valid signature of message by publicKey.
+func Verify(publicKey ed25519.PublicKey, message, signature []byte) bool {
+	return len(publicKey) == ed25519.PublicKeySize && ed25519.Verify(publicKey, message, signature)
+}
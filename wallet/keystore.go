@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// StandardScryptN is the scrypt cost used for new keystore files.
+	StandardScryptN = 1 << 18
+	// LightScryptN is a cheaper scrypt cost for tests and constrained devices.
+	LightScryptN = 1 << 12
+
+	keystoreVersion = 1
+	scryptR         = 8
+	scryptP         = 1
+	saltSize        = 32
+	keyFileSuffix   = ".json"
+)
+
+var (
+	// ErrWrongPassphrase is returned when a keystore file cannot be decrypted.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrKeyNotFound is returned when the keystore has no file for an address.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists is returned when storing a key that is already in the keystore.
+	ErrKeyExists = errors.New("key already exists")
+)
+
+// keyFile is the JSON representation of an encrypted key.
+type keyFile struct {
+	Version int        `json:"version"`
+	Address string     `json:"address"`
+	Crypto  cryptoJSON `json:"crypto"`
+}
+
+// cryptoJSON describes how the key seed in a keyFile is encrypted.
+type cryptoJSON struct {
+	KDF        string     `json:"kdf"`
+	KDFParams  scryptJSON `json:"kdfparams"`
+	Cipher     string     `json:"cipher"`
+	Nonce      string     `json:"nonce"`
+	Ciphertext string     `json:"ciphertext"`
+}
+
+// scryptJSON holds the scrypt parameters of a keyFile.
+type scryptJSON struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// Keystore keeps keys in a directory, one passphrase-encrypted file per address.
+// The key seed is encrypted with AES-256-GCM under a key derived with scrypt.
+type Keystore struct {
+	dir     string
+	scryptN int
+}
+
+// KeystoreOption configures optional Keystore settings.
+type KeystoreOption func(*Keystore)
+
+// WithScryptN sets the scrypt cost parameter used for new key files.
+func WithScryptN(n int) KeystoreOption {
+	return func(ks *Keystore) {
+		ks.scryptN = n
+	}
+}
+
+// NewKeystore creates a Keystore backed by dir, which is created when the first key is stored.
+func NewKeystore(dir string, opts ...KeystoreOption) *Keystore {
+	ks := &Keystore{dir: dir, scryptN: StandardScryptN}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	return ks
+}
+
+// Store encrypts key with passphrase and writes it to the keystore.
+func (ks *Keystore) Store(key *Key, passphrase string) error {
+	address := key.Address()
+	path := ks.path(address)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%w: %s", ErrKeyExists, address)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := ks.cipher(passphrase, salt, ks.scryptN, scryptR, scryptP)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ciphertext := aead.Seal(nil, nonce, key.Seed(), []byte(address))
+
+	data, err := json.MarshalIndent(keyFile{
+		Version: keystoreVersion,
+		Address: address,
+		Crypto: cryptoJSON{
+			KDF:        "scrypt",
+			KDFParams:  scryptJSON{N: ks.scryptN, R: scryptR, P: scryptP, Salt: hex.EncodeToString(salt)},
+			Cipher:     "aes-256-gcm",
+			Nonce:      hex.EncodeToString(nonce),
+			Ciphertext: hex.EncodeToString(ciphertext),
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(ks.dir, 0o700); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Load decrypts the key of address with passphrase.
+func (ks *Keystore) Load(address, passphrase string) (*Key, error) {
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(ks.path(address))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file of %s: %w", address, err)
+	}
+	if file.Version != keystoreVersion || file.Crypto.KDF != "scrypt" || file.Crypto.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported key file format for %s", address)
+	}
+	params := file.Crypto.KDFParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in key file of %s", address)
+	}
+	nonce, err := hex.DecodeString(file.Crypto.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce in key file of %s", address)
+	}
+	ciphertext, err := hex.DecodeString(file.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext in key file of %s", address)
+	}
+
+	aead, err := ks.cipher(passphrase, salt, params.N, params.R, params.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce in key file of %s", address)
+	}
+	seed, err := aead.Open(nil, nonce, ciphertext, []byte(address))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	key, err := NewKeyFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	if key.Address() != address {
+		return nil, fmt.Errorf("key file of %s holds a different key", address)
+	}
+	return key, nil
+}
+
+// List returns the addresses of all stored keys in sorted order.
+func (ks *Keystore) List() ([]string, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, entry := range entries {
+		address, ok := strings.CutSuffix(entry.Name(), keyFileSuffix)
+		if ok && !entry.IsDir() && ValidateAddress(address) == nil {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// path returns the key file path of address.
+func (ks *Keystore) path(address string) string {
+	return filepath.Join(ks.dir, address+keyFileSuffix)
+}
+
+// cipher derives the AES-256-GCM cipher for passphrase and the scrypt parameters.
+func (ks *Keystore) cipher(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pabloaaa/GO_BLOCKCHAIN/wallet"
+)
+
+// defaultKeystoreDir is the keystore directory used when -keystore is not given.
+const defaultKeystoreDir = "keystore"
+
+// runWalletCommand runs the "wallet" subcommands.
+func runWalletCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("expected a wallet subcommand: create, list, import or export")
+	}
+
+	switch args[0] {
+	case "create":
+		return runWalletCreate(args[1:])
+	case "list":
+		return runWalletList(args[1:])
+	case "import":
+		return runWalletImport(args[1:])
+	case "export":
+		return runWalletExport(args[1:])
+	default:
+		return fmt.Errorf("unknown wallet subcommand %q", args[0])
+	}
+}
+
+// runWalletCreate generates a seed phrase and stores the first key derived from it.
+func runWalletCreate(args []string) error {
+	flags := flag.NewFlagSet("wallet create", flag.ContinueOnError)
+	keystoreDir := flags.String("keystore", defaultKeystoreDir, "keystore directory")
+	passphraseFile := flags.String("passphrase-file", "", "file holding the keystore passphrase")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	mnemonic, err := wallet.NewMnemonic()
+	if err != nil {
+		return err
+	}
+	seed, err := wallet.SeedFromMnemonic(mnemonic, "")
+	if err != nil {
+		return err
+	}
+	key, err := wallet.DeriveKey(seed, wallet.DerivationPath(0, 0))
+	if err != nil {
+		return err
+	}
+
+	if err := storeKey(*keystoreDir, *passphraseFile, key); err != nil {
+		return err
+	}
+	fmt.Printf("Address: %s\n", key.Address())
+	fmt.Printf("Seed phrase (write it down, it is the only way to recover the key):\n%s\n", mnemonic)
+	return nil
+}
+
+// runWalletList prints the addresses of all keys in the keystore.
+func runWalletList(args []string) error {
+	flags := flag.NewFlagSet("wallet list", flag.ContinueOnError)
+	keystoreDir := flags.String("keystore", defaultKeystoreDir, "keystore directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	addresses, err := wallet.NewKeystore(*keystoreDir).List()
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		fmt.Println(address)
+	}
+	return nil
+}
+
+// runWalletImport stores a key given as a hex seed or derived from a seed phrase.
+func runWalletImport(args []string) error {
+	flags := flag.NewFlagSet("wallet import", flag.ContinueOnError)
+	keystoreDir := flags.String("keystore", defaultKeystoreDir, "keystore directory")
+	passphraseFile := flags.String("passphrase-file", "", "file holding the keystore passphrase")
+	keyHex := flags.String("key", "", "hex encoded private key seed")
+	mnemonic := flags.String("mnemonic", "", "seed phrase to derive the key from")
+	account := flags.Uint("account", 0, "account of the derived key")
+	index := flags.Uint("index", 0, "index of the derived key")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*keyHex == "") == (*mnemonic == "") {
+		return errors.New("exactly one of -key and -mnemonic is required")
+	}
+
+	var key *wallet.Key
+	if *keyHex != "" {
+		seed, err := hex.DecodeString(*keyHex)
+		if err != nil {
+			return fmt.Errorf("invalid -key: %w", err)
+		}
+		if key, err = wallet.NewKeyFromSeed(seed); err != nil {
+			return err
+		}
+	} else {
+		seed, err := wallet.SeedFromMnemonic(*mnemonic, "")
+		if err != nil {
+			return err
+		}
+		if key, err = wallet.DeriveKey(seed, wallet.DerivationPath(uint32(*account), uint32(*index))); err != nil {
+			return err
+		}
+	}
+
+	if err := storeKey(*keystoreDir, *passphraseFile, key); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s\n", key.Address())
+	return nil
+}
+
+// runWalletExport prints the hex encoded private key seed of an address.
+func runWalletExport(args []string) error {
+	flags := flag.NewFlagSet("wallet export", flag.ContinueOnError)
+	keystoreDir := flags.String("keystore", defaultKeystoreDir, "keystore directory")
+	passphraseFile := flags.String("passphrase-file", "", "file holding the keystore passphrase")
+	address := flags.String("address", "", "address of the key to export")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *address == "" {
+		return errors.New("-address is required")
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	key, err := wallet.NewKeystore(*keystoreDir).Load(*address, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(key.Seed()))
+	return nil
+}
+
+// storeKey encrypts key with the passphrase and stores it in the keystore.
+func storeKey(keystoreDir, passphraseFile string, key *wallet.Key) error {
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return err
+	}
+	return wallet.NewKeystore(keystoreDir).Store(key, passphrase)
+}
+
+// readPassphrase reads the passphrase from a file, or from standard input if no file is given.
+func readPassphrase(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}